@@ -2,6 +2,7 @@ package cluster
 
 import (
 	"context"
+	"git.multiverse.io/eventkit/kit/cache/v1"
 	"github.com/go-redis/redis/v8"
 	"time"
 )
@@ -30,3 +31,31 @@ func (c Operator) HGet(ctx context.Context, key, field string) (string, error) {
 func (c Operator) HGetAll(ctx context.Context, key string) (map[string]string, error) {
 	return c.ClusterClient.HGetAll(ctx, key).Result()
 }
+
+// PSubscribe Redis `PSUBSCRIBE pattern [pattern ...]` command.
+func (c Operator) PSubscribe(ctx context.Context, patterns ...string) *redis.PubSub {
+	return c.ClusterClient.PSubscribe(ctx, patterns...)
+}
+
+// HGetPipelined executes the Redis `HGET key field` commands in a pipeline.
+func (c Operator) HGetPipelined(ctx context.Context, keyFields []cache.KeyField) ([]string, []error) {
+	values := make([]string, len(keyFields))
+	errs := make([]error, len(keyFields))
+	if len(keyFields) == 0 {
+		return values, errs
+	}
+
+	cmds := make([]*redis.StringCmd, len(keyFields))
+	// the errors are also held by each command, e.g. redis.Nil for the fields not exists
+	_, _ = c.ClusterClient.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, keyField := range keyFields {
+			cmds[i] = pipe.HGet(ctx, keyField.Key, keyField.Field)
+		}
+		return nil
+	})
+	for i, cmd := range cmds {
+		values[i], errs[i] = cmd.Result()
+	}
+
+	return values, errs
+}
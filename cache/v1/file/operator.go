@@ -0,0 +1,225 @@
+package file
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"git.multiverse.io/eventkit/kit/cache/v1/memory"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/log"
+	"github.com/fsnotify/fsnotify"
+	"github.com/pelletier/go-toml/v2"
+)
+
+// TopicRoute is the route from topic to SU type
+type TopicRoute struct {
+	Tenant      string `json:"tenant" toml:"tenant"`
+	Workspace   string `json:"workspace" toml:"workspace"`
+	Environment string `json:"environment" toml:"environment"`
+	TopicType   string `json:"topicType" toml:"topicType"`
+	TopicID     string `json:"topicID" toml:"topicID"`
+	SuType      string `json:"suType" toml:"suType"`
+}
+
+// ElementRoute is the route from element to SU
+type ElementRoute struct {
+	Tenant       string `json:"tenant" toml:"tenant"`
+	Workspace    string `json:"workspace" toml:"workspace"`
+	Environment  string `json:"environment" toml:"environment"`
+	ElementType  string `json:"elementType" toml:"elementType"`
+	ElementClass string `json:"elementClass" toml:"elementClass"`
+	ElementID    string `json:"elementID" toml:"elementID"`
+	SuType       string `json:"suType" toml:"suType"`
+	Su           string `json:"su" toml:"su"`
+}
+
+// RoutingTable is the static routing table of the file backend,
+// the `strings` and `hashes` sections are the raw keys, same as the keys in the addressing redis.
+type RoutingTable struct {
+	Topics   []TopicRoute                 `json:"topics" toml:"topics"`
+	Elements []ElementRoute               `json:"elements" toml:"elements"`
+	Strings  map[string]string            `json:"strings" toml:"strings"`
+	Hashes   map[string]map[string]string `json:"hashes" toml:"hashes"`
+}
+
+// Operator is a cache operator that reads the routing data from a static TOML/JSON file,
+// the file is reloaded automatically when it changed.
+type Operator struct {
+	*memory.Operator
+	filePath     string
+	topicSuTitle string
+
+	lock        sync.Mutex
+	watcher     *fsnotify.Watcher
+	reloadHooks []func()
+}
+
+// NewOperator creates a new file operator and loads the routing table from the file
+func NewOperator(filePath, topicSuTitle string) (*Operator, error) {
+	o := &Operator{
+		Operator:     memory.NewOperator(),
+		filePath:     filePath,
+		topicSuTitle: topicSuTitle,
+	}
+	if err := o.Reload(); nil != err {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// Reload reads the routing table from the file and replaces all the routing data
+func (o *Operator) Reload() error {
+	content, err := ioutil.ReadFile(o.filePath)
+	if nil != err {
+		return err
+	}
+	// the file may be truncated and not written yet
+	if len(strings.TrimSpace(string(content))) == 0 {
+		return fmt.Errorf("the routing table file[%s] is empty", o.filePath)
+	}
+
+	table := &RoutingTable{}
+	if strings.EqualFold(filepath.Ext(o.filePath), ".json") {
+		err = json.Unmarshal(content, table)
+	} else {
+		err = toml.Unmarshal(content, table)
+	}
+	if nil != err {
+		return fmt.Errorf("failed to parse the routing table file[%s], error:%v", o.filePath, err)
+	}
+
+	stringValues, hashValues := table.toKeys(o.topicSuTitle)
+	o.Operator.Load(stringValues, hashValues)
+
+	o.lock.Lock()
+	hooks := append([]func(){}, o.reloadHooks...)
+	o.lock.Unlock()
+	for _, hook := range hooks {
+		hook()
+	}
+
+	log.Infosf("Successfully to load the routing table file[%s], topics:[%d], elements:[%d], strings:[%d], hashes:[%d]",
+		o.filePath, len(table.Topics), len(table.Elements), len(table.Strings), len(table.Hashes))
+	return nil
+}
+
+// RegisterReloadHook registers a function that will be called after the routing table reloaded
+func (o *Operator) RegisterReloadHook(hook func()) {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	o.reloadHooks = append(o.reloadHooks, hook)
+}
+
+// Watch watches the routing table file and reloads it when it changed,
+// the directory is watched so that the file replaced by editors(rename) can also be detected.
+func (o *Operator) Watch() error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if nil != o.watcher {
+		return nil
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if nil != err {
+		return err
+	}
+	if err = watcher.Add(filepath.Dir(o.filePath)); nil != err {
+		watcher.Close()
+		return err
+	}
+	o.watcher = watcher
+
+	go func() {
+		fileName := filepath.Clean(o.filePath)
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != fileName ||
+					0 == event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+					continue
+				}
+				if err := o.Reload(); nil != err {
+					log.Errorsf("Failed to reload the routing table file[%s], keep the previous routing data, error:%v", o.filePath, err)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Errorsf("Failed to watch the routing table file[%s], error:%v", o.filePath, err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Close stops watching the routing table file
+func (o *Operator) Close() error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	if nil == o.watcher {
+		return nil
+	}
+	err := o.watcher.Close()
+	o.watcher = nil
+
+	return err
+}
+
+// toKeys converts the routing table into the keys that same as the keys in the addressing redis
+func (r *RoutingTable) toKeys(topicSuTitle string) (map[string]string, map[string]map[string]string) {
+	stringValues := make(map[string]string)
+	hashValues := make(map[string]map[string]string)
+	hset := func(key, field, value string) {
+		hash, ok := hashValues[key]
+		if !ok {
+			hash = make(map[string]string)
+			hashValues[key] = hash
+		}
+		hash[field] = value
+	}
+
+	for k, v := range r.Strings {
+		stringValues[k] = v
+	}
+	for k, hash := range r.Hashes {
+		for field, v := range hash {
+			hset(k, field, v)
+		}
+	}
+
+	for _, topic := range r.Topics {
+		topicType := topic.TopicType
+		if "" == topicType {
+			topicType = constant.TopicTypeBusiness
+		}
+		stringValues[fmt.Sprintf("%s.%s.%s.%s.%s.%s", topicSuTitle,
+			topic.Tenant, topic.Workspace, topic.Environment, topicType, topic.TopicID)] = topic.SuType
+		hset(topicSuTitle, fmt.Sprintf("%s.%s.%s.%s.%s",
+			topic.Tenant, topic.Workspace, topic.Environment, topicType, topic.TopicID), topic.SuType)
+	}
+
+	for _, element := range r.Elements {
+		// keep the same as the addressing wrapper, the element class is defaulted to the element type before truncated
+		elementType := (element.ElementType + "   ")[0:3]
+		elementClass := element.ElementClass
+		if "" == elementClass {
+			elementClass = element.ElementType
+		}
+		hset(fmt.Sprintf("CIF.%s.%s.%s.%s.%s.%s",
+			element.Tenant, element.Workspace, element.Environment,
+			elementType, elementClass, element.ElementID), element.SuType, element.Su)
+	}
+
+	return stringValues, hashValues
+}
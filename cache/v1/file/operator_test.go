@@ -0,0 +1,92 @@
+package file
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"github.com/go-redis/redis/v8"
+)
+
+const tomlRoutingTable = `
+[[topics]]
+tenant = "org"
+workspace = "wks"
+environment = "env"
+topicID = "QueryCustomer"
+suType = "CUSSU"
+
+[[elements]]
+tenant = "org"
+workspace = "wks"
+environment = "env"
+elementType = "CUSTOMER"
+elementID = "001"
+suType = "CUSSU"
+su = "CUSSU01"
+
+[hashes."CUS.CUS.002"]
+CUSSU = "CUSSU02"
+`
+
+func TestOperator_TOML(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "routing.toml")
+	assert.True(t, nil == ioutil.WriteFile(filePath, []byte(tomlRoutingTable), 0644))
+
+	operator, err := NewOperator(filePath, "TOP.GLSTOPIC")
+	assert.True(t, nil == err)
+	ctx := context.Background()
+
+	v, err := operator.HGet(ctx, "TOP.GLSTOPIC", "org.wks.env.TRN.QueryCustomer")
+	assert.True(t, nil == err)
+	assert.Equal(t, "CUSSU", v)
+	v, _ = operator.Get(ctx, "TOP.GLSTOPIC.org.wks.env.TRN.QueryCustomer")
+	assert.Equal(t, "CUSSU", v)
+
+	v, err = operator.HGet(ctx, "CIF.org.wks.env.CUS.CUSTOMER.001", "CUSSU")
+	assert.True(t, nil == err)
+	assert.Equal(t, "CUSSU01", v)
+
+	v, _ = operator.HGet(ctx, "CUS.CUS.002", "CUSSU")
+	assert.Equal(t, "CUSSU02", v)
+}
+
+func TestOperator_JSONAndWatch(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "routing.json")
+	assert.True(t, nil == ioutil.WriteFile(filePath, []byte(`{"strings":{"k1":"v1"}}`), 0644))
+
+	operator, err := NewOperator(filePath, "TOP.GLSTOPIC")
+	assert.True(t, nil == err)
+	defer operator.Close()
+	reloaded := make(chan struct{}, 10)
+	operator.RegisterReloadHook(func() {
+		reloaded <- struct{}{}
+	})
+	assert.True(t, nil == operator.Watch())
+
+	v, _ := operator.Get(context.Background(), "k1")
+	assert.Equal(t, "v1", v)
+
+	assert.True(t, nil == ioutil.WriteFile(filePath, []byte(`{"strings":{"k2":"v2"}}`), 0644))
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the routing table file is not reloaded")
+	}
+	// the write may trigger more than one event, wait for the content to be reloaded
+	for i := 0; i < 50; i++ {
+		if v, _ = operator.Get(context.Background(), "k2"); "v2" == v {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, "v2", v)
+	_, err = operator.Get(context.Background(), "k1")
+	assert.Equal(t, redis.Nil, err)
+
+	_, err = NewOperator(filepath.Join(t.TempDir(), "not-exists.toml"), "TOP.GLSTOPIC")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,181 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+type stringValue struct {
+	value    string
+	expireAt time.Time
+}
+
+// Operator is an in-memory implement of cache operator, mainly used for testing and local development,
+// the missing keys and fields return redis.Nil as same as the redis operators.
+type Operator struct {
+	lock    sync.RWMutex
+	strings map[string]stringValue
+	hashes  map[string]map[string]string
+}
+
+// NewOperator creates a new in-memory operator
+func NewOperator() *Operator {
+	return &Operator{
+		strings: make(map[string]stringValue),
+		hashes:  make(map[string]map[string]string),
+	}
+}
+
+// Set sets the value of key, 0 expiration means the key has no expiration time.
+func (m *Operator) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	v := stringValue{value: toString(value)}
+	if expiration > 0 {
+		v.expireAt = time.Now().Add(expiration)
+	}
+	m.strings[key] = v
+	delete(m.hashes, key)
+
+	return nil
+}
+
+// Get returns the value of key, returns redis.Nil if the key does not exist.
+func (m *Operator) Get(ctx context.Context, key string) (string, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	v, ok := m.strings[key]
+	if !ok || (!v.expireAt.IsZero() && time.Now().After(v.expireAt)) {
+		return "", redis.Nil
+	}
+
+	return v.value, nil
+}
+
+// HGet returns the value of the field of hash, returns redis.Nil if the key or field does not exist.
+func (m *Operator) HGet(ctx context.Context, key, field string) (string, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	if hash, ok := m.hashes[key]; ok {
+		if v, ok := hash[field]; ok {
+			return v, nil
+		}
+	}
+
+	return "", redis.Nil
+}
+
+// HGetAll returns all the fields of hash, returns an empty map if the key does not exist.
+func (m *Operator) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	result := make(map[string]string)
+	for k, v := range m.hashes[key] {
+		result[k] = v
+	}
+
+	return result, nil
+}
+
+// HSet sets the value of the field of hash
+func (m *Operator) HSet(ctx context.Context, key, field string, value interface{}) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	hash, ok := m.hashes[key]
+	if !ok {
+		hash = make(map[string]string)
+		m.hashes[key] = hash
+	}
+	hash[field] = toString(value)
+	delete(m.strings, key)
+
+	return nil
+}
+
+// HDel deletes the fields of hash
+func (m *Operator) HDel(ctx context.Context, key string, fields ...string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if hash, ok := m.hashes[key]; ok {
+		for _, field := range fields {
+			delete(hash, field)
+		}
+		if len(hash) == 0 {
+			delete(m.hashes, key)
+		}
+	}
+
+	return nil
+}
+
+// Del deletes the keys
+func (m *Operator) Del(ctx context.Context, keys ...string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	for _, key := range keys {
+		delete(m.strings, key)
+		delete(m.hashes, key)
+	}
+
+	return nil
+}
+
+// Flush deletes all the keys
+func (m *Operator) Flush() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.strings = make(map[string]stringValue)
+	m.hashes = make(map[string]map[string]string)
+}
+
+// Load replaces all the keys with the strings and hashes
+func (m *Operator) Load(strings map[string]string, hashes map[string]map[string]string) {
+	newStrings := make(map[string]stringValue, len(strings))
+	for k, v := range strings {
+		newStrings[k] = stringValue{value: v}
+	}
+	newHashes := make(map[string]map[string]string, len(hashes))
+	for k, hash := range hashes {
+		newHash := make(map[string]string, len(hash))
+		for field, v := range hash {
+			newHash[field] = v
+		}
+		newHashes[k] = newHash
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.strings = newStrings
+	m.hashes = newHashes
+}
+
+func toString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+var defaultOperator = NewOperator()
+
+// Default returns the in-memory operator that used by the `memory` addressing cache backend,
+// the routing data can be preset via it in tests.
+func Default() *Operator {
+	return defaultOperator
+}
@@ -0,0 +1,56 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"github.com/go-redis/redis/v8"
+)
+
+func TestOperator(t *testing.T) {
+	ctx := context.Background()
+	operator := NewOperator()
+
+	_, err := operator.Get(ctx, "k1")
+	assert.Equal(t, redis.Nil, err)
+	assert.True(t, nil == operator.Set(ctx, "k1", "v1", 0))
+	v, err := operator.Get(ctx, "k1")
+	assert.True(t, nil == err)
+	assert.Equal(t, "v1", v)
+
+	operator.Set(ctx, "k2", 2, time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	_, err = operator.Get(ctx, "k2")
+	assert.Equal(t, redis.Nil, err)
+
+	operator.HSet(ctx, "h1", "f1", "v1")
+	operator.HSet(ctx, "h1", "f2", "v2")
+	v, err = operator.HGet(ctx, "h1", "f2")
+	assert.True(t, nil == err)
+	assert.Equal(t, "v2", v)
+	_, err = operator.HGet(ctx, "h1", "f3")
+	assert.Equal(t, redis.Nil, err)
+
+	all, _ := operator.HGetAll(ctx, "h1")
+	assert.Equal(t, 2, len(all))
+
+	operator.HDel(ctx, "h1", "f1")
+	_, err = operator.HGet(ctx, "h1", "f1")
+	assert.Equal(t, redis.Nil, err)
+
+	operator.Del(ctx, "h1", "k1")
+	all, _ = operator.HGetAll(ctx, "h1")
+	assert.Equal(t, 0, len(all))
+
+	operator.Load(map[string]string{"k3": "v3"}, map[string]map[string]string{"h2": {"f": "v"}})
+	v, _ = operator.Get(ctx, "k3")
+	assert.Equal(t, "v3", v)
+	v, _ = operator.HGet(ctx, "h2", "f")
+	assert.Equal(t, "v", v)
+
+	operator.Flush()
+	_, err = operator.Get(ctx, "k3")
+	assert.Equal(t, redis.Nil, err)
+}
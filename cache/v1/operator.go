@@ -14,3 +14,30 @@ type Operator interface {
 	HGet(ctx context.Context, key, field string) (string, error)
 	HGetAll(ctx context.Context, key string) (map[string]string, error)
 }
+
+// KeyField is the key and field pair of a redis hash
+type KeyField struct {
+	Key   string
+	Field string
+}
+
+// PipelineOperator is an interface that implemented by the operators that support pipelining `HGET` commands
+type PipelineOperator interface {
+	// HGetPipelined executes the `HGET` commands in a pipeline, the values and errors are in the same order of keyFields
+	HGetPipelined(ctx context.Context, keyFields []KeyField) ([]string, []error)
+}
+
+// HGetMulti executes the `HGET` commands in a pipeline if the operator supports, otherwise executes them one by one
+func HGetMulti(ctx context.Context, operator Operator, keyFields []KeyField) ([]string, []error) {
+	if pipelineOperator, ok := operator.(PipelineOperator); ok {
+		return pipelineOperator.HGetPipelined(ctx, keyFields)
+	}
+
+	values := make([]string, len(keyFields))
+	errs := make([]error, len(keyFields))
+	for i, keyField := range keyFields {
+		values[i], errs[i] = operator.HGet(ctx, keyField.Key, keyField.Field)
+	}
+
+	return values, errs
+}
@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"git.multiverse.io/eventkit/kit/handler/config"
+)
+
+// Define the names of the built-in addressing cache backends
+const (
+	BackendRedis  = "redis"
+	BackendMemory = "memory"
+	BackendFile   = "file"
+)
+
+// BackendFactory creates a new addressing cache operator with the addressing config
+type BackendFactory func(addressingConfig *config.Addressing) (Operator, error)
+
+var (
+	backendsLock sync.RWMutex
+	backends     = make(map[string]BackendFactory)
+)
+
+// RegisterBackend registers an addressing cache backend with name, the backend is selected by `addressing.cache.type`,
+// the backend registered later will replace the previous one with the same name.
+func RegisterBackend(name string, factory BackendFactory) {
+	backendsLock.Lock()
+	defer backendsLock.Unlock()
+
+	backends[strings.ToLower(name)] = factory
+}
+
+// GetBackend returns the addressing cache backend with name
+func GetBackend(name string) (BackendFactory, bool) {
+	backendsLock.RLock()
+	defer backendsLock.RUnlock()
+
+	factory, ok := backends[strings.ToLower(name)]
+	return factory, ok
+}
+
+// RegisteredBackends returns the names of all the registered backends
+func RegisteredBackends() []string {
+	backendsLock.RLock()
+	defer backendsLock.RUnlock()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
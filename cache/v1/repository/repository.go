@@ -4,7 +4,10 @@ import (
 	"context"
 	"git.multiverse.io/eventkit/kit/cache/v1"
 	"git.multiverse.io/eventkit/kit/cache/v1/cluster"
+	"git.multiverse.io/eventkit/kit/cache/v1/file"
+	"git.multiverse.io/eventkit/kit/cache/v1/memory"
 	"git.multiverse.io/eventkit/kit/cache/v1/singleton"
+	"git.multiverse.io/eventkit/kit/cache/v1/tiered"
 	"git.multiverse.io/eventkit/kit/client/mesh"
 	"git.multiverse.io/eventkit/kit/common/errors"
 	"git.multiverse.io/eventkit/kit/common/model/glsdef"
@@ -12,6 +15,7 @@ import (
 	"git.multiverse.io/eventkit/kit/handler/config"
 	"git.multiverse.io/eventkit/kit/log"
 	"github.com/go-redis/redis/v8"
+	"io"
 	"strings"
 	"time"
 )
@@ -37,6 +41,20 @@ func (c CacheRepository) HGetAll(ctx context.Context, key string) (map[string]st
 	return c.cacheOperator.HGetAll(ctx, key)
 }
 
+// HGetPipelined executes the `HGET` commands in a pipeline if the underlying operator supports
+func (c CacheRepository) HGetPipelined(ctx context.Context, keyFields []cache.KeyField) ([]string, []error) {
+	return cache.HGetMulti(ctx, c.cacheOperator, keyFields)
+}
+
+// PSubscribe subscribes the channels with patterns if the underlying operator supports
+func (c CacheRepository) PSubscribe(ctx context.Context, patterns ...string) *redis.PubSub {
+	if provider, ok := c.cacheOperator.(tiered.PubSubProvider); ok {
+		return provider.PSubscribe(ctx, patterns...)
+	}
+
+	return nil
+}
+
 // newSingletonRepository creates a new singleton redis operator via redis.Options
 func newSingletonRepository(options *redis.Options) cache.Operator {
 	return &CacheRepository{singleton.Operator{SingletonClient: redis.NewClient(options)}}
@@ -47,6 +65,51 @@ func newClusterRepository(options *redis.ClusterOptions) cache.Operator {
 	return &CacheRepository{cluster.Operator{ClusterClient: redis.NewClusterClient(options)}}
 }
 
+func init() {
+	cache.RegisterBackend(cache.BackendRedis, newRedisBackend)
+	cache.RegisterBackend(cache.BackendMemory, newMemoryBackend)
+	cache.RegisterBackend(cache.BackendFile, newFileBackend)
+}
+
+// newRedisBackend creates a cluster redis operator if there are multiple addresses, otherwise creates a singleton redis operator
+func newRedisBackend(addressingConfig *config.Addressing) (cache.Operator, error) {
+	if len(strings.Split(addressingConfig.Cache.Addr, ",")) > 1 {
+		// cluster
+		return newClusterRepository(&redis.ClusterOptions{
+			Addrs:    strings.Split(addressingConfig.Cache.Addr, ","),
+			Password: addressingConfig.Cache.Password,
+			PoolSize: addressingConfig.Cache.PoolNum,
+			ReadOnly: addressingConfig.Cache.Readonly,
+		}), nil
+	}
+
+	// singleton
+	return newSingletonRepository(&redis.Options{
+		Addr:     addressingConfig.Cache.Addr,
+		Password: addressingConfig.Cache.Password,
+		PoolSize: addressingConfig.Cache.PoolNum,
+	}), nil
+}
+
+// newMemoryBackend returns the shared in-memory operator, the routing data can be preset via memory.Default()
+func newMemoryBackend(addressingConfig *config.Addressing) (cache.Operator, error) {
+	return memory.Default(), nil
+}
+
+// newFileBackend creates a file operator that reads the routing table from the file specified by `addressing.cache.addr`
+func newFileBackend(addressingConfig *config.Addressing) (cache.Operator, error) {
+	operator, err := file.NewOperator(addressingConfig.Cache.Addr, addressingConfig.TopicSuTitle)
+	if nil != err {
+		return nil, err
+	}
+	if err = operator.Watch(); nil != err {
+		log.Errorsf("Failed to watch the routing table file[%s], the changes will not be reloaded, error:%v",
+			addressingConfig.Cache.Addr, err)
+	}
+
+	return operator, nil
+}
+
 // InitCacheOperatorIfNecessary initializes the cache operator of repository
 func InitCacheOperatorIfNecessary() error {
 	if nil == config.GetConfigs() {
@@ -95,22 +158,18 @@ func InitCacheOperatorIfNecessary() error {
 		addressingConfig.Cache.Readonly = responseData.Response.Readonly
 		addressingConfig.Cache.PoolNum = responseData.Response.Poolnum
 	}
-	var operator cache.Operator
-	if len(strings.Split(addressingConfig.Cache.Addr, ",")) > 1 {
-		// cluster
-		operator = newClusterRepository(&redis.ClusterOptions{
-			Addrs:    strings.Split(addressingConfig.Cache.Addr, ","),
-			Password: addressingConfig.Cache.Password,
-			PoolSize: addressingConfig.Cache.PoolNum,
-			ReadOnly: addressingConfig.Cache.Readonly,
-		})
-	} else {
-		// singleton
-		operator = newSingletonRepository(&redis.Options{
-			Addr:     addressingConfig.Cache.Addr,
-			Password: addressingConfig.Cache.Password,
-			PoolSize: addressingConfig.Cache.PoolNum,
-		})
+	factory, ok := cache.GetBackend(addressingConfig.Cache.Type)
+	if !ok {
+		if "" != addressingConfig.Cache.Type {
+			log.Infosf("Cannot found addressing cache backend[%s], use the backend[%s] instead, registered backends:%v",
+				addressingConfig.Cache.Type, cache.BackendRedis, cache.RegisteredBackends())
+		}
+		factory, _ = cache.GetBackend(cache.BackendRedis)
+	}
+	operator, err := factory(addressingConfig)
+	if nil != err {
+		return errors.Errorf(constant.SystemInternalError,
+			"Failed to create addressing cache operator with backend[%s], error:%v", addressingConfig.Cache.Type, err)
 	}
 	//if strings.EqualFold("cluster", addressingConfig.Cache.Type) {
 	//	if len(strings.Split(addressingConfig.Cache.Addr, ",")) <= 1 {
@@ -135,7 +194,65 @@ func InitCacheOperatorIfNecessary() error {
 	//	})
 	//}
 
-	cache.AddressingCacheOperator = operator
+	closeCacheOperator(cache.AddressingCacheOperator)
+	cache.AddressingCacheOperator = wrapLocalCacheIfNecessary(operator, addressingConfig)
 	log.Infosf("Successfully to init addressing cache operator, cache type is:%s", addressingConfig.Cache.Type)
 	return nil
 }
+
+// reloadNotifier is implemented by the operators that reload the routing data by themselves, e.g. the file operator
+type reloadNotifier interface {
+	RegisterReloadHook(hook func())
+}
+
+// closeCacheOperator releases the resources of the operator if necessary
+func closeCacheOperator(operator cache.Operator) {
+	if tieredOperator, ok := operator.(*tiered.Operator); ok {
+		tieredOperator.Close()
+		operator = tieredOperator.Next()
+	}
+	if closer, ok := operator.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+// wrapLocalCacheIfNecessary wraps the operator with an in-process LRU cache if the local cache is enabled
+func wrapLocalCacheIfNecessary(operator cache.Operator, addressingConfig *config.Addressing) cache.Operator {
+	localCacheConfig := addressingConfig.LocalCache
+	if !localCacheConfig.Enable {
+		return operator
+	}
+
+	opts := make([]tiered.Option, 0)
+	if localCacheConfig.MaxEntries > 0 {
+		opts = append(opts, tiered.WithMaxEntries(localCacheConfig.MaxEntries))
+	}
+	if localCacheConfig.TTLMilliseconds > 0 {
+		opts = append(opts, tiered.WithTTL(time.Duration(localCacheConfig.TTLMilliseconds)*time.Millisecond))
+	}
+	// the default negative TTL is used if it's not configured, the negative value disables the negative caching
+	if localCacheConfig.NegativeTTLMilliseconds > 0 {
+		opts = append(opts, tiered.WithNegativeTTL(time.Duration(localCacheConfig.NegativeTTLMilliseconds)*time.Millisecond))
+	} else if localCacheConfig.NegativeTTLMilliseconds < 0 {
+		opts = append(opts, tiered.WithNegativeTTL(0))
+	}
+	tieredOperator := tiered.NewOperator(operator, opts...)
+	if notifier, ok := operator.(reloadNotifier); ok {
+		notifier.RegisterReloadHook(tieredOperator.Purge)
+	}
+
+	if localCacheConfig.EnableKeyspaceNotification {
+		patterns := localCacheConfig.KeyspaceNotificationPatterns
+		if len(patterns) == 0 {
+			patterns = []string{
+				"__keyspace@*__:CIF.*",
+				"__keyspace@*__:" + addressingConfig.TopicSuTitle + "*",
+				"__keyspace@*__:" + constant.ReshardingFreezeKeyPrefix + "*",
+			}
+		}
+		tieredOperator.StartKeyspaceInvalidation(context.Background(), patterns...)
+	}
+	log.Infosf("Local addressing cache is enabled, config:%++v", localCacheConfig)
+
+	return tieredOperator
+}
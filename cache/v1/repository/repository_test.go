@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"git.multiverse.io/eventkit/kit/cache/v1"
+	"git.multiverse.io/eventkit/kit/cache/v1/memory"
+	"git.multiverse.io/eventkit/kit/cache/v1/tiered"
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/handler/config"
+)
+
+func TestInitCacheOperatorIfNecessary(t *testing.T) {
+	defer func() {
+		cache.AddressingCacheOperator = nil
+	}()
+	assert.Equal(t, []string{cache.BackendFile, cache.BackendMemory, cache.BackendRedis}, cache.RegisteredBackends())
+
+	config.SetConfigs(&config.ServiceConfigs{
+		Addressing: config.Addressing{
+			Enable: true,
+			Cache: config.GLSCache{
+				Type: "memory",
+			},
+		},
+	})
+	assert.True(t, nil == InitCacheOperatorIfNecessary())
+	assert.Equal(t, memory.Default(), cache.AddressingCacheOperator)
+
+	config.GetConfigs().Addressing.LocalCache.Enable = true
+	assert.True(t, nil == InitCacheOperatorIfNecessary())
+	tieredOperator, ok := cache.AddressingCacheOperator.(*tiered.Operator)
+	assert.True(t, ok)
+	assert.Equal(t, memory.Default(), tieredOperator.Next())
+
+	config.GetConfigs().Addressing.Cache.Type = "file"
+	config.GetConfigs().Addressing.Cache.Addr = "not-exists.toml"
+	assert.NotNil(t, InitCacheOperatorIfNecessary())
+}
+
+func TestWrapLocalCacheWithNegativeTTL(t *testing.T) {
+	addressingConfig := &config.Addressing{LocalCache: config.LocalCache{Enable: true}}
+	ctx := context.Background()
+
+	// the not found keys are cached by the default negative TTL if it's not configured
+	operator := wrapLocalCacheIfNecessary(memory.NewOperator(), addressingConfig).(*tiered.Operator)
+	for i := 0; i < 2; i++ {
+		_, err := operator.Get(ctx, "not-exists")
+		assert.NotNil(t, err)
+	}
+	assert.Equal(t, uint64(1), operator.Stats().NegativeHits)
+
+	addressingConfig.LocalCache.NegativeTTLMilliseconds = -1
+	operator = wrapLocalCacheIfNecessary(memory.NewOperator(), addressingConfig).(*tiered.Operator)
+	for i := 0; i < 2; i++ {
+		_, err := operator.Get(ctx, "not-exists")
+		assert.NotNil(t, err)
+	}
+	assert.Equal(t, uint64(0), operator.Stats().NegativeHits)
+}
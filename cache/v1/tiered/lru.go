@@ -0,0 +1,147 @@
+package tiered
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+type entry struct {
+	key      string
+	redisKey string
+	value    string
+	notFound bool
+	expireAt time.Time
+}
+
+// lru is a bounded LRU cache with TTL, it also indexes the entries by the redis key
+// so that all the fields of a redis hash can be invalidated at once.
+type lru struct {
+	lock       sync.Mutex
+	maxEntries int
+	ll         *list.List
+	items      map[string]*list.Element
+	byRedisKey map[string]map[string]struct{}
+	onEvict    func()
+	// generation is increased by every invalidation, the entries loaded before the invalidation are never added
+	generation uint64
+}
+
+func newLRU(maxEntries int, onEvict func()) *lru {
+	return &lru{
+		maxEntries: maxEntries,
+		ll:         list.New(),
+		items:      make(map[string]*list.Element),
+		byRedisKey: make(map[string]map[string]struct{}),
+		onEvict:    onEvict,
+	}
+}
+
+func (c *lru) get(key string, now time.Time) (*entry, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ele, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+	e := ele.Value.(*entry)
+	if now.After(e.expireAt) {
+		c.removeElement(ele)
+		return nil, false
+	}
+	c.ll.MoveToFront(ele)
+	copied := *e
+	return &copied, true
+}
+
+// currentGeneration returns the generation that should be captured before loading the entry
+func (c *lru) currentGeneration() uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.generation
+}
+
+// add adds the entry if no invalidation happened since the generation was captured, returns false if it's discarded
+func (c *lru) add(e *entry, generation uint64) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if generation != c.generation {
+		return false
+	}
+	if ele, ok := c.items[e.key]; ok {
+		c.ll.MoveToFront(ele)
+		ele.Value = e
+		return true
+	}
+
+	c.items[e.key] = c.ll.PushFront(e)
+	keys, ok := c.byRedisKey[e.redisKey]
+	if !ok {
+		keys = make(map[string]struct{})
+		c.byRedisKey[e.redisKey] = keys
+	}
+	keys[e.key] = struct{}{}
+
+	for c.maxEntries > 0 && c.ll.Len() > c.maxEntries {
+		if oldest := c.ll.Back(); nil != oldest {
+			c.removeElement(oldest)
+			if nil != c.onEvict {
+				c.onEvict()
+			}
+		}
+	}
+
+	return true
+}
+
+// removeRedisKeys removes all the entries that belong to the redis keys and increases the generation,
+// returns the number of removed entries
+func (c *lru) removeRedisKeys(redisKeys ...string) int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.generation++
+	removed := 0
+	for _, redisKey := range redisKeys {
+		for key := range c.byRedisKey[redisKey] {
+			if ele, ok := c.items[key]; ok {
+				c.removeElement(ele)
+				removed++
+			}
+		}
+	}
+
+	return removed
+}
+
+func (c *lru) purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.generation++
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+	c.byRedisKey = make(map[string]map[string]struct{})
+}
+
+func (c *lru) len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.ll.Len()
+}
+
+func (c *lru) removeElement(ele *list.Element) {
+	c.ll.Remove(ele)
+	e := ele.Value.(*entry)
+	delete(c.items, e.key)
+	if keys, ok := c.byRedisKey[e.redisKey]; ok {
+		delete(keys, e.key)
+		if len(keys) == 0 {
+			delete(c.byRedisKey, e.redisKey)
+		}
+	}
+}
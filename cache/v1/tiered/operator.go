@@ -0,0 +1,335 @@
+package tiered
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/cache/v1"
+	"git.multiverse.io/eventkit/kit/log"
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	// DefaultMaxEntries is the default max entries of the local cache
+	DefaultMaxEntries = 100000
+	// DefaultTTL is the default TTL of the entry that found in redis
+	DefaultTTL = 60 * time.Second
+	// DefaultNegativeTTL is the default TTL of the entry that not found in redis
+	DefaultNegativeTTL = time.Second
+
+	keyspaceChannelPrefix = "__keyspace@"
+)
+
+// Stats is the statistics of the local cache
+type Stats struct {
+	Hits          uint64 `json:"hits"`
+	NegativeHits  uint64 `json:"negativeHits"`
+	Misses        uint64 `json:"misses"`
+	Evictions     uint64 `json:"evictions"`
+	Invalidations uint64 `json:"invalidations"`
+	Entries       int    `json:"entries"`
+}
+
+// PubSubProvider is implemented by the redis operators that support subscribing channels with patterns
+type PubSubProvider interface {
+	PSubscribe(ctx context.Context, patterns ...string) *redis.PubSub
+}
+
+// Options defines the options of the tiered operator
+type Options struct {
+	MaxEntries  int
+	TTL         time.Duration
+	NegativeTTL time.Duration
+}
+
+// Option sets the options of the tiered operator
+type Option func(*Options)
+
+// WithMaxEntries sets the max entries of the local cache
+func WithMaxEntries(maxEntries int) Option {
+	return func(o *Options) {
+		o.MaxEntries = maxEntries
+	}
+}
+
+// WithTTL sets the TTL of the entry that found in redis
+func WithTTL(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.TTL = ttl
+	}
+}
+
+// WithNegativeTTL sets the TTL of the entry that not found in redis(negative caching), 0 means disable negative caching
+func WithNegativeTTL(negativeTTL time.Duration) Option {
+	return func(o *Options) {
+		o.NegativeTTL = negativeTTL
+	}
+}
+
+// Operator is a two-tier cache operator, an in-process LRU cache in front of the redis operator.
+// Only the `Get` and `HGet` results are cached locally, `Set` writes through and invalidates the local entries.
+// Errors other than redis.Nil are never cached.
+type Operator struct {
+	next    cache.Operator
+	options Options
+	cache   *lru
+
+	hits          uint64
+	negativeHits  uint64
+	misses        uint64
+	evictions     uint64
+	invalidations uint64
+
+	subscribeLock sync.Mutex
+	pubSub        *redis.PubSub
+}
+
+// NewOperator creates a new tiered operator in front of the next operator
+func NewOperator(next cache.Operator, opts ...Option) *Operator {
+	options := Options{
+		MaxEntries:  DefaultMaxEntries,
+		TTL:         DefaultTTL,
+		NegativeTTL: DefaultNegativeTTL,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	operator := &Operator{
+		next:    next,
+		options: options,
+	}
+	operator.cache = newLRU(options.MaxEntries, func() {
+		atomic.AddUint64(&operator.evictions, 1)
+	})
+
+	return operator
+}
+
+// Next returns the redis operator behind the local cache
+func (o *Operator) Next() cache.Operator {
+	return o.next
+}
+
+// Set writes through to the redis operator and invalidates the local entries of the key
+func (o *Operator) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) error {
+	defer o.Invalidate(key)
+	return o.next.Set(ctx, key, value, expiration)
+}
+
+// Get returns the value of key from the local cache, loads from redis if missed.
+func (o *Operator) Get(ctx context.Context, key string) (string, error) {
+	return o.load(key, "g\x00"+key, func() (string, error) {
+		return o.next.Get(ctx, key)
+	})
+}
+
+// HGet returns the value of the field of hash from the local cache, loads from redis if missed.
+func (o *Operator) HGet(ctx context.Context, key, field string) (string, error) {
+	return o.load(key, hashCacheKey(key, field), func() (string, error) {
+		return o.next.HGet(ctx, key, field)
+	})
+}
+
+// HGetPipelined returns the values of the fields from the local cache, the missed fields are loaded from redis in a pipeline.
+func (o *Operator) HGetPipelined(ctx context.Context, keyFields []cache.KeyField) ([]string, []error) {
+	values := make([]string, len(keyFields))
+	errs := make([]error, len(keyFields))
+	now := time.Now()
+
+	missedIndexes := make([]int, 0)
+	missedKeyFields := make([]cache.KeyField, 0)
+	for i, keyField := range keyFields {
+		if e, ok := o.cache.get(hashCacheKey(keyField.Key, keyField.Field), now); ok {
+			if e.notFound {
+				atomic.AddUint64(&o.negativeHits, 1)
+				errs[i] = redis.Nil
+			} else {
+				atomic.AddUint64(&o.hits, 1)
+				values[i] = e.value
+			}
+			continue
+		}
+		atomic.AddUint64(&o.misses, 1)
+		missedIndexes = append(missedIndexes, i)
+		missedKeyFields = append(missedKeyFields, keyField)
+	}
+	if len(missedKeyFields) == 0 {
+		return values, errs
+	}
+
+	generation := o.cache.currentGeneration()
+	missedValues, missedErrs := cache.HGetMulti(ctx, o.next, missedKeyFields)
+	for j, i := range missedIndexes {
+		values[i], errs[i] = missedValues[j], missedErrs[j]
+		o.fill(missedKeyFields[j].Key, hashCacheKey(missedKeyFields[j].Key, missedKeyFields[j].Field), missedValues[j], missedErrs[j], now, generation)
+	}
+
+	return values, errs
+}
+
+// HGetAll is not cached locally
+func (o *Operator) HGetAll(ctx context.Context, key string) (map[string]string, error) {
+	return o.next.HGetAll(ctx, key)
+}
+
+// PSubscribe subscribes the channels of the redis operator if it's supported
+func (o *Operator) PSubscribe(ctx context.Context, patterns ...string) *redis.PubSub {
+	if provider, ok := o.next.(PubSubProvider); ok {
+		return provider.PSubscribe(ctx, patterns...)
+	}
+
+	return nil
+}
+
+func (o *Operator) load(redisKey, cacheKey string, loadFn func() (string, error)) (string, error) {
+	now := time.Now()
+	if e, ok := o.cache.get(cacheKey, now); ok {
+		if e.notFound {
+			atomic.AddUint64(&o.negativeHits, 1)
+			return "", redis.Nil
+		}
+		atomic.AddUint64(&o.hits, 1)
+		return e.value, nil
+	}
+	atomic.AddUint64(&o.misses, 1)
+
+	// the result is not cached if any invalidation happened during loading,
+	// otherwise a stale result (e.g. loaded before GLS rebinding) may be cached.
+	generation := o.cache.currentGeneration()
+	value, err := loadFn()
+	o.fill(redisKey, cacheKey, value, err, now, generation)
+
+	return value, err
+}
+
+// fill adds the result that loaded from redis into local cache, errors other than redis.Nil are not cached.
+// The generation captured before loading is compared under the lock of the local cache, so that the result loaded
+// before any concurrent invalidation is discarded.
+func (o *Operator) fill(redisKey, cacheKey, value string, err error, now time.Time, generation uint64) {
+	if nil != err && redis.Nil != err {
+		return
+	}
+
+	notFound := redis.Nil == err
+	ttl := o.options.TTL
+	if notFound {
+		ttl = o.options.NegativeTTL
+	}
+	if ttl <= 0 {
+		return
+	}
+	o.cache.add(&entry{
+		key:      cacheKey,
+		redisKey: redisKey,
+		value:    value,
+		notFound: notFound,
+		expireAt: now.Add(ttl),
+	}, generation)
+}
+
+func hashCacheKey(key, field string) string {
+	return "h\x00" + key + "\x00" + field
+}
+
+// Invalidate removes all the local entries of the redis keys
+func (o *Operator) Invalidate(redisKeys ...string) {
+	if removed := o.cache.removeRedisKeys(redisKeys...); removed > 0 {
+		atomic.AddUint64(&o.invalidations, uint64(removed))
+	}
+}
+
+// Purge removes all the local entries
+func (o *Operator) Purge() {
+	o.cache.purge()
+}
+
+// Stats returns the statistics of the local cache
+func (o *Operator) Stats() Stats {
+	return Stats{
+		Hits:          atomic.LoadUint64(&o.hits),
+		NegativeHits:  atomic.LoadUint64(&o.negativeHits),
+		Misses:        atomic.LoadUint64(&o.misses),
+		Evictions:     atomic.LoadUint64(&o.evictions),
+		Invalidations: atomic.LoadUint64(&o.invalidations),
+		Entries:       o.cache.len(),
+	}
+}
+
+// StartKeyspaceInvalidation subscribes the redis keyspace notifications with the patterns(e.g. `__keyspace@*__:CIF.*`)
+// and invalidates the local entries when the keys changed.
+// The redis server must enable the keyspace notifications(e.g. `notify-keyspace-events Kgh$x`),
+// for redis cluster, the notifications are only received from the node that the subscription connected to.
+func (o *Operator) StartKeyspaceInvalidation(ctx context.Context, patterns ...string) bool {
+	o.subscribeLock.Lock()
+	defer o.subscribeLock.Unlock()
+
+	if nil != o.pubSub || len(patterns) == 0 {
+		return false
+	}
+	pubSub := o.PSubscribe(ctx, patterns...)
+	if nil == pubSub {
+		log.Infosf("The addressing cache operator doesn't support subscribing, skip keyspace notification based invalidation!")
+		return false
+	}
+	o.pubSub = pubSub
+
+	go func() {
+		for message := range pubSub.Channel() {
+			if key := keyOfKeyspaceChannel(message.Channel); "" != key {
+				log.Debugsf("keyspace notification received, key:[%s] event:[%s], invalidate local addressing cache", key, message.Payload)
+				o.Invalidate(key)
+			}
+		}
+	}()
+	log.Infosf("Successfully to subscribe keyspace notifications for local addressing cache, patterns:%v", patterns)
+
+	return true
+}
+
+// Close stops the keyspace notification subscription and purges the local entries
+func (o *Operator) Close() error {
+	o.subscribeLock.Lock()
+	defer o.subscribeLock.Unlock()
+
+	o.Purge()
+	if nil != o.pubSub {
+		err := o.pubSub.Close()
+		o.pubSub = nil
+		return err
+	}
+
+	return nil
+}
+
+// keyOfKeyspaceChannel returns the key of keyspace channel, e.g. returns `foo` for `__keyspace@0__:foo`
+func keyOfKeyspaceChannel(channel string) string {
+	if !strings.HasPrefix(channel, keyspaceChannelPrefix) {
+		return ""
+	}
+	idx := strings.Index(channel, "__:")
+	if idx < 0 {
+		return ""
+	}
+
+	return channel[idx+3:]
+}
+
+// GetStats returns the statistics of the local cache if the operator is a tiered operator
+func GetStats(operator cache.Operator) (Stats, bool) {
+	if tieredOperator, ok := operator.(*Operator); ok {
+		return tieredOperator.Stats(), true
+	}
+
+	return Stats{}, false
+}
+
+// Invalidate removes the local entries of the redis keys if the operator is a tiered operator
+func Invalidate(operator cache.Operator, redisKeys ...string) {
+	if tieredOperator, ok := operator.(*Operator); ok {
+		tieredOperator.Invalidate(redisKeys...)
+	}
+}
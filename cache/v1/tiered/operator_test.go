@@ -0,0 +1,145 @@
+package tiered
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/cache/v1"
+	"git.multiverse.io/eventkit/kit/common/assert"
+	mockcache "git.multiverse.io/eventkit/kit/mocks/cache"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+)
+
+func TestOperator_HGet(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	next := mockcache.NewMockOperator(mockCtrl)
+	operator := NewOperator(next, WithTTL(time.Minute), WithNegativeTTL(time.Minute))
+	ctx := context.Background()
+
+	next.EXPECT().HGet(gomock.Any(), "CIF.org.wks.env.CUS.CUS.001", "suType").Return("su1", nil).Times(1)
+	for i := 0; i < 3; i++ {
+		v, err := operator.HGet(ctx, "CIF.org.wks.env.CUS.CUS.001", "suType")
+		assert.True(t, nil == err)
+		assert.Equal(t, "su1", v)
+	}
+
+	// negative caching
+	next.EXPECT().HGet(gomock.Any(), "CIF.org.wks.env.CUS.CUS.002", "suType").Return("", redis.Nil).Times(1)
+	for i := 0; i < 2; i++ {
+		_, err := operator.HGet(ctx, "CIF.org.wks.env.CUS.CUS.002", "suType")
+		assert.Equal(t, redis.Nil, err)
+	}
+
+	// errors other than redis.Nil are not cached
+	next.EXPECT().Get(gomock.Any(), "topic").Return("", context.DeadlineExceeded).Times(2)
+	for i := 0; i < 2; i++ {
+		_, err := operator.Get(ctx, "topic")
+		assert.Equal(t, context.DeadlineExceeded, err)
+	}
+
+	stats := operator.Stats()
+	assert.Equal(t, uint64(2), stats.Hits)
+	assert.Equal(t, uint64(1), stats.NegativeHits)
+	assert.Equal(t, uint64(4), stats.Misses)
+	assert.Equal(t, 2, stats.Entries)
+
+	// rebinding: invalidate the key then load the new SU
+	operator.Invalidate("CIF.org.wks.env.CUS.CUS.001")
+	next.EXPECT().HGet(gomock.Any(), "CIF.org.wks.env.CUS.CUS.001", "suType").Return("su2", nil).Times(1)
+	v, err := operator.HGet(ctx, "CIF.org.wks.env.CUS.CUS.001", "suType")
+	assert.True(t, nil == err)
+	assert.Equal(t, "su2", v)
+	assert.Equal(t, uint64(1), operator.Stats().Invalidations)
+
+	// write through
+	next.EXPECT().Set(gomock.Any(), "CIF.org.wks.env.CUS.CUS.001", "v", time.Duration(0)).Return(nil)
+	assert.True(t, nil == operator.Set(ctx, "CIF.org.wks.env.CUS.CUS.001", "v", 0))
+	assert.Equal(t, 1, operator.Stats().Entries)
+
+	stats, ok := GetStats(operator)
+	assert.True(t, ok)
+	assert.Equal(t, 1, stats.Entries)
+	_, ok = GetStats(next)
+	assert.False(t, ok)
+}
+
+func TestOperator_InvalidateDuringLoading(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	next := mockcache.NewMockOperator(mockCtrl)
+	operator := NewOperator(next)
+
+	next.EXPECT().HGet(gomock.Any(), "key", "field").DoAndReturn(func(ctx context.Context, key, field string) (string, error) {
+		// the key is rebound while loading, the stale result must not be cached
+		operator.Invalidate(key)
+		return "stale", nil
+	}).Times(1)
+	next.EXPECT().HGet(gomock.Any(), "key", "field").Return("fresh", nil).Times(1)
+
+	v, _ := operator.HGet(context.Background(), "key", "field")
+	assert.Equal(t, "stale", v)
+	v, _ = operator.HGet(context.Background(), "key", "field")
+	assert.Equal(t, "fresh", v)
+}
+
+func TestLRU_AddAfterInvalidation(t *testing.T) {
+	c := newLRU(10, nil)
+	expireAt := time.Now().Add(time.Minute)
+
+	// the entry loaded before the invalidation is discarded even if the invalidation removed nothing
+	generation := c.currentGeneration()
+	c.removeRedisKeys("key")
+	assert.False(t, c.add(&entry{key: "key", redisKey: "key", value: "stale", expireAt: expireAt}, generation))
+	assert.Equal(t, 0, c.len())
+
+	generation = c.currentGeneration()
+	assert.True(t, c.add(&entry{key: "key", redisKey: "key", value: "fresh", expireAt: expireAt}, generation))
+	c.purge()
+	assert.False(t, c.add(&entry{key: "key", redisKey: "key", value: "stale", expireAt: expireAt}, generation))
+	assert.Equal(t, 0, c.len())
+}
+
+func TestOperator_Eviction(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	next := mockcache.NewMockOperator(mockCtrl)
+	operator := NewOperator(next, WithMaxEntries(2))
+
+	next.EXPECT().Get(gomock.Any(), gomock.Any()).Return("v", nil).Times(3)
+	operator.Get(context.Background(), "k1")
+	operator.Get(context.Background(), "k2")
+	operator.Get(context.Background(), "k3")
+
+	stats := operator.Stats()
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, uint64(1), stats.Evictions)
+}
+
+func TestKeyOfKeyspaceChannel(t *testing.T) {
+	assert.Equal(t, "CIF.org.wks.env.CUS.CUS.001", keyOfKeyspaceChannel("__keyspace@0__:CIF.org.wks.env.CUS.CUS.001"))
+	assert.Equal(t, "", keyOfKeyspaceChannel("__keyevent@0__:hset"))
+}
+
+func TestOperator_HGetPipelined(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	next := mockcache.NewMockOperator(mockCtrl)
+	operator := NewOperator(next)
+	ctx := context.Background()
+
+	next.EXPECT().HGet(gomock.Any(), "k1", "f").Return("v1", nil).Times(1)
+	next.EXPECT().HGet(gomock.Any(), "k2", "f").Return("", redis.Nil).Times(1)
+	operator.HGet(ctx, "k1", "f")
+
+	keyFields := []cache.KeyField{{Key: "k1", Field: "f"}, {Key: "k2", Field: "f"}}
+	for i := 0; i < 2; i++ {
+		values, errs := operator.HGetPipelined(ctx, keyFields)
+		assert.Equal(t, "v1", values[0])
+		assert.True(t, nil == errs[0])
+		assert.Equal(t, redis.Nil, errs[1])
+	}
+	assert.Equal(t, uint64(2), operator.Stats().Misses)
+}
@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"git.multiverse.io/eventkit/kit/common/health"
+	redis2 "github.com/go-redis/redis/v8"
+)
+
+// PoolStats is the statistics of a cache connection pool
+type PoolStats struct {
+	Type       string `json:"type"`
+	Su         string `json:"su"`
+	Default    bool   `json:"default"`
+	Hits       uint32 `json:"hits"`
+	Misses     uint32 `json:"misses"`
+	Timeouts   uint32 `json:"timeouts"`
+	TotalConns uint32 `json:"totalConns"`
+	IdleConns  uint32 `json:"idleConns"`
+	StaleConns uint32 `json:"staleConns"`
+}
+
+var registerHealthOnce sync.Once
+
+// registerHealth registers the cache connection pools into the health registry,
+// the service is DOWN if any cache connection pool cannot be pinged.
+func registerHealth() {
+	health.Register("cache", Ping, health.WithCritical(true))
+	health.RegisterComponent("cache", func() interface{} {
+		return Stats()
+	})
+}
+
+type namedClient struct {
+	name   string
+	client redis2.UniversalClient
+}
+
+// currentClients returns the clients of all the cache connection pools ordered by the alias name
+func currentClients() ([]namedClient, map[string]PoolStats) {
+	cachePools.RLock()
+	defer cachePools.RUnlock()
+
+	clients := make([]namedClient, 0, len(cachePools.CurrentCacheConfigs))
+	stats := make(map[string]PoolStats, len(cachePools.CurrentCacheConfigs))
+	if nil == _connectionPoolCache {
+		return clients, stats
+	}
+	for aliasName, cacheConfig := range cachePools.CurrentCacheConfigs {
+		stats[aliasName] = PoolStats{
+			Type:    cacheConfig.Type,
+			Su:      cacheConfig.Su,
+			Default: cacheConfig.Default,
+		}
+		cp, ok := _connectionPoolCache.Get(aliasName)
+		if !ok {
+			continue
+		}
+		if client, ok := cp.(redis2.UniversalClient); ok {
+			clients = append(clients, namedClient{name: aliasName, client: client})
+		}
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].name < clients[j].name
+	})
+
+	return clients, stats
+}
+
+// Stats returns the statistics of all the cache connection pools keyed by the alias name
+func Stats() map[string]PoolStats {
+	clients, stats := currentClients()
+	for _, client := range clients {
+		poolStats := stats[client.name]
+		if ps := client.client.PoolStats(); nil != ps {
+			poolStats.Hits = ps.Hits
+			poolStats.Misses = ps.Misses
+			poolStats.Timeouts = ps.Timeouts
+			poolStats.TotalConns = ps.TotalConns
+			poolStats.IdleConns = ps.IdleConns
+			poolStats.StaleConns = ps.StaleConns
+		}
+		stats[client.name] = poolStats
+	}
+
+	return stats
+}
+
+// Ping pings all the cache connection pools, returns the error of the first unavailable one
+func Ping(ctx context.Context) error {
+	clients, _ := currentClients()
+	for _, client := range clients {
+		if err := client.client.Ping(ctx).Err(); nil != err {
+			return fmt.Errorf("failed to ping cache[%s]: %v", client.name, err)
+		}
+	}
+
+	return nil
+}
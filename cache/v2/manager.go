@@ -3,6 +3,7 @@ package cache
 import (
 	"git.multiverse.io/eventkit/kit/cache/v2/redis"
 	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/shutdown"
 	"git.multiverse.io/eventkit/kit/constant"
 	"git.multiverse.io/eventkit/kit/handler/config"
 	"git.multiverse.io/eventkit/kit/log"
@@ -38,9 +39,36 @@ func InitCacheManagerForRedis(cacheConfigs map[string]config.Cache) *errors.Erro
 	}
 	// register config change hook function into config manager for redis
 	config.RegisterConfigOnChangeHookFunc("CacheManagerForRedis", rotateCacheConfigWhenConfigChanged, true)
+	registerCloserOnce.Do(registerCloser)
+	registerHealthOnce.Do(registerHealth)
 	return nil
 }
 
+var registerCloserOnce sync.Once
+
+// registerCloser registers Close into the shutdown coordinator
+func registerCloser() {
+	shutdown.RegisterCloser("cache connection pools", func() error {
+		Close()
+		return nil
+	})
+}
+
+// Close closes all the cache connection pools, it's executed by the shutdown coordinator when the service stops
+func Close() {
+	cachePools.Lock()
+	defer cachePools.Unlock()
+
+	if nil == _connectionPoolCache {
+		return
+	}
+	for _, cacheConfig := range cachePools.CurrentCacheConfigs {
+		_connectionPoolCache.Delete(cacheConfig.Name)
+	}
+	cachePools.Cache = make(map[string]suCacheConnectionPools)
+	cachePools.CurrentCacheConfigs = nil
+}
+
 type _cachePools struct {
 	sync.RWMutex
 	Cache               map[string]suCacheConnectionPools
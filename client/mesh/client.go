@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"git.multiverse.io/eventkit/kit/client"
+	"git.multiverse.io/eventkit/kit/codec"
 	"git.multiverse.io/eventkit/kit/common/errors"
 	"git.multiverse.io/eventkit/kit/common/msg"
 	"git.multiverse.io/eventkit/kit/common/util"
@@ -187,6 +188,17 @@ func injectRequestHeader(message *msg.Message, opts *client.RequestOptions) {
 	}
 }
 
+// injectContentType injects the content type declared by the codec of the request,
+// so that the handler decodes the request with the codec registered for the content type
+func injectContentType(message *msg.Message, request client.Request) {
+	if _, ok := message.GetAppProperty(constant.HTTPContentTypeKey); ok {
+		return
+	}
+	if contentType := codec.ContentTypeOf(request.Codec()); "" != contentType {
+		message.SetAppProperty(constant.HTTPContentTypeKey, contentType)
+	}
+}
+
 func injectResponseHeader(message *msg.Message, opts *client.ResponseOptions) {
 	if len(opts.SessionName) > 0 {
 		message.SessionName = opts.SessionName
@@ -211,7 +223,11 @@ func httpRequest(ctx context.Context, request client.Request, requestMessage *ms
 	req := fasthttp.AcquireRequest()
 	defer fasthttp.ReleaseRequest(req)
 
-	req.Header.Set(constant.HTTPContentTypeKey, request.RequestOptions().ContentType)
+	contentType := request.RequestOptions().ContentType
+	if declared := codec.ContentTypeOf(request.Codec()); "" != declared {
+		contentType = declared
+	}
+	req.Header.Set(constant.HTTPContentTypeKey, contentType)
 	req.Header.SetMethod(request.RequestOptions().HTTPMethod)
 	req.Header.DisableNormalizing()
 	// inject header
@@ -443,6 +459,16 @@ func (m *meshClient) SyncCall(ctx context.Context, request client.Request, respo
 	}
 	// inject header
 	injectRequestHeader(requestMessage, requestOptions)
+	if !request.RequestOptions().HTTPCall {
+		injectContentType(requestMessage, request)
+	}
+
+	// compress the payload if necessary
+	if !request.RequestOptions().HTTPCall {
+		if cerr := requestMessage.CompressBody(requestOptions.Compression); nil != cerr {
+			return nil, cerr
+		}
+	}
 
 	// inject context into appProps
 	handlerContexts := contexts.HandlerContextsFromContext(ctx)
@@ -659,6 +685,16 @@ func (m *meshClient) AsyncCall(ctx context.Context, request client.Request, opts
 
 	// inject header
 	injectRequestHeader(requestMessage, requestOptions)
+	if !request.RequestOptions().HTTPCall {
+		injectContentType(requestMessage, request)
+	}
+
+	// compress the payload if necessary
+	if !request.RequestOptions().HTTPCall {
+		if cerr := requestMessage.CompressBody(requestOptions.Compression); nil != cerr {
+			return cerr
+		}
+	}
 
 	// inject context into appProps
 	handlerContexts := contexts.HandlerContextsFromContext(ctx)
@@ -4,6 +4,7 @@ import (
 	"context"
 	"git.multiverse.io/eventkit/kit/client"
 	"git.multiverse.io/eventkit/kit/codec"
+	"git.multiverse.io/eventkit/kit/common/compressor"
 	"git.multiverse.io/eventkit/kit/constant"
 	"git.multiverse.io/eventkit/kit/handler/config"
 	"time"
@@ -298,6 +299,14 @@ func WithEnableLogging(enableLogging bool) client.RequestOption {
 	}
 }
 
+// WithCompression compresses the request payload with the algorithm(gzip/zstd/snappy) if the payload reaches the threshold(bytes),
+// 0 threshold means compressor.DefaultThreshold
+func WithCompression(algorithm string, threshold int) client.RequestOption {
+	return func(options *client.RequestOptions) {
+		options.Compression = &compressor.Options{Algorithm: algorithm, Threshold: threshold}
+	}
+}
+
 // SkipResponseAutoParse marks skip auto parse on response
 func SkipResponseAutoParse() client.RequestOption {
 	return func(options *client.RequestOptions) {
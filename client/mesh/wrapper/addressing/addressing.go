@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"git.multiverse.io/eventkit/kit/cache/v1"
+	"git.multiverse.io/eventkit/kit/cache/v1/tiered"
 	"git.multiverse.io/eventkit/kit/client"
 	"git.multiverse.io/eventkit/kit/common/errors"
 	"git.multiverse.io/eventkit/kit/common/model/glsdef"
@@ -128,6 +129,68 @@ func Lookup(ctx context.Context, dim *glsdef.Dimension, element glsdef.Element)
 	return pd, nil
 }
 
+// InvalidateElement removes the local cached lookup results of the element,
+// it should be called after the element is rebound/unbound to keep the local cache consistent.
+func InvalidateElement(dim *glsdef.Dimension, element glsdef.Element) {
+	if nil == dim || nil == config.GetConfigs() {
+		return
+	}
+	if element.ElementClass == "" {
+		element.ElementClass = element.ElementType
+	}
+	element.ElementType = (element.ElementType + "   ")[0:3]
+
+	keys := make([]string, 0)
+	for _, elementID := range allElementIDs(element.ElementID) {
+		keys = append(keys,
+			fmt.Sprintf("CIF.%s.%s.%s.%s.%s.%s", dim.Tenant, dim.Workspace, dim.Environment, element.ElementType, element.ElementClass, elementID),
+			fmt.Sprintf("%s.%s.%s", element.ElementType, element.ElementClass, elementID))
+	}
+	tiered.Invalidate(cache.AddressingCacheOperator, keys...)
+}
+
+// InvalidateTopic removes the local cached topic to SU type results of the topic
+func InvalidateTopic(dim *glsdef.Dimension) {
+	if nil == dim || nil == config.GetConfigs() {
+		return
+	}
+	topicSuTitle := config.GetConfigs().Addressing.TopicSuTitle
+	keys := []string{topicSuTitle}
+	for _, topicID := range allTopicIDs(dim.Topic.TopicID) {
+		keys = append(keys, fmt.Sprintf("%s.%s.%s.%s.%s.%s", topicSuTitle, dim.Tenant, dim.Workspace, dim.Environment, dim.Topic.TopicType, topicID))
+	}
+	tiered.Invalidate(cache.AddressingCacheOperator, keys...)
+}
+
+// LocalCacheStats returns the statistics of the local addressing cache, returns false if the local cache is disabled
+func LocalCacheStats() (tiered.Stats, bool) {
+	return tiered.GetStats(cache.AddressingCacheOperator)
+}
+
+// allElementIDs returns all the sharded element IDs(see randomElementIDIfNecessary) of the element ID
+func allElementIDs(elementID string) []string {
+	shardNumber := config.GetConfigs().Addressing.RandomElementIDMap[strings.ToLower(elementID)]
+	return withShardSuffix(elementID, shardNumber)
+}
+
+// allTopicIDs returns all the sharded topic IDs(see randomTopicIDIfNecessary) of the topic ID
+func allTopicIDs(topicID string) []string {
+	shardNumber := config.GetConfigs().Addressing.RandomTopicIDMap[strings.ToLower(topicID)]
+	return withShardSuffix(topicID, shardNumber)
+}
+
+func withShardSuffix(id string, shardNumber int) []string {
+	if shardNumber <= 0 {
+		return []string{id}
+	}
+	ids := make([]string, 0, shardNumber)
+	for i := 0; i < shardNumber; i++ {
+		ids = append(ids, id+strconv.Itoa(i))
+	}
+
+	return ids
+}
+
 // Before wraps the requests, will generate a new span ID each time.
 func (t *Wrapper) Before(ctx context.Context, request interface{}, opts interface{}) (context.Context, error) {
 	startTime := time.Now()
@@ -196,14 +259,21 @@ func (t *Wrapper) Before(ctx context.Context, request interface{}, opts interfac
 		return ctx, err
 	} else {
 		log.Debugsf("Got SU ID = %s", pd.SuID)
-		requestOptions.Su = pd.SuID
+		suID := pd.SuID
+		if standbySU, ok := failoverIfNecessary(ctx, dimension.SuType, requestOptions.EventID, pd.SuID, handlerContexts.CommonSu); ok {
+			log.Infof(ctx, "SU[%s] is down, failover to SU[%s]", pd.SuID, standbySU)
+			suID = standbySU
+		}
+		requestOptions.Su = suID
+		ctx = context.WithValue(ctx, addressedSUKey{}, suID)
 	}
 
 	return ctx, nil
 }
 
-// After do nothing
+// After tracks the failures of the SU addressed in `Before` for failover
 func (t *Wrapper) After(ctx context.Context, request interface{}, responseMeta interface{}, opts interface{}) (context.Context, error) {
+	recordCallResult(ctx, responseMeta)
 	return ctx, nil
 }
 
@@ -154,7 +154,9 @@ func TestWrapper_Before(t *testing.T) {
 		GetUint64Fn:  nil,
 	})
 	retCtx, err = wrapper.Before(ctx, nil, requestOptions)
-	assert.Nil(t, err)
-	assert.Equal(t, ctx, retCtx)
+	assert.True(t, nil == err)
 	assert.Equal(t, requestOptions.Su, "V2")
+	// the addressed SU is carried by the context for tracking the result of the call in `After`
+	assert.Equal(t, "V2", retCtx.Value(addressedSUKey{}))
+	assert.Equal(t, handlerContexts, contexts.HandlerContextsFromContext(retCtx))
 }
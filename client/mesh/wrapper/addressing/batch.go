@@ -0,0 +1,176 @@
+package addressing
+
+import (
+	"context"
+	"fmt"
+
+	"git.multiverse.io/eventkit/kit/cache/v1"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/model/glsdef"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/log"
+)
+
+// MissResolver resolves the SU IDs of the elements that cannot be found in the addressing cache,
+// the returned SU IDs must be in the same order of the elements, empty string means not found.
+type MissResolver func(ctx context.Context, dim *glsdef.Dimension, elements []glsdef.Element) ([]string, *errors.Error)
+
+// BatchLookupOptions defines the options of the batch lookup
+type BatchLookupOptions struct {
+	MissResolver MissResolver
+}
+
+// BatchLookupOption sets the options of the batch lookup
+type BatchLookupOption func(*BatchLookupOptions)
+
+// WithMissResolver sets the resolver for the elements not found in the addressing cache(e.g. GLS LookupListUsingSUType)
+func WithMissResolver(missResolver MissResolver) BatchLookupOption {
+	return func(o *BatchLookupOptions) {
+		o.MissResolver = missResolver
+	}
+}
+
+// BatchLookupResult is the result of batch lookup
+type BatchLookupResult struct {
+	// SuType is the SU type of the dimension
+	SuType string
+	// SuIDs are the SU IDs of elements, in the same order of the elements, empty string means not found
+	SuIDs []string
+	// Groups maps the SU ID to the indexes of the elements
+	Groups map[string][]int
+	// NotFound are the indexes of the elements that cannot be found
+	NotFound []int
+}
+
+// SuList returns the distinct SU IDs of the result
+func (b *BatchLookupResult) SuList() []string {
+	suList := make([]string, 0, len(b.Groups))
+	for _, suID := range b.SuIDs {
+		if _, ok := b.Groups[suID]; ok && !containsString(suList, suID) {
+			suList = append(suList, suID)
+		}
+	}
+
+	return suList
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LookupBatch looks up the SU IDs of multiple elements with the same dimension,
+// the `HGET`s are pipelined and the legacy keys are only tried for the elements not found with the new keys,
+// the elements still not found will be resolved by the MissResolver if it's specified.
+func LookupBatch(ctx context.Context, dim *glsdef.Dimension, elements []glsdef.Element, opts ...BatchLookupOption) (*BatchLookupResult, *errors.Error) {
+	options := &BatchLookupOptions{}
+	for _, o := range opts {
+		o(options)
+	}
+
+	if nil == cache.AddressingCacheOperator {
+		return nil, errors.Errorf(constant.SystemInternalError, "Cannot get cache operator")
+	}
+
+	if err := CheckSuTypeTopic(ctx, dim); nil != err {
+		return nil, err
+	}
+
+	result := &BatchLookupResult{
+		SuType: dim.SuType,
+		SuIDs:  make([]string, len(elements)),
+		Groups: make(map[string][]int),
+	}
+	if len(elements) == 0 {
+		return result, nil
+	}
+
+	normalizedElements := make([]glsdef.Element, len(elements))
+	keyFields := make([]cache.KeyField, len(elements))
+	legacyKeyFields := make([]cache.KeyField, len(elements))
+	for i, element := range elements {
+		if element.ElementClass == "" {
+			element.ElementClass = element.ElementType
+		}
+		element.ElementType = (element.ElementType + "   ")[0:3]
+		normalizedElements[i] = element
+		elementID := randomElementIDIfNecessary(element.ElementID)
+		keyFields[i] = cache.KeyField{
+			Key: fmt.Sprintf("CIF.%s.%s.%s.%s.%s.%s",
+				dim.Tenant,
+				dim.Workspace,
+				dim.Environment,
+				element.ElementType, element.ElementClass, elementID),
+			Field: dim.SuType,
+		}
+		legacyKeyFields[i] = cache.KeyField{
+			Key:   fmt.Sprintf("%s.%s.%s", element.ElementType, element.ElementClass, elementID),
+			Field: dim.SuType,
+		}
+	}
+
+	values, errs := cache.HGetMulti(ctx, cache.AddressingCacheOperator, keyFields)
+	missedIndexes := make([]int, 0)
+	for i := range elements {
+		if nil == errs[i] && "" != values[i] {
+			result.SuIDs[i] = values[i]
+		} else {
+			missedIndexes = append(missedIndexes, i)
+		}
+	}
+
+	// fallback to the legacy keys
+	if len(missedIndexes) > 0 {
+		missedKeyFields := make([]cache.KeyField, len(missedIndexes))
+		for j, i := range missedIndexes {
+			missedKeyFields[j] = legacyKeyFields[i]
+		}
+		values, errs = cache.HGetMulti(ctx, cache.AddressingCacheOperator, missedKeyFields)
+		stillMissedIndexes := make([]int, 0)
+		for j, i := range missedIndexes {
+			if nil == errs[j] && "" != values[j] {
+				result.SuIDs[i] = values[j]
+			} else {
+				stillMissedIndexes = append(stillMissedIndexes, i)
+			}
+		}
+		missedIndexes = stillMissedIndexes
+	}
+
+	// resolve the missed elements
+	if len(missedIndexes) > 0 && nil != options.MissResolver {
+		missedElements := make([]glsdef.Element, len(missedIndexes))
+		for j, i := range missedIndexes {
+			missedElements[j] = normalizedElements[i]
+		}
+		suIDs, err := options.MissResolver(ctx, dim, missedElements)
+		if nil != err {
+			return nil, err
+		}
+		if len(suIDs) != len(missedElements) {
+			return nil, errors.Errorf(constant.SystemInternalError,
+				"the number of SU IDs[%d] resolved by miss resolver is not equal to the number of elements[%d]",
+				len(suIDs), len(missedElements))
+		}
+		for j, i := range missedIndexes {
+			result.SuIDs[i] = suIDs[j]
+		}
+	}
+
+	for i, suID := range result.SuIDs {
+		if "" == suID {
+			result.NotFound = append(result.NotFound, i)
+			continue
+		}
+		result.Groups[suID] = append(result.Groups[suID], i)
+	}
+
+	log.Debugf(ctx, "Batch lookup elements, dimension[%++v], elements:[%d], SUs:[%d], not found:[%d]",
+		dim, len(elements), len(result.Groups), len(result.NotFound))
+	return result, nil
+}
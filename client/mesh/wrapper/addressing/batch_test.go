@@ -0,0 +1,61 @@
+package addressing
+
+import (
+	"context"
+	"testing"
+
+	v1 "git.multiverse.io/eventkit/kit/cache/v1"
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/model/glsdef"
+	"git.multiverse.io/eventkit/kit/handler/config"
+	mockcache "git.multiverse.io/eventkit/kit/mocks/cache"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+)
+
+func TestLookupBatch(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	operator := mockcache.NewMockOperator(mockCtrl)
+	v1.AddressingCacheOperator = operator
+	config.SetConfigs(&config.ServiceConfigs{})
+
+	operator.EXPECT().HGet(gomock.Any(), "CIF.org.wks.env.CUS.CUS.001", "suType").Return("su1", nil)
+	operator.EXPECT().HGet(gomock.Any(), "CIF.org.wks.env.CUS.CUS.002", "suType").Return("su2", nil)
+	operator.EXPECT().HGet(gomock.Any(), "CIF.org.wks.env.CUS.CUS.003", "suType").Return("", redis.Nil)
+	operator.EXPECT().HGet(gomock.Any(), "CIF.org.wks.env.CUS.CUS.004", "suType").Return("", redis.Nil)
+	operator.EXPECT().HGet(gomock.Any(), "CIF.org.wks.env.CUS.CUS.005", "suType").Return("", redis.Nil)
+	// legacy keys
+	operator.EXPECT().HGet(gomock.Any(), "CUS.CUS.003", "suType").Return("su1", nil)
+	operator.EXPECT().HGet(gomock.Any(), "CUS.CUS.004", "suType").Return("", redis.Nil)
+	operator.EXPECT().HGet(gomock.Any(), "CUS.CUS.005", "suType").Return("", redis.Nil)
+
+	dim := &glsdef.Dimension{
+		Tenant:      "org",
+		Workspace:   "wks",
+		Environment: "env",
+		SuType:      "suType",
+	}
+	elements := []glsdef.Element{
+		{ElementType: "CUS", ElementID: "001"},
+		{ElementType: "CUS", ElementID: "002"},
+		{ElementType: "CUS", ElementID: "003"},
+		{ElementType: "CUS", ElementID: "004"},
+		{ElementType: "CUS", ElementID: "005"},
+	}
+	resolved := make([]string, 0)
+	result, err := LookupBatch(context.Background(), dim, elements, WithMissResolver(
+		func(ctx context.Context, dim *glsdef.Dimension, elements []glsdef.Element) ([]string, *errors.Error) {
+			for _, element := range elements {
+				resolved = append(resolved, element.ElementID)
+			}
+			return []string{"su3", ""}, nil
+		}))
+	assert.True(t, nil == err)
+	assert.Equal(t, []string{"004", "005"}, resolved)
+	assert.Equal(t, []string{"su1", "su2", "su1", "su3", ""}, result.SuIDs)
+	assert.Equal(t, []int{0, 2}, result.Groups["su1"])
+	assert.Equal(t, []int{4}, result.NotFound)
+	assert.Equal(t, []string{"su1", "su2", "su3"}, result.SuList())
+}
@@ -0,0 +1,328 @@
+package addressing
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/client"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/util"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/handler/config"
+	"git.multiverse.io/eventkit/kit/log"
+)
+
+// defaultFailoverErrorCodes are the error codes that count as the failures of SU if not configured
+var defaultFailoverErrorCodes = []string{
+	constant.SystemRemoteCallTimeout,
+	constant.SystemErrConnectionClosed,
+	constant.SystemErrConnectionAborted,
+	constant.SystemErrConnectionRefused,
+	constant.SystemErrConnectionReset,
+	constant.SystemMeshRequestReplyTimeout,
+}
+
+// SuListProvider returns the SU list of the SU type(e.g. GLS QuerySuListUsingSUType)
+type SuListProvider func(ctx context.Context, suType string) ([]string, *errors.Error)
+
+// FailoverEvent is the alert emitted when the requests are routed from the down SU to another SU
+type FailoverEvent struct {
+	SuType    string `json:"suType"`
+	TopicID   string `json:"topicID"`
+	FailedSU  string `json:"failedSU"`
+	StandbySU string `json:"standbySU"`
+	Failures  int    `json:"failures"`
+	// DownUntil is the time in milliseconds that the failed SU will be retried
+	DownUntil int64 `json:"downUntil"`
+	// Timestamp is the failover time in milliseconds
+	Timestamp int64 `json:"timestamp"`
+}
+
+// FailoverAlerter emits the failover alert
+type FailoverAlerter func(ctx context.Context, event *FailoverEvent)
+
+// SuHealth is the health of SU tracked by the addressing layer
+type SuHealth struct {
+	SU        string
+	Healthy   bool
+	Failures  int
+	DownUntil time.Time
+}
+
+type suHealth struct {
+	failures  int
+	downUntil time.Time
+}
+
+type suList struct {
+	sus       []string
+	refreshAt time.Time
+}
+
+type healthTracker struct {
+	lock    sync.Mutex
+	sus     map[string]*suHealth
+	suLists map[string]*suList
+}
+
+var (
+	tracker = &healthTracker{
+		sus:     make(map[string]*suHealth),
+		suLists: make(map[string]*suList),
+	}
+	failoverLock    sync.RWMutex
+	suListProvider  SuListProvider
+	failoverAlerter FailoverAlerter = func(ctx context.Context, event *FailoverEvent) {
+		log.Errorf(ctx, "Addressing failover, SU[%s] is down, the requests of topic[%s] are routed to SU[%s]",
+			event.FailedSU, event.TopicID, event.StandbySU)
+	}
+)
+
+// SetSuListProvider sets the provider of SU list, the standby SU must be in the SU list of the SU type if it's set
+func SetSuListProvider(provider SuListProvider) {
+	failoverLock.Lock()
+	defer failoverLock.Unlock()
+
+	suListProvider = provider
+}
+
+// SetFailoverAlerter sets the alerter that emits the failover alerts, defaults to logging
+func SetFailoverAlerter(alerter FailoverAlerter) {
+	failoverLock.Lock()
+	defer failoverLock.Unlock()
+
+	failoverAlerter = alerter
+}
+
+// ResetSuHealth clears the tracked health of all SUs
+func ResetSuHealth() {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	tracker.sus = make(map[string]*suHealth)
+	tracker.suLists = make(map[string]*suList)
+}
+
+// GetSuHealth returns the health of the SUs in the SU list of the SU type
+func GetSuHealth(ctx context.Context, suType string) ([]SuHealth, *errors.Error) {
+	sus, err := getSuList(ctx, suType)
+	if nil != err {
+		return nil, err
+	}
+
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	now := time.Now()
+	result := make([]SuHealth, 0, len(sus))
+	for _, su := range sus {
+		health := SuHealth{SU: su, Healthy: true}
+		if h, ok := tracker.sus[su]; ok {
+			health.Failures = h.failures
+			health.DownUntil = h.downUntil
+			health.Healthy = !now.Before(h.downUntil)
+		}
+		result = append(result, health)
+	}
+
+	return result, nil
+}
+
+func getSuList(ctx context.Context, suType string) ([]string, *errors.Error) {
+	failoverLock.RLock()
+	provider := suListProvider
+	failoverLock.RUnlock()
+	if nil == provider {
+		return nil, errors.Errorf(constant.SystemInternalError, "The SU list provider is not set")
+	}
+
+	tracker.lock.Lock()
+	cached, ok := tracker.suLists[suType]
+	tracker.lock.Unlock()
+	if ok && time.Now().Before(cached.refreshAt) {
+		return cached.sus, nil
+	}
+
+	sus, err := provider(ctx, suType)
+	if nil != err {
+		return nil, err
+	}
+	refreshMilliseconds := constant.DefaultFailoverSuListRefreshMilliseconds
+	if nil != config.GetConfigs() && config.GetConfigs().Addressing.Failover.SuListRefreshMilliseconds > 0 {
+		refreshMilliseconds = config.GetConfigs().Addressing.Failover.SuListRefreshMilliseconds
+	}
+	refreshInterval := time.Duration(refreshMilliseconds) * time.Millisecond
+	tracker.lock.Lock()
+	tracker.suLists[suType] = &suList{
+		sus:       sus,
+		refreshAt: time.Now().Add(refreshInterval),
+	}
+	tracker.lock.Unlock()
+
+	return sus, nil
+}
+
+// isHealthy returns whether the SU is healthy, the SU is retried(half-open) after the down period
+func (h *healthTracker) isHealthy(su string) bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	health, ok := h.sus[su]
+	return !ok || !time.Now().Before(health.downUntil)
+}
+
+func (h *healthTracker) recordFailure(su string, failover config.Failover) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	health, ok := h.sus[su]
+	if !ok {
+		health = &suHealth{}
+		h.sus[su] = health
+	}
+	threshold := failover.FailureThreshold
+	if threshold <= 0 {
+		threshold = constant.DefaultFailoverFailureThreshold
+	}
+	recovery := failover.RecoveryMilliseconds
+	if recovery <= 0 {
+		recovery = constant.DefaultFailoverRecoveryMilliseconds
+	}
+	health.failures++
+	if health.failures >= threshold && !time.Now().Before(health.downUntil) {
+		health.downUntil = time.Now().Add(time.Duration(recovery) * time.Millisecond)
+	}
+}
+
+func (h *healthTracker) recordSuccess(su string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	delete(h.sus, su)
+}
+
+// healthOf returns the snapshot of the tracked health of SU
+func (h *healthTracker) healthOf(su string) suHealth {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	if health, ok := h.sus[su]; ok {
+		return *health
+	}
+
+	return suHealth{}
+}
+
+func isFailoverTopic(failover config.Failover, topicID string) bool {
+	for _, topic := range failover.Topics {
+		if strings.EqualFold(topic, topicID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isFailoverErrorCode(failover config.Failover, errorCode string) bool {
+	errorCodes := failover.ErrorCodes
+	if len(errorCodes) == 0 {
+		errorCodes = defaultFailoverErrorCodes
+	}
+	for _, code := range errorCodes {
+		if code == errorCode {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isStandbyOf checks whether the standby SU is in the SU list of the SU type, returns true if the SU list is unavailable
+func isStandbyOf(ctx context.Context, suType, standbySU string) bool {
+	sus, err := getSuList(ctx, suType)
+	if nil != err {
+		log.Debugf(ctx, "Cannot get the SU list of SU type[%s], skip checking the standby SU[%s], error:%s", suType, standbySU, err.Error())
+		return true
+	}
+	for _, su := range sus {
+		if su == standbySU {
+			return true
+		}
+	}
+
+	return false
+}
+
+// failoverIfNecessary returns the standby SU if the addressed SU is down and the topic is failover-capable,
+// the standby SU configured for the SU takes precedence over the CommonSu.
+func failoverIfNecessary(ctx context.Context, suType, topicID, su, commonSu string) (string, bool) {
+	if nil == config.GetConfigs() {
+		return su, false
+	}
+	failover := config.GetConfigs().Addressing.Failover
+	if !failover.Enable || !isFailoverTopic(failover, topicID) || tracker.isHealthy(su) {
+		return su, false
+	}
+
+	candidates := make([]string, 0, 2)
+	if standbySU := failover.StandbySUs[su]; "" != standbySU && isStandbyOf(ctx, suType, standbySU) {
+		candidates = append(candidates, standbySU)
+	}
+	if failover.FallbackToCommonSu && "" != commonSu {
+		candidates = append(candidates, commonSu)
+	}
+
+	for _, candidate := range candidates {
+		if candidate == su || !tracker.isHealthy(candidate) {
+			continue
+		}
+		// every failover is alerted
+		failoverLock.RLock()
+		alerter := failoverAlerter
+		failoverLock.RUnlock()
+		if nil != alerter {
+			health := tracker.healthOf(su)
+			alerter(ctx, &FailoverEvent{
+				SuType:    suType,
+				TopicID:   topicID,
+				FailedSU:  su,
+				StandbySU: candidate,
+				Failures:  health.failures,
+				DownUntil: health.downUntil.UnixNano() / int64(time.Millisecond),
+				Timestamp: time.Now().UnixNano() / int64(time.Millisecond),
+			})
+		}
+		return candidate, true
+	}
+
+	log.Errorf(ctx, "Addressing SU[%s] is down, but there is no healthy standby SU for topic[%s]", su, topicID)
+	return su, false
+}
+
+type addressedSUKey struct{}
+
+// recordCallResult tracks the result of the call to the SU addressed in `Before`,
+// nothing is recorded without the response meta(e.g. the async calls), the result of the call is unknown.
+func recordCallResult(ctx context.Context, responseMeta interface{}) {
+	su, ok := ctx.Value(addressedSUKey{}).(string)
+	if !ok || nil == config.GetConfigs() {
+		return
+	}
+	failover := config.GetConfigs().Addressing.Failover
+	if !failover.Enable {
+		return
+	}
+	meta, ok := responseMeta.(client.ResponseMeta)
+	if !ok || util.IsNil(meta) {
+		return
+	}
+
+	errorCode := meta.Header()[constant.ReturnErrorCode]
+	if isFailoverErrorCode(failover, errorCode) {
+		tracker.recordFailure(su, failover)
+	} else {
+		tracker.recordSuccess(su)
+	}
+}
@@ -0,0 +1,125 @@
+package addressing
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/client/mesh"
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/handler/config"
+)
+
+func TestFailoverIfNecessary(t *testing.T) {
+	defer func() {
+		ResetSuHealth()
+		SetSuListProvider(nil)
+	}()
+	config.SetConfigs(&config.ServiceConfigs{
+		Addressing: config.Addressing{
+			Failover: config.Failover{
+				Enable:                    true,
+				Topics:                    []string{"Transfer"},
+				StandbySUs:                map[string]string{"su1": "su1b"},
+				FallbackToCommonSu:        true,
+				FailureThreshold:          2,
+				RecoveryMilliseconds:      60 * 1000,
+				SuListRefreshMilliseconds: 60 * 1000,
+			},
+		},
+	})
+	alerts := make([]*FailoverEvent, 0)
+	SetFailoverAlerter(func(ctx context.Context, event *FailoverEvent) {
+		alerts = append(alerts, event)
+	})
+	SetSuListProvider(func(ctx context.Context, suType string) ([]string, *errors.Error) {
+		return []string{"su1", "su1b", "su2"}, nil
+	})
+
+	ctx := context.WithValue(context.Background(), addressedSUKey{}, "su1")
+	timeout := mesh.NewMeshResponseMeta(nil, map[string]string{constant.ReturnErrorCode: constant.SystemRemoteCallTimeout})
+	business := mesh.NewMeshResponseMeta(nil, map[string]string{constant.ReturnErrorCode: "BIZ0001"})
+
+	// the business errors reset the failures
+	recordCallResult(ctx, timeout)
+	recordCallResult(ctx, business)
+	recordCallResult(ctx, timeout)
+	su, ok := failoverIfNecessary(ctx, "ACC", "Transfer", "su1", "common")
+	assert.False(t, ok)
+	assert.Equal(t, "su1", su)
+
+	// the calls without the response meta(e.g. the async calls) are not recorded
+	recordCallResult(ctx, nil)
+	recordCallResult(ctx, timeout)
+	for i := 0; i < 2; i++ {
+		su, ok = failoverIfNecessary(ctx, "ACC", "Transfer", "su1", "common")
+		assert.True(t, ok)
+		assert.Equal(t, "su1b", su)
+	}
+	// every failover is alerted
+	assert.Equal(t, 2, len(alerts))
+	assert.Equal(t, "su1", alerts[0].FailedSU)
+	assert.Equal(t, "su1b", alerts[0].StandbySU)
+	assert.Equal(t, 2, alerts[1].Failures)
+
+	// the topics not marked as failover-capable are not failed over
+	su, ok = failoverIfNecessary(ctx, "ACC", "Query", "su1", "common")
+	assert.False(t, ok)
+
+	// fallback to CommonSu if the standby SU is down
+	standbyCtx := context.WithValue(context.Background(), addressedSUKey{}, "su1b")
+	recordCallResult(standbyCtx, timeout)
+	recordCallResult(standbyCtx, timeout)
+	su, ok = failoverIfNecessary(ctx, "ACC", "Transfer", "su1", "common")
+	assert.True(t, ok)
+	assert.Equal(t, "common", su)
+
+	health, err := GetSuHealth(ctx, "ACC")
+	assert.True(t, nil == err)
+	assert.Equal(t, 3, len(health))
+	assert.False(t, health[0].Healthy)
+	assert.False(t, health[1].Healthy)
+	assert.True(t, health[2].Healthy)
+
+	// the standby SU must be in the SU list
+	ResetSuHealth()
+	SetSuListProvider(func(ctx context.Context, suType string) ([]string, *errors.Error) {
+		return []string{"su1", "su2"}, nil
+	})
+	recordCallResult(ctx, timeout)
+	recordCallResult(ctx, timeout)
+	su, ok = failoverIfNecessary(ctx, "ACC", "Transfer", "su1", "")
+	assert.False(t, ok)
+	assert.Equal(t, "su1", su)
+}
+
+func TestFailoverWithDefaults(t *testing.T) {
+	defer ResetSuHealth()
+	config.SetConfigs(&config.ServiceConfigs{
+		Addressing: config.Addressing{
+			Failover: config.Failover{
+				Enable:     true,
+				Topics:     []string{"Transfer"},
+				StandbySUs: map[string]string{"su1": "su1b"},
+			},
+		},
+	})
+
+	ctx := context.WithValue(context.Background(), addressedSUKey{}, "su1")
+	timeout := mesh.NewMeshResponseMeta(nil, map[string]string{constant.ReturnErrorCode: constant.SystemRemoteCallTimeout})
+
+	// the SU is marked as down at the default failure threshold, and kept down for the default recovery time
+	for i := 0; i < constant.DefaultFailoverFailureThreshold-1; i++ {
+		recordCallResult(ctx, timeout)
+	}
+	_, ok := failoverIfNecessary(ctx, "ACC", "Transfer", "su1", "")
+	assert.False(t, ok)
+	recordCallResult(ctx, timeout)
+	su, ok := failoverIfNecessary(ctx, "ACC", "Transfer", "su1", "")
+	assert.True(t, ok)
+	assert.Equal(t, "su1b", su)
+	health := tracker.healthOf("su1")
+	assert.True(t, health.downUntil.After(time.Now().Add((constant.DefaultFailoverRecoveryMilliseconds-1000)*time.Millisecond)))
+}
@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"git.multiverse.io/eventkit/kit/codec"
+	"git.multiverse.io/eventkit/kit/common/compressor"
 	"git.multiverse.io/eventkit/kit/handler/config"
 	"git.multiverse.io/eventkit/kit/interceptor"
 	"git.multiverse.io/eventkit/kit/wrapper"
@@ -76,6 +77,7 @@ type RequestOptions struct {
 	OriginalHeader   						map[string]string
 	Masker                           		config.Masker
 	EnableLogging           				bool
+	Compression                             *compressor.Options
 
 	HTTPCall    bool
 	Address     string
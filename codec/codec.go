@@ -2,8 +2,10 @@ package codec
 
 import (
 	"git.multiverse.io/eventkit/kit/codec/json"
+	"git.multiverse.io/eventkit/kit/codec/protobuf"
 	"git.multiverse.io/eventkit/kit/codec/text"
 	"git.multiverse.io/eventkit/kit/codec/xml"
+	"git.multiverse.io/eventkit/kit/constant"
 )
 
 // Codec is an interface that defines the create function of Encoder/Decoder
@@ -12,13 +14,30 @@ type Codec interface {
 	Decoder() Decoder
 }
 
+// ContentTyper is implemented by the codecs that declare the content type of the encoded data,
+// the content type is sent with the encoded data so that the receiver decodes it with the same codec
+type ContentTyper interface {
+	ContentType() string
+}
+
 type impl struct {
-	encoder func() Encoder
-	decoder func() Decoder
+	encoder     func() Encoder
+	decoder     func() Decoder
+	contentType string
 }
 
-func (c *impl) Encoder() Encoder { return c.encoder() }
-func (c *impl) Decoder() Decoder { return c.decoder() }
+func (c *impl) Encoder() Encoder    { return c.encoder() }
+func (c *impl) Decoder() Decoder    { return c.decoder() }
+func (c *impl) ContentType() string { return c.contentType }
+
+// ContentTypeOf returns the content type declared by the codec, returns empty if the codec doesn't declare the content type
+func ContentTypeOf(c Codec) string {
+	if contentTyper, ok := c.(ContentTyper); ok {
+		return contentTyper.ContentType()
+	}
+
+	return ""
+}
 
 // BuildCustomCodec creates a new codec with customize Encoder and Decoder
 func BuildCustomCodec(encoder Encoder, decoder Decoder) Codec {
@@ -51,3 +70,13 @@ func BuildTextCodec() Codec {
 		decoder: func() Decoder { return &text.Decoder{} },
 	}
 }
+
+// BuildProtobufCodec creates a new Codec that used to protobuf marshal/unmarshal the proto.Message,
+// the codec declares the content type `application/x-protobuf`
+func BuildProtobufCodec() Codec {
+	return &impl{
+		encoder:     func() Encoder { return &protobuf.Encoder{} },
+		decoder:     func() Decoder { return &protobuf.Decoder{} },
+		contentType: constant.ContentTypeProtobuf,
+	}
+}
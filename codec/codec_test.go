@@ -2,6 +2,7 @@ package codec
 
 import (
 	"fmt"
+	"git.multiverse.io/eventkit/kit/codec/protobuf"
 	"github.com/go-playground/assert/v2"
 	"testing"
 )
@@ -78,3 +79,11 @@ func TestFindType(t *testing.T) {
 	findTypeWrapper(bs)
 	findTypeWrapper(&bs)
 }
+
+func TestProtobufCodec(t *testing.T) {
+	codec := BuildProtobufCodec()
+	assert.Equal(t, "application/x-protobuf", ContentTypeOf(codec))
+	assert.Equal(t, 0, len(ContentTypeOf(BuildJSONCodec())))
+	assert.Equal(t, &protobuf.Encoder{}, codec.Encoder())
+	assert.Equal(t, &protobuf.Decoder{}, codec.Decoder())
+}
@@ -0,0 +1,52 @@
+package protobuf
+
+import (
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/util"
+	"git.multiverse.io/eventkit/kit/constant"
+	"google.golang.org/protobuf/proto"
+)
+
+// Decoder is an implement of codec.Decoder used to decode protobuf byte array into a proto.Message
+type Decoder struct{}
+
+// Encoder is an implement of codec.Encoder used to encode a proto.Message into protobuf byte array
+type Encoder struct{}
+
+// Decode decodes byte array into a proto.Message
+func (p *Decoder) Decode(data []byte, v interface{}) error {
+	if util.IsNil(v) {
+		return nil
+	}
+	message, ok := v.(proto.Message)
+	if !ok {
+		return errors.Errorf(constant.SystemInternalError, "The target[%T] isn't a proto.Message", v)
+	}
+	if nil == data || len(data) == 0 {
+		return nil
+	}
+
+	if err := proto.Unmarshal(data, message); nil != err {
+		return errors.Wrap(constant.SystemInternalError, err, 0)
+	}
+
+	return nil
+}
+
+// Encode encodes a proto.Message into byte array
+func (p *Encoder) Encode(v interface{}) ([]byte, error) {
+	if util.IsNil(v) {
+		return make([]byte, 0), nil
+	}
+	message, ok := v.(proto.Message)
+	if !ok {
+		return nil, errors.Errorf(constant.SystemInternalError, "The object[%T] isn't a proto.Message", v)
+	}
+
+	bs, err := proto.Marshal(message)
+	if nil != err {
+		return nil, errors.Wrap(constant.SystemInternalError, err, 0)
+	}
+
+	return bs, nil
+}
@@ -0,0 +1,35 @@
+package protobuf
+
+import (
+	"testing"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestProtobufCodec(t *testing.T) {
+	encoder := Encoder{}
+	bs, err := encoder.Encode(wrapperspb.String("test field A"))
+	assert.True(t, nil == err)
+	assert.True(t, len(bs) > 0)
+
+	decoder := Decoder{}
+	message := &wrapperspb.StringValue{}
+	assert.True(t, nil == decoder.Decode(bs, message))
+	assert.Equal(t, "test field A", message.GetValue())
+
+	// the empty message
+	var empty *wrapperspb.StringValue
+	bs, err = encoder.Encode(empty)
+	assert.True(t, nil == err)
+	assert.Equal(t, 0, len(bs))
+	assert.True(t, nil == decoder.Decode(nil, &wrapperspb.StringValue{}))
+
+	// not a proto.Message
+	_, err = encoder.Encode(struct{ A string }{A: "a"})
+	assert.NotNil(t, err)
+	assert.NotNil(t, decoder.Decode(bs, &struct{ A string }{}))
+
+	// the invalid data
+	assert.NotNil(t, decoder.Decode([]byte{0xff, 0xff}, &wrapperspb.StringValue{}))
+}
@@ -0,0 +1,76 @@
+package registry
+
+import (
+	"strings"
+	"sync"
+
+	"git.multiverse.io/eventkit/kit/codec"
+	"git.multiverse.io/eventkit/kit/codec/auto"
+	"git.multiverse.io/eventkit/kit/constant"
+)
+
+type registry struct {
+	sync.RWMutex
+	codecs map[string]codec.Codec
+	// contentTypes keeps the order of the registration, the codecs are negotiated in the order
+	contentTypes []string
+}
+
+var defaultRegistry = newRegistry()
+
+func newRegistry() *registry {
+	r := &registry{codecs: make(map[string]codec.Codec)}
+	r.register(constant.DefaultContentTypeJSON, auto.BuildAutoCodecWithJSONCodec())
+	r.register("application/xml", auto.BuildAutoCodec(codec.BuildXMLCodec()))
+	r.register("text/xml", auto.BuildAutoCodec(codec.BuildXMLCodec()))
+	r.register("text/plain", codec.BuildTextCodec())
+	r.register(constant.ContentTypeProtobuf, codec.BuildProtobufCodec())
+	r.register("application/protobuf", codec.BuildProtobufCodec())
+
+	return r
+}
+
+func (r *registry) register(contentType string, c codec.Codec) {
+	r.Lock()
+	defer r.Unlock()
+
+	mediaType := MediaType(contentType)
+	if _, ok := r.codecs[mediaType]; !ok {
+		r.contentTypes = append(r.contentTypes, mediaType)
+	}
+	r.codecs[mediaType] = c
+}
+
+// MediaType returns the lower case media type of the content type without the parameters, e.g. `text/plain; charset=utf-8` returns `text/plain`
+func MediaType(contentType string) string {
+	if idx := strings.Index(contentType, ";"); idx >= 0 {
+		contentType = contentType[:idx]
+	}
+
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
+
+// Register registers the codec of the content type, the codec registered later replaces the former codec of the same content type
+func Register(contentType string, c codec.Codec) {
+	defaultRegistry.register(contentType, c)
+}
+
+// Lookup returns the codec registered for the content type, the parameters of the content type are ignored
+func Lookup(contentType string) (codec.Codec, bool) {
+	defaultRegistry.RLock()
+	defer defaultRegistry.RUnlock()
+
+	c, ok := defaultRegistry.codecs[MediaType(contentType)]
+	return c, ok
+}
+
+// ContentTypes returns the registered content types in the order of the registration
+func ContentTypes() []string {
+	defaultRegistry.RLock()
+	defer defaultRegistry.RUnlock()
+
+	contentTypes := make([]string, len(defaultRegistry.contentTypes))
+	copy(contentTypes, defaultRegistry.contentTypes)
+
+	return contentTypes
+}
@@ -0,0 +1,44 @@
+package registry
+
+import (
+	"testing"
+
+	"git.multiverse.io/eventkit/kit/codec"
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/constant"
+)
+
+func TestMediaType(t *testing.T) {
+	assert.Equal(t, "text/plain", MediaType("Text/Plain; charset=utf-8"))
+	assert.Equal(t, constant.DefaultContentTypeJSON, MediaType(" application/json "))
+}
+
+func TestLookup(t *testing.T) {
+	c, ok := Lookup("application/x-protobuf")
+	assert.True(t, ok)
+	assert.Equal(t, constant.ContentTypeProtobuf, codec.ContentTypeOf(c))
+
+	_, ok = Lookup("application/json; charset=utf-8")
+	assert.True(t, ok)
+	_, ok = Lookup("application/octet-stream")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{constant.DefaultContentTypeJSON, "application/xml", "text/xml", "text/plain", constant.ContentTypeProtobuf, "application/protobuf"}, ContentTypes())
+}
+
+func TestRegister(t *testing.T) {
+	defer func() {
+		defaultRegistry = newRegistry()
+	}()
+
+	Register("application/x-custom", codec.BuildTextCodec())
+	_, ok := Lookup("APPLICATION/X-CUSTOM")
+	assert.True(t, ok)
+
+	// replaces the former codec
+	Register("text/plain", codec.BuildJSONCodec())
+	c, _ := Lookup("text/plain")
+	assert.Equal(t, codec.BuildJSONCodec().Encoder(), c.Encoder())
+	assert.Equal(t, 7, len(ContentTypes()))
+	assert.Equal(t, "application/x-custom", ContentTypes()[6])
+}
@@ -108,6 +108,17 @@ func (l *Log) refile() error {
 	return nil
 }
 
+// Flush commits the written APM logs to the file
+func (l *Log) Flush() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if f, ok := l.log.Writer().(*os.File); ok {
+		return f.Sync()
+	}
+
+	return nil
+}
+
 // APMlogf formats according to a format specifier and writes the resulting string to the APM file
 func (l *Log) APMlogf(format string, v ...interface{}) error {
 	l.writer([]byte(fmt.Sprintf(format+"\n", v...)))
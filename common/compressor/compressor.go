@@ -0,0 +1,254 @@
+// Package compressor implements the payload compression of the protocol messages.
+// The compressed payload is signalled by the app property constant.ContentEncoding that contains the algorithm,
+// the receiver decompresses the payload and deletes the app property transparently.
+package compressor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/constant"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// Gzip is the name of the gzip algorithm
+	Gzip = "gzip"
+	// Zstd is the name of the zstd algorithm
+	Zstd = "zstd"
+	// Snappy is the name of the snappy algorithm
+	Snappy = "snappy"
+)
+
+// DefaultThreshold is the default minimum payload size(bytes) to compress
+const DefaultThreshold = 4096
+
+// MaxDecompressedSize is the max size(bytes) of the decompressed payload,
+// the payload exceeding it is rejected so that a small compressed payload cannot exhaust the memory.
+var MaxDecompressedSize = 64 << 20
+
+// Compressor compresses and decompresses the payload
+type Compressor interface {
+	Name() string
+	Compress(data []byte) ([]byte, error)
+	Decompress(data []byte) ([]byte, error)
+}
+
+var (
+	lock        sync.RWMutex
+	compressors = map[string]Compressor{
+		Gzip:   &gzipCompressor{},
+		Zstd:   &zstdCompressor{},
+		Snappy: &snappyCompressor{},
+	}
+)
+
+// Register registers a compressor, the registered compressor will replace the existing one with the same name
+func Register(compressor Compressor) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	compressors[strings.ToLower(compressor.Name())] = compressor
+}
+
+// Get returns the compressor of the algorithm
+func Get(algorithm string) (Compressor, bool) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	compressor, ok := compressors[strings.ToLower(algorithm)]
+
+	return compressor, ok
+}
+
+// Options defines the options of the payload compression
+type Options struct {
+	// Algorithm is the name of the compressor, empty means the compression is disabled
+	Algorithm string
+	// Threshold is the minimum payload size(bytes) to compress, 0 means DefaultThreshold
+	Threshold int
+}
+
+// IsEnabled returns true if the payload of the size should be compressed
+func (o *Options) IsEnabled(size int) bool {
+	if nil == o || "" == o.Algorithm {
+		return false
+	}
+	threshold := o.Threshold
+	if threshold <= 0 {
+		threshold = DefaultThreshold
+	}
+
+	return size >= threshold
+}
+
+// Compress compresses the payload with the algorithm
+func Compress(algorithm string, data []byte) ([]byte, *errors.Error) {
+	compressor, ok := Get(algorithm)
+	if !ok {
+		return nil, errors.Errorf(constant.CompressionError, "Unknown compression algorithm[%s]", algorithm)
+	}
+	compressed, err := compressor.Compress(data)
+	if nil != err {
+		return nil, errors.Wrap(constant.CompressionError, err, 0)
+	}
+
+	return compressed, nil
+}
+
+// Decompress decompresses the payload with the algorithm
+func Decompress(algorithm string, data []byte) ([]byte, *errors.Error) {
+	compressor, ok := Get(algorithm)
+	if !ok {
+		return nil, errors.Errorf(constant.CompressionError, "Unknown compression algorithm[%s]", algorithm)
+	}
+	decompressed, err := compressor.Decompress(data)
+	if nil != err {
+		return nil, errors.Wrap(constant.CompressionError, err, 0)
+	}
+	if len(decompressed) > MaxDecompressedSize {
+		return nil, errors.Errorf(constant.CompressionError, "The decompressed payload exceeds the max size[%d]", MaxDecompressedSize)
+	}
+
+	return decompressed, nil
+}
+
+// CompressIfNecessary compresses the payload if the compression is enabled and the payload reaches the threshold,
+// the algorithm is set into the app properties. The payload that has been compressed is returned as it is.
+func CompressIfNecessary(appProps map[string]string, body []byte, options *Options) ([]byte, *errors.Error) {
+	if nil == appProps || "" != appProps[constant.ContentEncoding] || !options.IsEnabled(len(body)) {
+		return body, nil
+	}
+	compressed, err := Compress(options.Algorithm, body)
+	if nil != err {
+		return nil, err
+	}
+	appProps[constant.ContentEncoding] = strings.ToLower(options.Algorithm)
+
+	return compressed, nil
+}
+
+// DecompressIfNecessary decompresses the payload if the app properties contain the algorithm,
+// the algorithm is deleted from the app properties after decompressing.
+func DecompressIfNecessary(appProps map[string]string, body []byte) ([]byte, *errors.Error) {
+	algorithm := appProps[constant.ContentEncoding]
+	if "" == algorithm {
+		return body, nil
+	}
+	decompressed, err := Decompress(algorithm, body)
+	if nil != err {
+		return nil, err
+	}
+	delete(appProps, constant.ContentEncoding)
+
+	return decompressed, nil
+}
+
+type gzipCompressor struct{}
+
+func (c *gzipCompressor) Name() string {
+	return Gzip
+}
+
+func (c *gzipCompressor) Compress(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); nil != err {
+		return nil, err
+	}
+	if err := writer.Close(); nil != err {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c *gzipCompressor) Decompress(data []byte) ([]byte, error) {
+	reader, err := gzip.NewReader(bytes.NewReader(data))
+	if nil != err {
+		return nil, err
+	}
+	defer reader.Close()
+
+	decompressed, err := ioutil.ReadAll(io.LimitReader(reader, int64(MaxDecompressedSize)+1))
+	if nil != err {
+		return nil, err
+	}
+	if len(decompressed) > MaxDecompressedSize {
+		return nil, errExceedMaxDecompressedSize()
+	}
+
+	return decompressed, nil
+}
+
+func errExceedMaxDecompressedSize() error {
+	return fmt.Errorf("the decompressed payload exceeds the max size[%d]", MaxDecompressedSize)
+}
+
+type zstdCompressor struct {
+	once    sync.Once
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+	err     error
+}
+
+func (c *zstdCompressor) Name() string {
+	return Zstd
+}
+
+// init creates the encoder and decoder lazily, both of them are safe for the concurrent EncodeAll and DecodeAll
+func (c *zstdCompressor) init() error {
+	c.once.Do(func() {
+		if c.encoder, c.err = zstd.NewWriter(nil); nil != c.err {
+			return
+		}
+		c.decoder, c.err = zstd.NewReader(nil, zstd.WithDecoderMaxMemory(uint64(MaxDecompressedSize)))
+	})
+
+	return c.err
+}
+
+func (c *zstdCompressor) Compress(data []byte) ([]byte, error) {
+	if err := c.init(); nil != err {
+		return nil, err
+	}
+
+	return c.encoder.EncodeAll(data, nil), nil
+}
+
+func (c *zstdCompressor) Decompress(data []byte) ([]byte, error) {
+	if err := c.init(); nil != err {
+		return nil, err
+	}
+
+	return c.decoder.DecodeAll(data, nil)
+}
+
+type snappyCompressor struct{}
+
+func (c *snappyCompressor) Name() string {
+	return Snappy
+}
+
+func (c *snappyCompressor) Compress(data []byte) ([]byte, error) {
+	return snappy.Encode(nil, data), nil
+}
+
+func (c *snappyCompressor) Decompress(data []byte) ([]byte, error) {
+	size, err := snappy.DecodedLen(data)
+	if nil != err {
+		return nil, err
+	}
+	if size > MaxDecompressedSize {
+		return nil, errExceedMaxDecompressedSize()
+	}
+
+	return snappy.Decode(nil, data)
+}
@@ -0,0 +1,82 @@
+package compressor
+
+import (
+	"bytes"
+	"testing"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/constant"
+)
+
+func TestCompressAndDecompress(t *testing.T) {
+	data := bytes.Repeat([]byte(`{"statement":"large payload"}`), 100)
+	for _, algorithm := range []string{Gzip, Zstd, Snappy} {
+		compressed, err := Compress(algorithm, data)
+		assert.True(t, nil == err)
+		assert.True(t, len(compressed) < len(data))
+
+		decompressed, err := Decompress(algorithm, compressed)
+		assert.True(t, nil == err)
+		assert.Equal(t, data, decompressed)
+	}
+
+	_, err := Compress("unknown", data)
+	assert.NotNil(t, err)
+	assert.Equal(t, constant.CompressionError, err.ErrorCode)
+
+	_, err = Decompress(Gzip, data)
+	assert.NotNil(t, err)
+}
+
+func TestCompressIfNecessary(t *testing.T) {
+	data := bytes.Repeat([]byte("a"), 100)
+	appProps := map[string]string{}
+
+	// disabled or smaller than the threshold
+	body, err := CompressIfNecessary(appProps, data, nil)
+	assert.True(t, nil == err)
+	assert.Equal(t, data, body)
+	body, _ = CompressIfNecessary(appProps, data, &Options{Algorithm: Zstd})
+	assert.Equal(t, data, body)
+	assert.Equal(t, "", appProps[constant.ContentEncoding])
+
+	body, err = CompressIfNecessary(appProps, data, &Options{Algorithm: Zstd, Threshold: 10})
+	assert.True(t, nil == err)
+	assert.Equal(t, Zstd, appProps[constant.ContentEncoding])
+
+	// the compressed payload is not compressed again
+	compressedAgain, _ := CompressIfNecessary(appProps, body, &Options{Algorithm: Gzip, Threshold: 1})
+	assert.Equal(t, body, compressedAgain)
+
+	decompressed, err := DecompressIfNecessary(appProps, body)
+	assert.True(t, nil == err)
+	assert.Equal(t, data, decompressed)
+	assert.Equal(t, "", appProps[constant.ContentEncoding])
+
+	// not compressed
+	decompressed, err = DecompressIfNecessary(appProps, data)
+	assert.True(t, nil == err)
+	assert.Equal(t, data, decompressed)
+}
+
+func TestDecompressWithMaxDecompressedSize(t *testing.T) {
+	defer func(size int) {
+		MaxDecompressedSize = size
+	}(MaxDecompressedSize)
+	MaxDecompressedSize = 1024
+
+	data := bytes.Repeat([]byte("a"), MaxDecompressedSize+1)
+	for _, algorithm := range []string{Gzip, Zstd, Snappy} {
+		compressed, err := Compress(algorithm, data)
+		assert.True(t, nil == err)
+
+		_, err = Decompress(algorithm, compressed)
+		assert.NotNil(t, err)
+		assert.Equal(t, constant.CompressionError, err.ErrorCode)
+
+		compressed, _ = Compress(algorithm, data[1:])
+		decompressed, err := Decompress(algorithm, compressed)
+		assert.True(t, nil == err)
+		assert.Equal(t, MaxDecompressedSize, len(decompressed))
+	}
+}
@@ -0,0 +1,292 @@
+// Package health implements the health check registry of the service.
+// The checks registered by the apps and the kit are evaluated into a health status,
+// and the components(e.g. DB/cache pools, addressing cache, circuit breakers) contribute their details,
+// the report is sent with the heartbeat so that the platform can tell a live-but-broken instance from a healthy one.
+package health
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// The following elements represent the health status.
+const (
+	StatusUp       = "UP"
+	StatusDegraded = "DEGRADED"
+	StatusDown     = "DOWN"
+)
+
+// DefaultCheckTimeout is the default timeout of a health check
+const DefaultCheckTimeout = 3 * time.Second
+
+// CheckFunc checks the health, returns nil if healthy
+type CheckFunc func(ctx context.Context) error
+
+// ComponentFunc returns the details of a component, the result must be able to marshal into JSON
+type ComponentFunc func() interface{}
+
+// CheckOptions is the options of a health check
+type CheckOptions struct {
+	// Critical marks the service is DOWN if the check fails, otherwise the service is DEGRADED
+	Critical bool
+	// Timeout is the max execution time of the check, default DefaultCheckTimeout
+	Timeout time.Duration
+}
+
+// CheckOption is used to modify the options of a health check
+type CheckOption func(*CheckOptions)
+
+// WithCritical marks the check as critical
+func WithCritical(critical bool) CheckOption {
+	return func(options *CheckOptions) {
+		options.Critical = critical
+	}
+}
+
+// WithTimeout modifies the timeout of the check
+func WithTimeout(timeout time.Duration) CheckOption {
+	return func(options *CheckOptions) {
+		options.Timeout = timeout
+	}
+}
+
+// CheckResult is the result of a health check
+type CheckResult struct {
+	Name                 string `json:"name"`
+	Status               string `json:"status"`
+	Critical             bool   `json:"critical"`
+	Error                string `json:"error,omitempty"`
+	DurationMilliseconds int64  `json:"durationMilliseconds"`
+}
+
+// RuntimeStats is the goroutine and memory statistics of the process
+type RuntimeStats struct {
+	Goroutines   int    `json:"goroutines"`
+	HeapAlloc    uint64 `json:"heapAlloc"`
+	HeapInuse    uint64 `json:"heapInuse"`
+	HeapObjects  uint64 `json:"heapObjects"`
+	Sys          uint64 `json:"sys"`
+	NumGC        uint32 `json:"numGC"`
+	PauseTotalNs uint64 `json:"pauseTotalNs"`
+}
+
+// Report is the health report of the service
+type Report struct {
+	Status     string                 `json:"status"`
+	Runtime    RuntimeStats           `json:"runtime"`
+	Checks     []CheckResult          `json:"checks,omitempty"`
+	Components map[string]interface{} `json:"components,omitempty"`
+}
+
+type check struct {
+	name    string
+	fn      CheckFunc
+	options CheckOptions
+}
+
+// Registry stores the health checks and the components
+type Registry struct {
+	lock       sync.RWMutex
+	checks     map[string]*check
+	components map[string]ComponentFunc
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		checks:     make(map[string]*check),
+		components: make(map[string]ComponentFunc),
+	}
+}
+
+// Register registers a health check, the check with the same name is replaced
+func (r *Registry) Register(name string, fn CheckFunc, opts ...CheckOption) {
+	options := CheckOptions{Timeout: DefaultCheckTimeout}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = DefaultCheckTimeout
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.checks[name] = &check{name: name, fn: fn, options: options}
+}
+
+// Unregister removes the health check
+func (r *Registry) Unregister(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.checks, name)
+}
+
+// RegisterComponent registers the details function of a component, the component with the same name is replaced
+func (r *Registry) RegisterComponent(name string, fn ComponentFunc) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.components[name] = fn
+}
+
+// UnregisterComponent removes the component
+func (r *Registry) UnregisterComponent(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	delete(r.components, name)
+}
+
+// Evaluate executes all the checks concurrently and collects the details of the components.
+// The status is DOWN if any critical check fails, DEGRADED if any non-critical check fails, otherwise UP.
+func (r *Registry) Evaluate(ctx context.Context) Report {
+	r.lock.RLock()
+	checks := make([]*check, 0, len(r.checks))
+	for _, c := range r.checks {
+		checks = append(checks, c)
+	}
+	components := make(map[string]ComponentFunc, len(r.components))
+	for name, fn := range r.components {
+		components[name] = fn
+	}
+	r.lock.RUnlock()
+
+	report := Report{
+		Status:  StatusUp,
+		Runtime: CollectRuntimeStats(),
+	}
+
+	if len(checks) > 0 {
+		report.Checks = make([]CheckResult, len(checks))
+		var wg sync.WaitGroup
+		for i, c := range checks {
+			wg.Add(1)
+			go func(i int, c *check) {
+				defer wg.Done()
+				report.Checks[i] = c.run(ctx)
+			}(i, c)
+		}
+		wg.Wait()
+		sort.Slice(report.Checks, func(i, j int) bool {
+			return report.Checks[i].Name < report.Checks[j].Name
+		})
+		for _, result := range report.Checks {
+			if StatusUp == result.Status {
+				continue
+			}
+			if result.Critical {
+				report.Status = StatusDown
+			} else if StatusUp == report.Status {
+				report.Status = StatusDegraded
+			}
+		}
+	}
+
+	if len(components) > 0 {
+		report.Components = make(map[string]interface{}, len(components))
+		for name, fn := range components {
+			report.Components[name] = componentDetails(fn)
+		}
+	}
+
+	return report
+}
+
+func (c *check) run(ctx context.Context) (result CheckResult) {
+	result = CheckResult{
+		Name:     c.name,
+		Status:   StatusUp,
+		Critical: c.options.Critical,
+	}
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, c.options.Timeout)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		defer func() {
+			if e := recover(); nil != e {
+				errCh <- fmt.Errorf("health check panic: %v", e)
+			}
+		}()
+		errCh <- c.fn(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-errCh:
+	case <-ctx.Done():
+		err = fmt.Errorf("health check timeout: %v", ctx.Err())
+	}
+	result.DurationMilliseconds = time.Since(start).Milliseconds()
+	if nil != err {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+func componentDetails(fn ComponentFunc) (details interface{}) {
+	defer func() {
+		if e := recover(); nil != e {
+			details = map[string]string{"error": fmt.Sprintf("%v", e)}
+		}
+	}()
+
+	return fn()
+}
+
+// CollectRuntimeStats returns the goroutine and memory statistics of the process
+func CollectRuntimeStats() RuntimeStats {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	return RuntimeStats{
+		Goroutines:   runtime.NumGoroutine(),
+		HeapAlloc:    memStats.HeapAlloc,
+		HeapInuse:    memStats.HeapInuse,
+		HeapObjects:  memStats.HeapObjects,
+		Sys:          memStats.Sys,
+		NumGC:        memStats.NumGC,
+		PauseTotalNs: memStats.PauseTotalNs,
+	}
+}
+
+var defaultRegistry = NewRegistry()
+
+// Default returns the global registry of the service
+func Default() *Registry {
+	return defaultRegistry
+}
+
+// Register registers a health check into the global registry
+func Register(name string, fn CheckFunc, opts ...CheckOption) {
+	defaultRegistry.Register(name, fn, opts...)
+}
+
+// Unregister removes the health check from the global registry
+func Unregister(name string) {
+	defaultRegistry.Unregister(name)
+}
+
+// RegisterComponent registers the details function of a component into the global registry
+func RegisterComponent(name string, fn ComponentFunc) {
+	defaultRegistry.RegisterComponent(name, fn)
+}
+
+// UnregisterComponent removes the component from the global registry
+func UnregisterComponent(name string) {
+	defaultRegistry.UnregisterComponent(name)
+}
+
+// Evaluate evaluates the health report of the global registry
+func Evaluate(ctx context.Context) Report {
+	return defaultRegistry.Evaluate(ctx)
+}
@@ -0,0 +1,69 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+)
+
+func TestRegistry_Evaluate(t *testing.T) {
+	registry := NewRegistry()
+	report := registry.Evaluate(context.Background())
+	assert.Equal(t, StatusUp, report.Status)
+	assert.True(t, report.Runtime.Goroutines > 0)
+	assert.Equal(t, 0, len(report.Checks))
+
+	registry.Register("ok", func(ctx context.Context) error {
+		return nil
+	}, WithCritical(true))
+	registry.Register("optional", func(ctx context.Context) error {
+		return fmt.Errorf("optional dependency is unavailable")
+	})
+	registry.RegisterComponent("pool", func() interface{} {
+		return map[string]int{"size": 10}
+	})
+	registry.RegisterComponent("broken", func() interface{} {
+		panic("broken component")
+	})
+
+	report = registry.Evaluate(context.Background())
+	assert.Equal(t, StatusDegraded, report.Status)
+	assert.Equal(t, 2, len(report.Checks))
+	assert.Equal(t, "ok", report.Checks[0].Name)
+	assert.Equal(t, StatusUp, report.Checks[0].Status)
+	assert.Equal(t, "optional", report.Checks[1].Name)
+	assert.Equal(t, StatusDown, report.Checks[1].Status)
+	assert.Equal(t, "optional dependency is unavailable", report.Checks[1].Error)
+	assert.Equal(t, map[string]int{"size": 10}, report.Components["pool"])
+	assert.Equal(t, map[string]string{"error": "broken component"}, report.Components["broken"])
+
+	registry.Register("critical", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}, WithCritical(true), WithTimeout(10*time.Millisecond))
+	report = registry.Evaluate(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, StatusDown, report.Checks[0].Status)
+	assert.True(t, report.Checks[0].Critical)
+
+	registry.Unregister("critical")
+	registry.Unregister("optional")
+	registry.UnregisterComponent("broken")
+	report = registry.Evaluate(context.Background())
+	assert.Equal(t, StatusUp, report.Status)
+	assert.Equal(t, 1, len(report.Components))
+}
+
+func TestRegistry_CheckPanic(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register("panic", func(ctx context.Context) error {
+		panic("unexpected")
+	}, WithCritical(true))
+
+	report := registry.Evaluate(context.Background())
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, "health check panic: unexpected", report.Checks[0].Error)
+}
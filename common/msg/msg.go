@@ -2,6 +2,7 @@ package msg
 
 import (
 	"encoding/json"
+	"git.multiverse.io/eventkit/kit/common/compressor"
 	"git.multiverse.io/eventkit/kit/common/errors"
 	"git.multiverse.io/eventkit/kit/common/util"
 	"git.multiverse.io/eventkit/kit/constant"
@@ -22,6 +23,12 @@ type Message struct {
 
 	RequestURL string
 
+	// PathParams stores the parameters of the URL path, e.g. `id` of `/accounts/:id`
+	PathParams map[string]string
+
+	// QueryParams stores the query arguments of the HTTP request
+	QueryParams map[string][]string
+
 	// True means this is a synchronous call message
 	// False means this is an asynchronous call message
 	NeedReply bool
@@ -71,6 +78,18 @@ func (msg Message) GetMsgTopicId() string {
 	return msg.TopicAttribute[constant.TopicID]
 }
 
+// GetMsgTopicVersion is used to get the destination event version of message
+func (msg *Message) GetMsgTopicVersion() string {
+	if nil == msg.TopicAttribute {
+		return ""
+	}
+	if version := msg.TopicAttribute[constant.TopicDestinationVersion]; "" != version {
+		return version
+	}
+
+	return msg.TopicAttribute[constant.TopicVersion]
+}
+
 // GetSourceORG gets source org. from topic attributes
 func (msg Message) GetSourceORG() string {
 	if nil == msg.TopicAttribute {
@@ -258,6 +277,36 @@ func (msg *Message) DeleteProperty(key string) {
 	delete(msg.appProps, key)
 }
 
+// CompressBody compresses the payload with the compression options if the payload reaches the threshold,
+// the algorithm is set into the app property constant.ContentEncoding.
+func (msg *Message) CompressBody(options *compressor.Options) *errors.Error {
+	msg.appPropsRWLock.Lock()
+	defer func() { msg.appPropsRWLock.Unlock() }()
+	if nil == msg.appProps {
+		msg.appProps = make(map[string]string, 0)
+	}
+	body, err := compressor.CompressIfNecessary(msg.appProps, msg.Body, options)
+	if nil != err {
+		return err
+	}
+	msg.Body = body
+
+	return nil
+}
+
+// DecompressBody decompresses the payload if the app property constant.ContentEncoding exists
+func (msg *Message) DecompressBody() *errors.Error {
+	msg.appPropsRWLock.Lock()
+	defer func() { msg.appPropsRWLock.Unlock() }()
+	body, err := compressor.DecompressIfNecessary(msg.appProps, msg.Body)
+	if nil != err {
+		return err
+	}
+	msg.Body = body
+
+	return nil
+}
+
 // AppPropsToString converts app properties into string
 func (msg Message) AppPropsToString() string {
 	msg.appPropsRWLock.RLock()
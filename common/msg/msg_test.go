@@ -87,6 +87,12 @@ func TestMessage_GetMsgAttributes(t *testing.T) {
 	assert.True(t, msg.IsValidTopicType())
 }
 
+func TestMessage_GetMsgTopicVersion(t *testing.T) {
+	assert.Equal(t, "", (&Message{}).GetMsgTopicVersion())
+	assert.Equal(t, "v1", (&Message{TopicAttribute: map[string]string{constant.TopicVersion: "v1"}}).GetMsgTopicVersion())
+	assert.Equal(t, "v2", (&Message{TopicAttribute: map[string]string{constant.TopicVersion: "v1", constant.TopicDestinationVersion: "v2"}}).GetMsgTopicVersion())
+}
+
 func TestMessage_JudgeUserLang(t *testing.T) {
 	assert.Equal(t, msg.JudgeUserLang(), constant.LangEnUS)
 }
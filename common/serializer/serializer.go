@@ -4,6 +4,7 @@ import (
 	"encoding/base64"
 	"encoding/binary"
 	"git.multiverse.io/eventkit/kit/common/bytebuf"
+	"git.multiverse.io/eventkit/kit/common/compressor"
 	"git.multiverse.io/eventkit/kit/common/errors"
 	"git.multiverse.io/eventkit/kit/common/protocol"
 	"git.multiverse.io/eventkit/kit/constant"
@@ -365,8 +366,12 @@ func Bytes2protoMsg(inputBytes []byte) (protocol.ProtoMessage, error) {
 		msg.AppProps = make(map[string]string)
 	}
 
-	// payload
-	msg.Body = string(inputBytes[pos+4:])
+	// payload, decompress it if the payload is compressed
+	body, err := compressor.DecompressIfNecessary(msg.AppProps, inputBytes[pos+4:])
+	if nil != err {
+		return *msg, err
+	}
+	msg.Body = string(body)
 
 	return *msg, nil
 }
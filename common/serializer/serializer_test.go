@@ -3,6 +3,7 @@ package serializer
 import (
 	"encoding/base64"
 	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/compressor"
 	"git.multiverse.io/eventkit/kit/common/errors"
 	"git.multiverse.io/eventkit/kit/common/protocol"
 	"git.multiverse.io/eventkit/kit/constant"
@@ -164,3 +165,25 @@ func TestProtoMsg2StringAndString2ProtoMsgCompatible(t *testing.T) {
 	assert.NotNil(t, err)
 	t.Logf("The result of error:%++v", err)
 }
+
+func TestBytes2ProtoMsgWithCompressedPayload(t *testing.T) {
+	body, cerr := compressor.Compress(compressor.Zstd, []byte("test body"))
+	assert.True(t, nil == cerr)
+	msg := &protocol.ProtoMessage{}
+	msg.AppProps = map[string]string{constant.ContentEncoding: compressor.Zstd}
+	msg.Body = string(body)
+
+	resMsg, err := Bytes2protoMsg(ProtoMsg2Bytes(msg, nil))
+	assert.True(t, nil == err)
+	assert.Equal(t, "test body", resMsg.Body)
+	assert.Equal(t, "", resMsg.AppProps[constant.ContentEncoding])
+
+	resMsg, err = String2protoMsg(ProtoMsg2String(msg, nil))
+	assert.True(t, nil == err)
+	assert.Equal(t, "test body", resMsg.Body)
+
+	// the payload cannot be decompressed
+	msg.AppProps[constant.ContentEncoding] = compressor.Gzip
+	_, err = Bytes2protoMsg(ProtoMsg2Bytes(msg, nil))
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,302 @@
+// Package shutdown coordinates the graceful shutdown of the service.
+// When the shutdown starts, the new callbacks are rejected, the in-flight handlers and the registered background tasks
+// are drained until the deadline, then the closers(e.g. DB/cache pools, APM logger) are executed
+// in the reverse order of registration, and the logs are flushed at last.
+package shutdown
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/log"
+)
+
+// The following elements represent the state of the coordinator.
+const (
+	StateRunning  = "RUNNING"
+	StateDraining = "DRAINING"
+	StateClosing  = "CLOSING"
+	StateStopped  = "STOPPED"
+)
+
+// Progress is the drain progress of the shutdown
+type Progress struct {
+	State string
+	// InFlight is the number of the in-flight handlers
+	InFlight int64
+	// BackgroundTasks is the number of the running background tasks
+	BackgroundTasks int64
+	// StartedAt is the start time of the shutdown in milliseconds
+	StartedAt int64
+	// Deadline is the deadline of the drain in milliseconds
+	Deadline int64
+	// Rejected is the number of the callbacks rejected during the shutdown
+	Rejected int64
+}
+
+type closer struct {
+	name string
+	fn   func() error
+}
+
+// Coordinator tracks the in-flight handlers and the background tasks, and executes the shutdown process
+type Coordinator struct {
+	lock      sync.Mutex
+	state     string
+	startedAt time.Time
+	deadline  time.Time
+	closers   []closer
+	// stopping is closed when the shutdown starts, stopped is closed when the shutdown finishes
+	stopping chan struct{}
+	stopped  chan struct{}
+	// drained is signalled when the in-flight handlers or the background tasks finish
+	drained chan struct{}
+
+	inFlight        int64
+	backgroundTasks int64
+	rejected        int64
+}
+
+// NewCoordinator creates a coordinator in running state
+func NewCoordinator() *Coordinator {
+	return &Coordinator{
+		state:    StateRunning,
+		stopping: make(chan struct{}),
+		stopped:  make(chan struct{}),
+		drained:  make(chan struct{}, 1),
+	}
+}
+
+// Enter marks a handler as in-flight, returns false if the coordinator is shutting down and the handler should be rejected,
+// Exit must be called after the handler finishes if Enter returns true.
+func (c *Coordinator) Enter() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if StateRunning != c.state {
+		atomic.AddInt64(&c.rejected, 1)
+		return false
+	}
+	atomic.AddInt64(&c.inFlight, 1)
+
+	return true
+}
+
+// Exit marks the in-flight handler as finished
+func (c *Coordinator) Exit() {
+	atomic.AddInt64(&c.inFlight, -1)
+	c.signalDrained()
+}
+
+// Track registers a background task that is running, call the returned function after the task finishes
+func (c *Coordinator) Track() (done func()) {
+	atomic.AddInt64(&c.backgroundTasks, 1)
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			atomic.AddInt64(&c.backgroundTasks, -1)
+			c.signalDrained()
+		})
+	}
+}
+
+// Go runs the background task in a new goroutine and waits for it when shutting down,
+// the context is cancelled when the drain deadline is exceeded.
+func (c *Coordinator) Go(name string, fn func(ctx context.Context)) {
+	done := c.Track()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.stopping:
+		}
+		c.lock.Lock()
+		deadline := c.deadline
+		c.lock.Unlock()
+
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+		case <-timer.C:
+			cancel()
+		}
+	}()
+	go func() {
+		defer done()
+		defer cancel()
+		defer func() {
+			if e := recover(); nil != e {
+				log.Errorsf("Background task[%s] panic, error=%++v", name, e)
+			}
+		}()
+		fn(ctx)
+	}()
+}
+
+// RegisterCloser registers a function that is executed after the drain, the closers are executed in the reverse order of registration
+func (c *Coordinator) RegisterCloser(name string, fn func() error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.closers = append(c.closers, closer{name: name, fn: fn})
+}
+
+// IsShuttingDown returns true if the shutdown has started
+func (c *Coordinator) IsShuttingDown() bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return StateRunning != c.state
+}
+
+// Progress returns the drain progress of the shutdown
+func (c *Coordinator) Progress() Progress {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	progress := Progress{
+		State:           c.state,
+		InFlight:        atomic.LoadInt64(&c.inFlight),
+		BackgroundTasks: atomic.LoadInt64(&c.backgroundTasks),
+		Rejected:        atomic.LoadInt64(&c.rejected),
+	}
+	if !c.startedAt.IsZero() {
+		progress.StartedAt = c.startedAt.UnixNano() / int64(time.Millisecond)
+		progress.Deadline = c.deadline.UnixNano() / int64(time.Millisecond)
+	}
+
+	return progress
+}
+
+// Stopping returns a channel that is closed when the shutdown starts
+func (c *Coordinator) Stopping() <-chan struct{} {
+	return c.stopping
+}
+
+// Shutdown rejects the new handlers, waits for the in-flight handlers and the background tasks until the timeout,
+// then executes the closers and flushes the logs. The following calls wait for the first shutdown to finish.
+// returns the ShuttingDownError if the drain timeout is exceeded or any closer fails.
+func (c *Coordinator) Shutdown(timeout time.Duration) *errors.Error {
+	c.lock.Lock()
+	if StateRunning != c.state {
+		c.lock.Unlock()
+		<-c.stopped
+		return nil
+	}
+	c.state = StateDraining
+	c.startedAt = time.Now()
+	c.deadline = c.startedAt.Add(timeout)
+	close(c.stopping)
+	c.lock.Unlock()
+
+	log.Infosf("Start draining the in-flight handlers and background tasks, timeout=%s", timeout)
+	var err *errors.Error
+	if !c.waitForDrained() {
+		progress := c.Progress()
+		err = errors.Errorf(constant.ShuttingDownError, "Drain timeout %s exceeded, in-flight handlers=%d, background tasks=%d",
+			timeout, progress.InFlight, progress.BackgroundTasks)
+		log.Errorsf("Failed to drain, error=%s", errors.ErrorToString(err))
+	}
+
+	c.lock.Lock()
+	c.state = StateClosing
+	closers := c.closers
+	c.lock.Unlock()
+
+	for i := len(closers) - 1; i >= 0; i-- {
+		log.Infosf("Closing [%s]...", closers[i].name)
+		if cerr := closers[i].fn(); nil != cerr {
+			log.Errorsf("Failed to close [%s], error=%v", closers[i].name, cerr)
+			if nil == err {
+				err = errors.Errorf(constant.ShuttingDownError, "Failed to close [%s], error=%v", closers[i].name, cerr)
+			}
+		}
+	}
+
+	c.lock.Lock()
+	c.state = StateStopped
+	close(c.stopped)
+	c.lock.Unlock()
+
+	log.Infos("Shutdown finished!")
+	_ = log.Sync()
+
+	return err
+}
+
+func (c *Coordinator) signalDrained() {
+	select {
+	case c.drained <- struct{}{}:
+	default:
+	}
+}
+
+// waitForDrained returns false if the deadline is exceeded before all the handlers and background tasks finish
+func (c *Coordinator) waitForDrained() bool {
+	timer := time.NewTimer(time.Until(c.deadline))
+	defer timer.Stop()
+	for {
+		if 0 >= atomic.LoadInt64(&c.inFlight) && 0 >= atomic.LoadInt64(&c.backgroundTasks) {
+			return true
+		}
+		select {
+		case <-c.drained:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+var defaultCoordinator = NewCoordinator()
+
+// Default returns the global coordinator of the service
+func Default() *Coordinator {
+	return defaultCoordinator
+}
+
+// Enter marks a handler as in-flight in the global coordinator
+func Enter() bool {
+	return defaultCoordinator.Enter()
+}
+
+// Exit marks the in-flight handler as finished in the global coordinator
+func Exit() {
+	defaultCoordinator.Exit()
+}
+
+// Track registers a background task into the global coordinator
+func Track() (done func()) {
+	return defaultCoordinator.Track()
+}
+
+// Go runs the background task that the global coordinator waits for when shutting down
+func Go(name string, fn func(ctx context.Context)) {
+	defaultCoordinator.Go(name, fn)
+}
+
+// RegisterCloser registers a closer into the global coordinator
+func RegisterCloser(name string, fn func() error) {
+	defaultCoordinator.RegisterCloser(name, fn)
+}
+
+// IsShuttingDown returns true if the shutdown of the global coordinator has started
+func IsShuttingDown() bool {
+	return defaultCoordinator.IsShuttingDown()
+}
+
+// CurrentProgress returns the drain progress of the global coordinator
+func CurrentProgress() Progress {
+	return defaultCoordinator.Progress()
+}
+
+// Shutdown executes the shutdown process of the global coordinator
+func Shutdown(timeout time.Duration) *errors.Error {
+	return defaultCoordinator.Shutdown(timeout)
+}
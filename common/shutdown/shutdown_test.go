@@ -0,0 +1,87 @@
+package shutdown
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/constant"
+)
+
+func TestCoordinator_Shutdown(t *testing.T) {
+	coordinator := NewCoordinator()
+	closed := make([]string, 0)
+	coordinator.RegisterCloser("db", func() error {
+		closed = append(closed, "db")
+		return nil
+	})
+	coordinator.RegisterCloser("cache", func() error {
+		closed = append(closed, "cache")
+		return nil
+	})
+
+	assert.True(t, coordinator.Enter())
+	taskFinished := make(chan struct{})
+	coordinator.Go("task", func(ctx context.Context) {
+		time.Sleep(50 * time.Millisecond)
+		close(taskFinished)
+	})
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		assert.Equal(t, StateDraining, coordinator.Progress().State)
+		coordinator.Exit()
+	}()
+
+	finished := make(chan struct{})
+	go func() {
+		<-coordinator.Stopping()
+		// the new handlers are rejected when draining
+		assert.False(t, coordinator.Enter())
+		close(finished)
+	}()
+
+	assert.True(t, nil == coordinator.Shutdown(5*time.Second))
+	<-finished
+	<-taskFinished
+	assert.Equal(t, []string{"cache", "db"}, closed)
+
+	progress := coordinator.Progress()
+	assert.Equal(t, StateStopped, progress.State)
+	assert.Equal(t, int64(0), progress.InFlight)
+	assert.Equal(t, int64(0), progress.BackgroundTasks)
+	assert.Equal(t, int64(1), progress.Rejected)
+	assert.True(t, progress.Deadline > progress.StartedAt)
+
+	// the following shutdown returns immediately
+	assert.True(t, nil == coordinator.Shutdown(time.Second))
+}
+
+func TestCoordinator_ShutdownTimeout(t *testing.T) {
+	coordinator := NewCoordinator()
+	closed := false
+	coordinator.RegisterCloser("db", func() error {
+		closed = true
+		return fmt.Errorf("close failed")
+	})
+	cancelled := make(chan struct{})
+	coordinator.Go("blocked", func(ctx context.Context) {
+		<-ctx.Done()
+		close(cancelled)
+	})
+	done := coordinator.Track()
+	defer done()
+
+	err := coordinator.Shutdown(50 * time.Millisecond)
+	assert.NotNil(t, err)
+	assert.Equal(t, constant.ShuttingDownError, err.ErrorCode)
+	assert.True(t, closed)
+
+	// the context of the background task is cancelled when the deadline is exceeded
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the context of the background task is not cancelled")
+	}
+}
@@ -1,6 +1,7 @@
 package status
 
 import (
+	"git.multiverse.io/eventkit/kit/common/shutdown"
 	"git.multiverse.io/eventkit/kit/log"
 	"sync"
 	"time"
@@ -151,6 +152,8 @@ type Response struct {
 	Status        int
 	Version       string
 	ProtocolLevel int
+	// Drain is the drain progress of the graceful shutdown, only present when the client is shutting down
+	Drain *shutdown.Progress `json:",omitempty"`
 }
 
 // WaitingForServerStatus is used to waiting the server status change to the specified value
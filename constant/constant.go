@@ -56,6 +56,9 @@ const (
 	CsStartTimestamp  = "_CS_START_TIMESTAMP"
 	SrStartTimestamp  = "_SR_START_TIMESTAMP"
 	IsNeedLookup      = "_is_need_lookup"
+	MessageDedupID    = "_MESSAGE_DEDUP_ID"
+	DuplicateMessage  = "_DUPLICATE_MESSAGE"
+	ContentEncoding   = "_CONTENT_ENCODING"
 
 	TargetSU        = "_TARGET_SU"
 	GlsElementType  = "_GLS_ELEMENT_TYPE"
@@ -134,6 +137,23 @@ const (
 	CannotFoundHandlerWithURLError     = "SY99999974"
 	InvalidEventTypeError              = "SY99999973"
 	CannotFoundHandlerWithEventIDError = "SY99999972"
+
+	ScatterGatherQuorumNotReachedError = "SY99999971"
+	ScatterGatherCanceledError         = "SY99999970"
+
+	ShardingDataFrozenError = "SY99999969"
+	ReshardingError         = "SY99999968"
+
+	OutboxError                   = "SY99999967"
+	DuplicateMessageInFlightError = "SY99999966"
+	CompressionError              = "SY99999965"
+	ShuttingDownError             = "SY99999964"
+	CallbackOverloadedError       = "SY99999963"
+	RequestEntityTooLargeError    = "SY99999962"
+	RouteDisabledError            = "SY99999961"
+	RouteUnderMaintenanceError    = "SY99999960"
+	RouteReadOnlyError            = "SY99999959"
+	HandlerTimeoutError           = "SY99999958"
 )
 
 // Define trace id related keys, contains old version key
@@ -193,6 +213,7 @@ const (
 const (
 	HTTPContentTypeKey     = "Content-Type"
 	DefaultContentTypeJSON = "application/json"
+	ContentTypeProtobuf    = "application/x-protobuf"
 	DefaultHTTPMethodPost  = "POST"
 	RequestTypeHTTP        = "http"
 )
@@ -220,10 +241,25 @@ const (
 	// DefaultTimeoutMilliseconds default timeout will be set if request downstream if the timeout is not specified
 	DefaultTimeoutMilliseconds = 30 * 1000
 
+	// DefaultTimeoutSafetyMarginMilliseconds is subtracted from the timeout propagated by the upstream when enforcing the timeout of the handler
+	DefaultTimeoutSafetyMarginMilliseconds = 100
+
+	// DefaultFailoverFailureThreshold is the number of consecutive failures that marks the SU as down if not configured
+	DefaultFailoverFailureThreshold = 3
+
+	// DefaultFailoverRecoveryMilliseconds is the time that the down SU is kept down if not configured
+	DefaultFailoverRecoveryMilliseconds = 30 * 1000
+
+	// DefaultFailoverSuListRefreshMilliseconds is the refresh interval of the SU list of the failover if not configured
+	DefaultFailoverSuListRefreshMilliseconds = 60 * 1000
+
 	// Default redis pool size
 	DefaultRedisPoolSize = 10
 )
 
+// ReshardingFreezeKeyPrefix is the key prefix of the frozen flags of the sharding data shared by the cache
+const ReshardingFreezeKeyPrefix = "GLS_FREEZE."
+
 // Define the key type when generating the serial number
 const (
 	TraceIDType = "0"
@@ -318,6 +354,7 @@ const (
 const (
 	CommDirect                  = "direct"
 	CommMesh                    = "mesh"
+	CommUDS                     = "uds"
 	ParticipantAddressSplitChar = "|"
 	TopicIDSplitChat            = "/"
 	AttributesPrefix            = "_attr."
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"sync"
+
+	"git.multiverse.io/eventkit/kit/common/health"
+	"github.com/beego/beego/v2/adapter/orm"
+	"github.com/xormplus/xorm"
+)
+
+// PoolStats is the statistics of a DB connection pool
+type PoolStats struct {
+	Type                     string `json:"type"`
+	Su                       string `json:"su"`
+	Default                  bool   `json:"default"`
+	MaxOpenConnections       int    `json:"maxOpenConnections"`
+	OpenConnections          int    `json:"openConnections"`
+	InUse                    int    `json:"inUse"`
+	Idle                     int    `json:"idle"`
+	WaitCount                int64  `json:"waitCount"`
+	WaitDurationMilliseconds int64  `json:"waitDurationMilliseconds"`
+}
+
+var registerHealthOnce sync.Once
+
+// registerHealth registers the DB connection pools into the health registry,
+// the service is DOWN if any DB connection pool cannot be pinged.
+func registerHealth() {
+	health.Register("db", Ping, health.WithCritical(true))
+	health.RegisterComponent("db", func() interface{} {
+		return Stats()
+	})
+}
+
+type namedDB struct {
+	name string
+	db   *sql.DB
+}
+
+func sqlDBOf(aliasName string, cp interface{}) (*sql.DB, error) {
+	if engine, ok := cp.(*xorm.Engine); ok {
+		return engine.DB().DB, nil
+	}
+
+	return orm.GetDB(aliasName)
+}
+
+// currentDBs returns the sql.DB of all the DB connection pools ordered by the alias name
+func currentDBs() ([]namedDB, map[string]PoolStats) {
+	dbPoolsCache.RLock()
+	defer dbPoolsCache.RUnlock()
+
+	dbs := make([]namedDB, 0, len(dbPoolsCache.CurrentDBConfigs))
+	stats := make(map[string]PoolStats, len(dbPoolsCache.CurrentDBConfigs))
+	if nil == _connectionPoolCache {
+		return dbs, stats
+	}
+	for aliasName, dbConfig := range dbPoolsCache.CurrentDBConfigs {
+		stats[aliasName] = PoolStats{
+			Type:    dbConfig.Type,
+			Su:      dbConfig.Su,
+			Default: dbConfig.Default,
+		}
+		cp, ok := _connectionPoolCache.Get(dbConfig.Type, aliasName)
+		if !ok {
+			continue
+		}
+		if db, err := sqlDBOf(aliasName, cp); nil == err && nil != db {
+			dbs = append(dbs, namedDB{name: aliasName, db: db})
+		}
+	}
+	sort.Slice(dbs, func(i, j int) bool {
+		return dbs[i].name < dbs[j].name
+	})
+
+	return dbs, stats
+}
+
+// Stats returns the statistics of all the DB connection pools keyed by the alias name
+func Stats() map[string]PoolStats {
+	dbs, stats := currentDBs()
+	for _, db := range dbs {
+		dbStats := db.db.Stats()
+		poolStats := stats[db.name]
+		poolStats.MaxOpenConnections = dbStats.MaxOpenConnections
+		poolStats.OpenConnections = dbStats.OpenConnections
+		poolStats.InUse = dbStats.InUse
+		poolStats.Idle = dbStats.Idle
+		poolStats.WaitCount = dbStats.WaitCount
+		poolStats.WaitDurationMilliseconds = dbStats.WaitDuration.Milliseconds()
+		stats[db.name] = poolStats
+	}
+
+	return stats
+}
+
+// Ping pings all the DB connection pools, returns the error of the first unavailable one
+func Ping(ctx context.Context) error {
+	dbs, _ := currentDBs()
+	for _, db := range dbs {
+		if err := db.db.PingContext(ctx); nil != err {
+			return fmt.Errorf("failed to ping DB[%s]: %v", db.name, err)
+		}
+	}
+
+	return nil
+}
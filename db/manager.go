@@ -2,6 +2,7 @@ package db
 
 import (
 	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/shutdown"
 	"git.multiverse.io/eventkit/kit/constant"
 	"git.multiverse.io/eventkit/kit/db/beego"
 	xormConnectionPoolCache "git.multiverse.io/eventkit/kit/db/xorm"
@@ -40,6 +41,8 @@ func InitDBManagerForXorm(dbConfigs map[string]config.Db) *errors.Error {
 	}
 	// register config change hook function into config manager for XORM
 	config.RegisterConfigOnChangeHookFunc("DBManagerForXorm", rotateDBConfigWhenConfigChanged, true)
+	registerCloserOnce.Do(registerCloser)
+	registerHealthOnce.Do(registerHealth)
 	return nil
 }
 
@@ -51,9 +54,36 @@ func InitDBManagerForBeegoOrmer(dbConfigs map[string]config.Db) *errors.Error {
 	}
 	// register config change hook function into config manager for Beego Ormer
 	config.RegisterConfigOnChangeHookFunc("DBManagerForBeegoOrmer", rotateDBConfigWhenConfigChanged, true)
+	registerCloserOnce.Do(registerCloser)
+	registerHealthOnce.Do(registerHealth)
 	return nil
 }
 
+var registerCloserOnce sync.Once
+
+// registerCloser registers Close into the shutdown coordinator
+func registerCloser() {
+	shutdown.RegisterCloser("DB connection pools", func() error {
+		Close()
+		return nil
+	})
+}
+
+// Close closes all the DB connection pools, it's executed by the shutdown coordinator when the service stops
+func Close() {
+	dbPoolsCache.Lock()
+	defer dbPoolsCache.Unlock()
+
+	if nil == _connectionPoolCache {
+		return
+	}
+	for _, dbConfig := range dbPoolsCache.CurrentDBConfigs {
+		_connectionPoolCache.Delete(dbConfig.Type, dbConfig.Name)
+	}
+	dbPoolsCache.Cache = make(map[string]suDBPools)
+	dbPoolsCache.CurrentDBConfigs = nil
+}
+
 type _dbPoolsCache struct {
 	sync.RWMutex
 	Cache            map[string]suDBPools
@@ -0,0 +1,199 @@
+// Package outbox implements the transactional outbox for reliable asynchronous publishing.
+// The business code saves the outgoing messages into the outbox table in the same local transaction
+// as the business data(see SaveWithXorm and SaveWithBeego), then the Relay publishes the pending records
+// through the remote call interface with retries, keeps the order of the records with the same aggregate key,
+// and carries the dedup ID in the header(constant.MessageDedupID) so that the consumers can drop the duplicates.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/json"
+	"git.multiverse.io/eventkit/kit/common/msg"
+	"git.multiverse.io/eventkit/kit/constant"
+	"github.com/beego/beego/v2/adapter/orm"
+	uuid "github.com/satori/go.uuid"
+	"github.com/xormplus/xorm"
+)
+
+// TableName is the name of the outbox table
+const TableName = "eventkit_outbox"
+
+// Status of the outbox record
+const (
+	StatusPending   = "PENDING"
+	StatusPublished = "PUBLISHED"
+	// StatusFailed means the record is given up after the max attempts
+	StatusFailed = "FAILED"
+)
+
+// Record is the row of the outbox table
+type Record struct {
+	ID              int64  `xorm:"pk autoincr BIGINT 'id'" json:"id"`
+	AggregateKey    string `xorm:"VARCHAR(128) index 'aggregate_key'" json:"aggregateKey"`
+	DedupID         string `xorm:"VARCHAR(64) notnull unique 'dedup_id'" json:"dedupID"`
+	TopicType       string `xorm:"VARCHAR(16) 'topic_type'" json:"topicType"`
+	TopicID         string `xorm:"VARCHAR(128) 'topic_id'" json:"topicID"`
+	TopicAttributes string `xorm:"TEXT 'topic_attributes'" json:"topicAttributes"`
+	Header          string `xorm:"TEXT 'header'" json:"header"`
+	Body            []byte `xorm:"BLOB 'body'" json:"body"`
+	Status          string `xorm:"VARCHAR(16) index 'status'" json:"status"`
+	Attempts        int    `xorm:"INT 'attempts'" json:"attempts"`
+	// NextAttemptAt is the time in milliseconds that the record can be published
+	NextAttemptAt int64  `xorm:"BIGINT 'next_attempt_at'" json:"nextAttemptAt"`
+	LastError     string `xorm:"VARCHAR(512) 'last_error'" json:"lastError"`
+	CreatedAt     int64  `xorm:"BIGINT 'created_at'" json:"createdAt"`
+	UpdatedAt     int64  `xorm:"BIGINT 'updated_at'" json:"updatedAt"`
+	// LeaseOwner is the relay that claimed the record for publishing until LeaseUntil(in milliseconds)
+	LeaseOwner string `xorm:"VARCHAR(64) 'lease_owner'" json:"leaseOwner"`
+	LeaseUntil int64  `xorm:"BIGINT 'lease_until'" json:"leaseUntil"`
+}
+
+// TableName returns the table name of the record for XORM
+func (r *Record) TableName() string {
+	return TableName
+}
+
+// RecordOptions defines the options of the outbox record
+type RecordOptions struct {
+	AggregateKey string
+	DedupID      string
+}
+
+// RecordOption sets the options of the outbox record
+type RecordOption func(*RecordOptions)
+
+// WithAggregateKey sets the aggregate key, the records with the same aggregate key are published in order
+func WithAggregateKey(aggregateKey string) RecordOption {
+	return func(o *RecordOptions) {
+		o.AggregateKey = aggregateKey
+	}
+}
+
+// WithDedupID sets the dedup ID of the record, defaults to a random UUID
+func WithDedupID(dedupID string) RecordOption {
+	return func(o *RecordOptions) {
+		o.DedupID = dedupID
+	}
+}
+
+// Store is the storage of the outbox records used by the relay
+type Store interface {
+	// Save saves the record out of the business transaction
+	Save(ctx context.Context, record *Record) *errors.Error
+	// FetchPending returns the pending records in the order of ID
+	FetchPending(ctx context.Context, limit int) ([]*Record, *errors.Error)
+	// Claim leases the fetched record to the owner until leaseUntil, returns false if the record is published,
+	// changed since it's fetched, or leased by another owner before now, so that the record is published by one relay at a time
+	Claim(ctx context.Context, record *Record, owner string, now, leaseUntil int64) (bool, *errors.Error)
+	// Update updates the status, attempts, next attempt time, last error and lease of the record
+	Update(ctx context.Context, record *Record) *errors.Error
+	// Purge deletes the published records updated before the time, returns the number of deleted records
+	Purge(ctx context.Context, before time.Time) (int64, *errors.Error)
+}
+
+func nowMilliseconds() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+// NewRecord creates a pending outbox record of the message
+func NewRecord(message *msg.Message, opts ...RecordOption) (*Record, *errors.Error) {
+	if nil == message {
+		return nil, errors.Errorf(constant.OutboxError, "The message of outbox record is nil")
+	}
+	if "" == message.GetMsgTopicId() {
+		return nil, errors.Errorf(constant.OutboxError, "The topic ID of outbox message is empty")
+	}
+	options := RecordOptions{}
+	for _, o := range opts {
+		o(&options)
+	}
+	if "" == options.DedupID {
+		options.DedupID = uuid.NewV4().String()
+	}
+
+	topicAttributes, err := json.MarshalToString(message.TopicAttribute)
+	if nil != err {
+		return nil, errors.Wrap(constant.OutboxError, err, 0)
+	}
+	header, err := json.MarshalToString(message.CloneAppProps())
+	if nil != err {
+		return nil, errors.Wrap(constant.OutboxError, err, 0)
+	}
+	now := nowMilliseconds()
+
+	return &Record{
+		AggregateKey:    options.AggregateKey,
+		DedupID:         options.DedupID,
+		TopicType:       message.GetMsgTopicType(),
+		TopicID:         message.GetMsgTopicId(),
+		TopicAttributes: topicAttributes,
+		Header:          header,
+		Body:            message.Body,
+		Status:          StatusPending,
+		NextAttemptAt:   now,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}, nil
+}
+
+// SaveWithXorm saves the message into the outbox table with the XORM session,
+// the session should be in the transaction of the business data.
+func SaveWithXorm(session *xorm.Session, message *msg.Message, opts ...RecordOption) (*Record, *errors.Error) {
+	record, err := NewRecord(message, opts...)
+	if nil != err {
+		return nil, err
+	}
+	if _, xerr := session.Insert(record); nil != xerr {
+		return nil, errors.Wrap(constant.OutboxError, xerr, 0)
+	}
+
+	return record, nil
+}
+
+// SaveWithBeego saves the message into the outbox table with the beego ormer,
+// the ormer should be in the transaction(ormer.Begin()) of the business data.
+func SaveWithBeego(ormer orm.Ormer, message *msg.Message, opts ...RecordOption) (*Record, *errors.Error) {
+	record, err := NewRecord(message, opts...)
+	if nil != err {
+		return nil, err
+	}
+	if berr := insertWithBeego(ormer, record); nil != berr {
+		return nil, berr
+	}
+
+	return record, nil
+}
+
+// CreateTableWithXorm creates or syncs the outbox table with the XORM engine
+func CreateTableWithXorm(engine *xorm.Engine) *errors.Error {
+	if err := engine.Sync2(new(Record)); nil != err {
+		return errors.Wrap(constant.OutboxError, err, 0)
+	}
+
+	return nil
+}
+
+var recordColumns = []string{"aggregate_key", "dedup_id", "topic_type", "topic_id", "topic_attributes", "header",
+	"body", "status", "attempts", "next_attempt_at", "last_error", "created_at", "updated_at", "lease_owner", "lease_until"}
+
+func insertWithBeego(ormer orm.Ormer, record *Record) *errors.Error {
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (?%s)",
+		TableName, strings.Join(recordColumns, ", "), strings.Repeat(", ?", len(recordColumns)-1))
+	res, err := ormer.Raw(query, record.AggregateKey, record.DedupID, record.TopicType, record.TopicID,
+		record.TopicAttributes, record.Header, record.Body, record.Status, record.Attempts,
+		record.NextAttemptAt, record.LastError, record.CreatedAt, record.UpdatedAt, record.LeaseOwner, record.LeaseUntil).Exec()
+	if nil != err {
+		return errors.Wrap(constant.OutboxError, err, 0)
+	}
+	// the last insert ID is not supported by some drivers(e.g. postgres), it's not necessary for the relay
+	if id, err := res.LastInsertId(); nil == err {
+		record.ID = id
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package outbox
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/msg"
+	"git.multiverse.io/eventkit/kit/constant"
+)
+
+func newMessage(topicID, body string) *msg.Message {
+	message := &msg.Message{
+		TopicAttribute: map[string]string{
+			constant.TopicType:                 constant.TopicTypeBusiness,
+			constant.TopicID:                   topicID,
+			constant.TopicDestinationORG:       "org1",
+			constant.TopicDestinationSU:        "su1",
+			constant.TopicDestinationWorkspace: "wks1",
+		},
+		Body: []byte(body),
+	}
+	message.SetAppProperty("key1", "value1")
+
+	return message
+}
+
+func TestNewRecord(t *testing.T) {
+	_, err := NewRecord(&msg.Message{})
+	assert.NotNil(t, err)
+
+	record, err := NewRecord(newMessage("AccountOpened", "body"), WithAggregateKey("ACC-001"))
+	assert.True(t, nil == err)
+	assert.Equal(t, StatusPending, record.Status)
+	assert.Equal(t, "ACC-001", record.AggregateKey)
+	assert.Equal(t, "AccountOpened", record.TopicID)
+	assert.True(t, "" != record.DedupID)
+
+	request, err := record.Request()
+	assert.True(t, nil == err)
+	options := request.RequestOptions()
+	assert.Equal(t, constant.TopicTypeBusiness, options.TopicType)
+	assert.Equal(t, "AccountOpened", options.EventID)
+	assert.Equal(t, "org1", options.Org)
+	assert.Equal(t, "su1", options.Su)
+	assert.Equal(t, "value1", options.Header["key1"])
+	assert.Equal(t, record.DedupID, options.Header[constant.MessageDedupID])
+	body, eerr := request.Codec().Encoder().Encode(request.Body())
+	assert.True(t, nil == eerr)
+	assert.Equal(t, "body", string(body))
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	for _, dedupID := range []string{"d1", "d2"} {
+		record, _ := NewRecord(newMessage("AccountOpened", "body"), WithDedupID(dedupID))
+		assert.True(t, nil == store.Save(ctx, record))
+	}
+	record, _ := NewRecord(newMessage("AccountOpened", "body"), WithDedupID("d1"))
+	assert.NotNil(t, store.Save(ctx, record))
+
+	records, err := store.FetchPending(ctx, 1)
+	assert.True(t, nil == err)
+	assert.Equal(t, 1, len(records))
+	assert.Equal(t, int64(1), records[0].ID)
+
+	records[0].Status = StatusPublished
+	records[0].UpdatedAt = time.Now().Add(-time.Hour).UnixNano() / int64(time.Millisecond)
+	assert.True(t, nil == store.Update(ctx, records[0]))
+	deleted, err := store.Purge(ctx, time.Now().Add(-time.Minute))
+	assert.True(t, nil == err)
+	assert.Equal(t, int64(1), deleted)
+	assert.Equal(t, 1, len(store.Records("")))
+}
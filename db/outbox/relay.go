@@ -0,0 +1,288 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/client"
+	"git.multiverse.io/eventkit/kit/client/mesh"
+	"git.multiverse.io/eventkit/kit/codec"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/json"
+	"git.multiverse.io/eventkit/kit/common/shutdown"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/handler/remote"
+	"git.multiverse.io/eventkit/kit/log"
+	uuid "github.com/satori/go.uuid"
+)
+
+const maxLastErrorLength = 512
+
+// RelayOptions defines the options of the relay
+type RelayOptions struct {
+	// BatchSize is the max number of the pending records fetched in each round
+	BatchSize int
+	// PollInterval is the interval between the rounds
+	PollInterval time.Duration
+	// MaxAttempts is the max attempts of publishing the record, the record is marked as FAILED after that
+	MaxAttempts int
+	// RetryBackoff is the initial waiting time of retry, it's doubled on each failure until MaxRetryBackoff
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
+	// Retention is the time that the published records are kept, 0 means the published records are not purged
+	Retention time.Duration
+	// LeaseDuration is the time that the record claimed by the relay cannot be claimed by other relays,
+	// it should be longer than publishing the record
+	LeaseDuration time.Duration
+}
+
+// RelayOption sets the options of the relay
+type RelayOption func(*RelayOptions)
+
+// WithBatchSize sets the max number of the pending records fetched in each round
+func WithBatchSize(batchSize int) RelayOption {
+	return func(o *RelayOptions) {
+		o.BatchSize = batchSize
+	}
+}
+
+// WithPollInterval sets the interval between the rounds
+func WithPollInterval(pollInterval time.Duration) RelayOption {
+	return func(o *RelayOptions) {
+		o.PollInterval = pollInterval
+	}
+}
+
+// WithMaxAttempts sets the max attempts of publishing the record
+func WithMaxAttempts(maxAttempts int) RelayOption {
+	return func(o *RelayOptions) {
+		o.MaxAttempts = maxAttempts
+	}
+}
+
+// WithRetryBackoff sets the initial and max waiting time of retry
+func WithRetryBackoff(retryBackoff, maxRetryBackoff time.Duration) RelayOption {
+	return func(o *RelayOptions) {
+		o.RetryBackoff = retryBackoff
+		o.MaxRetryBackoff = maxRetryBackoff
+	}
+}
+
+// WithRetention sets the time that the published records are kept
+func WithRetention(retention time.Duration) RelayOption {
+	return func(o *RelayOptions) {
+		o.Retention = retention
+	}
+}
+
+// WithLeaseDuration sets the time that the record claimed by the relay cannot be claimed by other relays
+func WithLeaseDuration(leaseDuration time.Duration) RelayOption {
+	return func(o *RelayOptions) {
+		o.LeaseDuration = leaseDuration
+	}
+}
+
+// Relay publishes the pending outbox records through the remote call interface.
+// The records with the same aggregate key are published in order: if a record fails,
+// the following records of the same aggregate key wait until it's published or marked as FAILED.
+// The delivery is at-least-once, the consumers should drop the duplicates by the dedup ID in the header.
+// The relays of multiple instances share the outbox table, each record is claimed by one relay before publishing.
+type Relay struct {
+	store   Store
+	callInc remote.CallInc
+	options RelayOptions
+	// owner identifies the relay in the lease of the claimed records
+	owner string
+
+	lock   sync.Mutex
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// NewRelay creates a relay of the outbox store
+func NewRelay(store Store, callInc remote.CallInc, opts ...RelayOption) *Relay {
+	options := RelayOptions{
+		BatchSize:       100,
+		PollInterval:    time.Second,
+		MaxAttempts:     10,
+		RetryBackoff:    time.Second,
+		MaxRetryBackoff: 5 * time.Minute,
+		LeaseDuration:   time.Minute,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return &Relay{
+		store:   store,
+		callInc: callInc,
+		options: options,
+		owner:   uuid.NewV4().String(),
+	}
+}
+
+// Start starts publishing the pending records in background, the relay stops when the shutdown starts
+// and the shutdown coordinator waits for the current round.
+func (r *Relay) Start() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if nil != r.stopCh {
+		return
+	}
+	stopCh, doneCh := make(chan struct{}), make(chan struct{})
+	r.stopCh, r.doneCh = stopCh, doneCh
+	shutdown.Go("outbox relay", func(ctx context.Context) {
+		r.run(ctx, stopCh, doneCh)
+	})
+}
+
+// Stop stops the relay and waits for the current round
+func (r *Relay) Stop() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if nil == r.stopCh {
+		return
+	}
+	close(r.stopCh)
+	<-r.doneCh
+	r.stopCh = nil
+	r.doneCh = nil
+}
+
+func (r *Relay) run(ctx context.Context, stopCh, doneCh chan struct{}) {
+	defer close(doneCh)
+	ticker := time.NewTicker(r.options.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := r.RelayOnce(ctx); nil != err {
+			log.Errorf(ctx, "Outbox relay failed, error:%s", err.Error())
+		}
+		if r.options.Retention > 0 {
+			if _, err := r.store.Purge(ctx, time.Now().Add(-r.options.Retention)); nil != err {
+				log.Errorf(ctx, "Failed to purge the published outbox records, error:%s", err.Error())
+			}
+		}
+		select {
+		case <-stopCh:
+			return
+		case <-shutdown.Default().Stopping():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce publishes a batch of the pending records, returns the number of published records
+func (r *Relay) RelayOnce(ctx context.Context) (int, *errors.Error) {
+	records, err := r.store.FetchPending(ctx, r.options.BatchSize)
+	if nil != err {
+		return 0, err
+	}
+
+	published := 0
+	// blocked marks the aggregate keys that have a preceding record not published in this round
+	blocked := make(map[string]bool)
+	now := nowMilliseconds()
+	for _, record := range records {
+		if "" != record.AggregateKey && blocked[record.AggregateKey] {
+			continue
+		}
+		if record.NextAttemptAt > now {
+			blocked[record.AggregateKey] = true
+			continue
+		}
+		// the record claimed by another relay blocks the following records of the same aggregate key
+		claimed, cerr := r.store.Claim(ctx, record, r.owner, now, now+int64(r.options.LeaseDuration/time.Millisecond))
+		if nil != cerr {
+			return published, cerr
+		}
+		if !claimed {
+			blocked[record.AggregateKey] = true
+			continue
+		}
+
+		record.Attempts++
+		record.UpdatedAt = nowMilliseconds()
+		if perr := r.publish(ctx, record); nil != perr {
+			blocked[record.AggregateKey] = true
+			record.LastError = perr.Error()
+			if len(record.LastError) > maxLastErrorLength {
+				record.LastError = record.LastError[:maxLastErrorLength]
+			}
+			if record.Attempts >= r.options.MaxAttempts {
+				record.Status = StatusFailed
+				log.Errorf(ctx, "Give up publishing the outbox record[id=%d, dedupID=%s, topicID=%s] after %d attempts, error:%s",
+					record.ID, record.DedupID, record.TopicID, record.Attempts, record.LastError)
+			} else {
+				record.NextAttemptAt = record.UpdatedAt + int64(r.backoff(record.Attempts)/time.Millisecond)
+			}
+		} else {
+			record.Status = StatusPublished
+			record.LastError = ""
+			published++
+		}
+
+		// release the lease, stop this round if the record cannot be updated,
+		// the record will be published again with the same dedup ID after the lease expires
+		record.LeaseOwner = ""
+		record.LeaseUntil = 0
+		if uerr := r.store.Update(ctx, record); nil != uerr {
+			return published, uerr
+		}
+	}
+
+	return published, nil
+}
+
+func (r *Relay) backoff(attempts int) time.Duration {
+	backoff := r.options.RetryBackoff
+	for i := 1; i < attempts && backoff < r.options.MaxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > r.options.MaxRetryBackoff {
+		backoff = r.options.MaxRetryBackoff
+	}
+
+	return backoff
+}
+
+func (r *Relay) publish(ctx context.Context, record *Record) *errors.Error {
+	request, err := record.Request()
+	if nil != err {
+		return err
+	}
+
+	return r.callInc.AsyncCalls(ctx, request)
+}
+
+// Request converts the record to the mesh request, the body is sent as is
+func (r *Record) Request() (client.Request, *errors.Error) {
+	topicAttributes := make(map[string]string)
+	if "" != r.TopicAttributes {
+		if err := json.UnmarshalFromString(r.TopicAttributes, &topicAttributes); nil != err {
+			return nil, errors.Wrap(constant.OutboxError, err, 0)
+		}
+	}
+	header := make(map[string]string)
+	if "" != r.Header {
+		if err := json.UnmarshalFromString(r.Header, &header); nil != err {
+			return nil, errors.Wrap(constant.OutboxError, err, 0)
+		}
+	}
+	header[constant.MessageDedupID] = r.DedupID
+
+	return mesh.NewMeshRequest(r.Body,
+		mesh.WithTopicType(r.TopicType),
+		mesh.WithEventID(r.TopicID),
+		mesh.WithORG(topicAttributes[constant.TopicDestinationORG]),
+		mesh.WithWorkspace(topicAttributes[constant.TopicDestinationWorkspace]),
+		mesh.WithEnvironment(topicAttributes[constant.TopicDestinationEnvironment]),
+		mesh.WithSU(topicAttributes[constant.TopicDestinationSU]),
+		mesh.WithHeader(header),
+		mesh.WithCodec(codec.BuildTextCodec()),
+	), nil
+}
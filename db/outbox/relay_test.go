@@ -0,0 +1,211 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/client"
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/shutdown"
+	"git.multiverse.io/eventkit/kit/constant"
+	mockremote "git.multiverse.io/eventkit/kit/mocks/remote"
+	"github.com/golang/mock/gomock"
+)
+
+func TestRelay_RelayOnce(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	callInc := mockremote.NewMockCallInc(mockCtrl)
+	store := NewMemoryStore()
+	ctx := context.Background()
+	relay := NewRelay(store, callInc, WithMaxAttempts(2), WithRetryBackoff(time.Hour, time.Hour))
+
+	// A1 fails, A2 must wait for A1, B1 and the record without aggregate key are not blocked
+	for _, item := range []struct{ key, body string }{{"A", "A1"}, {"A", "A2"}, {"B", "B1"}, {"", "N1"}} {
+		record, _ := NewRecord(newMessage("Changed", item.body), WithAggregateKey(item.key))
+		assert.True(t, nil == store.Save(ctx, record))
+	}
+
+	published := make([]string, 0)
+	dedupIDs := make(map[string]bool)
+	callInc.EXPECT().AsyncCalls(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request client.Request, opts ...client.CallOption) *errors.Error {
+			body := string(request.Body().([]byte))
+			if "A1" == body {
+				return errors.Errorf(constant.SystemRemoteCallTimeout, "timeout")
+			}
+			published = append(published, body)
+			dedupIDs[request.RequestOptions().Header[constant.MessageDedupID]] = true
+			return nil
+		}).AnyTimes()
+
+	count, err := relay.RelayOnce(ctx)
+	assert.True(t, nil == err)
+	assert.Equal(t, 2, count)
+	assert.Equal(t, []string{"B1", "N1"}, published)
+	assert.Equal(t, 2, len(dedupIDs))
+
+	pending := store.Records(StatusPending)
+	assert.Equal(t, 2, len(pending))
+	assert.Equal(t, 1, pending[0].Attempts)
+	assert.True(t, pending[0].NextAttemptAt > nowMilliseconds())
+	assert.Equal(t, 0, pending[1].Attempts)
+
+	// A1 is waiting for retry, A2 is still blocked
+	count, _ = relay.RelayOnce(ctx)
+	assert.Equal(t, 0, count)
+
+	// A1 is given up after the max attempts, then A2 is published
+	failed := store.Records(StatusPending)[0]
+	failed.NextAttemptAt = 0
+	assert.True(t, nil == store.Update(ctx, failed))
+	count, _ = relay.RelayOnce(ctx)
+	assert.Equal(t, 0, count)
+	assert.Equal(t, 1, len(store.Records(StatusFailed)))
+
+	count, _ = relay.RelayOnce(ctx)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, []string{"B1", "N1", "A2"}, published)
+	assert.Equal(t, 0, len(store.Records(StatusPending)))
+}
+
+func TestRelay_StartAndStop(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	callInc := mockremote.NewMockCallInc(mockCtrl)
+	store := NewMemoryStore()
+	relay := NewRelay(store, callInc, WithPollInterval(10*time.Millisecond), WithRetention(time.Hour))
+
+	done := make(chan struct{})
+	callInc.EXPECT().AsyncCalls(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request client.Request, opts ...client.CallOption) *errors.Error {
+			close(done)
+			return nil
+		}).Times(1)
+
+	backgroundTasks := shutdown.CurrentProgress().BackgroundTasks
+	relay.Start()
+	// the relay is tracked by the shutdown coordinator
+	assert.Equal(t, backgroundTasks+1, shutdown.CurrentProgress().BackgroundTasks)
+	record, _ := NewRecord(newMessage("Changed", "body"))
+	assert.True(t, nil == store.Save(context.Background(), record))
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the outbox record is not published")
+	}
+	relay.Stop()
+	relay.Stop()
+	assert.Equal(t, 1, len(store.Records(StatusPublished)))
+	for deadline := time.Now().Add(5 * time.Second); shutdown.CurrentProgress().BackgroundTasks != backgroundTasks; {
+		if time.Now().After(deadline) {
+			t.Fatal("the relay is still tracked by the shutdown coordinator")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestMemoryStore_Claim(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	record, _ := NewRecord(newMessage("Changed", "body"))
+	assert.True(t, nil == store.Save(ctx, record))
+	now := nowMilliseconds()
+
+	fetched, _ := store.FetchPending(ctx, 10)
+	stale, _ := store.FetchPending(ctx, 10)
+	claimed, err := store.Claim(ctx, fetched[0], "relay1", now, now+1000)
+	assert.True(t, nil == err)
+	assert.True(t, claimed)
+	assert.Equal(t, "relay1", fetched[0].LeaseOwner)
+
+	// the record leased by another relay cannot be claimed until the lease expires
+	claimed, _ = store.Claim(ctx, stale[0], "relay2", now, now+1000)
+	assert.False(t, claimed)
+	claimed, _ = store.Claim(ctx, stale[0], "relay2", now+1001, now+2000)
+	assert.True(t, claimed)
+
+	// the record changed since it's fetched cannot be claimed
+	fetched[0].Attempts++
+	fetched[0].UpdatedAt++
+	fetched[0].LeaseOwner = ""
+	fetched[0].LeaseUntil = 0
+	assert.True(t, nil == store.Update(ctx, fetched[0]))
+	claimed, _ = store.Claim(ctx, stale[0], "relay2", now, now+1000)
+	assert.False(t, claimed)
+}
+
+func TestRelay_RelayOnceWithClaimedRecords(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	callInc := mockremote.NewMockCallInc(mockCtrl)
+	store := NewMemoryStore()
+	ctx := context.Background()
+
+	for _, item := range []struct{ key, body string }{{"A", "A1"}, {"A", "A2"}, {"B", "B1"}} {
+		record, _ := NewRecord(newMessage("Changed", item.body), WithAggregateKey(item.key))
+		assert.True(t, nil == store.Save(ctx, record))
+	}
+
+	lock := sync.Mutex{}
+	published := make(map[string]int)
+	order := make([]string, 0)
+	callInc.EXPECT().AsyncCalls(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request client.Request, opts ...client.CallOption) *errors.Error {
+			lock.Lock()
+			defer lock.Unlock()
+			body := string(request.Body().([]byte))
+			published[body]++
+			if 'C' == body[0] {
+				order = append(order, body)
+			}
+			return nil
+		}).AnyTimes()
+
+	// A1 is claimed by another relay, A2 waits for A1
+	now := nowMilliseconds()
+	a1 := store.Records(StatusPending)[0]
+	claimed, _ := store.Claim(ctx, a1, "another relay", now, now+int64(time.Hour/time.Millisecond))
+	assert.True(t, claimed)
+	count, err := NewRelay(store, callInc).RelayOnce(ctx)
+	assert.True(t, nil == err)
+	assert.Equal(t, 1, count)
+	assert.Equal(t, map[string]int{"B1": 1}, published)
+
+	// the lease is released after the record is updated
+	a1.LeaseOwner = ""
+	a1.LeaseUntil = 0
+	assert.True(t, nil == store.Update(ctx, a1))
+
+	// the records are published once by the concurrent relays
+	for i := 0; i < 20; i++ {
+		record, _ := NewRecord(newMessage("Changed", "C"+string(rune('a'+i))), WithAggregateKey("C"))
+		assert.True(t, nil == store.Save(ctx, record))
+	}
+	wg := sync.WaitGroup{}
+	for i := 0; i < 4; i++ {
+		relay := NewRelay(store, callInc)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				relay.RelayOnce(ctx)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 0, len(store.Records(StatusPending)))
+	assert.Equal(t, 23, len(published))
+	for body, times := range published {
+		if 1 != times {
+			t.Errorf("the record[%s] is published %d times", body, times)
+		}
+	}
+	// the records with the same aggregate key are published in order
+	for i := 1; i < len(order); i++ {
+		assert.True(t, order[i-1] < order[i])
+	}
+}
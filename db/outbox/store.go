@@ -0,0 +1,302 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/constant"
+	"github.com/beego/beego/v2/adapter/orm"
+	clientorm "github.com/beego/beego/v2/client/orm"
+	"github.com/xormplus/xorm"
+)
+
+var updateColumns = []string{"status", "attempts", "next_attempt_at", "last_error", "updated_at", "lease_owner", "lease_until"}
+
+// claimCondition matches the pending record unchanged since it's fetched and not leased by another owner
+const claimCondition = "id = ? AND status = ? AND attempts = ? AND updated_at = ? AND " +
+	"(lease_owner IS NULL OR lease_owner = '' OR lease_owner = ? OR lease_until < ?)"
+
+type xormStore struct {
+	engine *xorm.Engine
+}
+
+// NewXormStore creates an outbox store with the XORM engine(e.g. db.GetXormEngine)
+func NewXormStore(engine *xorm.Engine) Store {
+	return &xormStore{engine: engine}
+}
+
+func (s *xormStore) Save(ctx context.Context, record *Record) *errors.Error {
+	if _, err := s.engine.Context(ctx).Insert(record); nil != err {
+		return errors.Wrap(constant.OutboxError, err, 0)
+	}
+
+	return nil
+}
+
+func (s *xormStore) FetchPending(ctx context.Context, limit int) ([]*Record, *errors.Error) {
+	records := make([]*Record, 0)
+	if err := s.engine.Context(ctx).Where("status = ?", StatusPending).Asc("id").Limit(limit).Find(&records); nil != err {
+		return nil, errors.Wrap(constant.OutboxError, err, 0)
+	}
+
+	return records, nil
+}
+
+func (s *xormStore) Claim(ctx context.Context, record *Record, owner string, now, leaseUntil int64) (bool, *errors.Error) {
+	affected, err := s.engine.Context(ctx).Table(TableName).
+		Where(claimCondition, record.ID, StatusPending, record.Attempts, record.UpdatedAt, owner, now).
+		Update(map[string]interface{}{"lease_owner": owner, "lease_until": leaseUntil})
+	if nil != err {
+		return false, errors.Wrap(constant.OutboxError, err, 0)
+	}
+	if 0 == affected {
+		return false, nil
+	}
+	record.LeaseOwner = owner
+	record.LeaseUntil = leaseUntil
+
+	return true, nil
+}
+
+func (s *xormStore) Update(ctx context.Context, record *Record) *errors.Error {
+	if _, err := s.engine.Context(ctx).ID(record.ID).Cols(updateColumns...).Update(record); nil != err {
+		return errors.Wrap(constant.OutboxError, err, 0)
+	}
+
+	return nil
+}
+
+func (s *xormStore) Purge(ctx context.Context, before time.Time) (int64, *errors.Error) {
+	deleted, err := s.engine.Context(ctx).Where("status = ? AND updated_at < ?", StatusPublished, before.UnixNano()/int64(time.Millisecond)).
+		Delete(new(Record))
+	if nil != err {
+		return 0, errors.Wrap(constant.OutboxError, err, 0)
+	}
+
+	return deleted, nil
+}
+
+type beegoStore struct {
+	ormer orm.Ormer
+}
+
+// NewBeegoStore creates an outbox store with the beego ormer(e.g. db.GetBeegoOrmer)
+func NewBeegoStore(ormer orm.Ormer) Store {
+	return &beegoStore{ormer: ormer}
+}
+
+func (s *beegoStore) Save(ctx context.Context, record *Record) *errors.Error {
+	return insertWithBeego(s.ormer, record)
+}
+
+func (s *beegoStore) FetchPending(ctx context.Context, limit int) ([]*Record, *errors.Error) {
+	query := fmt.Sprintf("SELECT id, %s FROM %s WHERE status = ? ORDER BY id LIMIT ?", strings.Join(recordColumns, ", "), TableName)
+	var rows []clientorm.Params
+	if _, err := s.ormer.Raw(query, StatusPending, limit).Values(&rows); nil != err {
+		return nil, errors.Wrap(constant.OutboxError, err, 0)
+	}
+
+	records := make([]*Record, 0, len(rows))
+	for _, row := range rows {
+		records = append(records, recordFromParams(row))
+	}
+
+	return records, nil
+}
+
+func (s *beegoStore) Claim(ctx context.Context, record *Record, owner string, now, leaseUntil int64) (bool, *errors.Error) {
+	query := fmt.Sprintf("UPDATE %s SET lease_owner = ?, lease_until = ? WHERE %s", TableName, claimCondition)
+	res, err := s.ormer.Raw(query, owner, leaseUntil, record.ID, StatusPending, record.Attempts, record.UpdatedAt, owner, now).Exec()
+	if nil != err {
+		return false, errors.Wrap(constant.OutboxError, err, 0)
+	}
+	if affected, _ := res.RowsAffected(); 0 == affected {
+		return false, nil
+	}
+	record.LeaseOwner = owner
+	record.LeaseUntil = leaseUntil
+
+	return true, nil
+}
+
+func (s *beegoStore) Update(ctx context.Context, record *Record) *errors.Error {
+	query := fmt.Sprintf("UPDATE %s SET %s = ? WHERE id = ?", TableName, strings.Join(updateColumns, " = ?, "))
+	if _, err := s.ormer.Raw(query, record.Status, record.Attempts, record.NextAttemptAt, record.LastError,
+		record.UpdatedAt, record.LeaseOwner, record.LeaseUntil, record.ID).Exec(); nil != err {
+		return errors.Wrap(constant.OutboxError, err, 0)
+	}
+
+	return nil
+}
+
+func (s *beegoStore) Purge(ctx context.Context, before time.Time) (int64, *errors.Error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE status = ? AND updated_at < ?", TableName)
+	res, err := s.ormer.Raw(query, StatusPublished, before.UnixNano()/int64(time.Millisecond)).Exec()
+	if nil != err {
+		return 0, errors.Wrap(constant.OutboxError, err, 0)
+	}
+	deleted, _ := res.RowsAffected()
+
+	return deleted, nil
+}
+
+// recordFromParams converts the row queried by beego raw seter, all the values are string or nil
+func recordFromParams(row clientorm.Params) *Record {
+	stringOf := func(key string) string {
+		if v, ok := row[key].(string); ok {
+			return v
+		}
+		return ""
+	}
+	int64Of := func(key string) int64 {
+		v, _ := strconv.ParseInt(stringOf(key), 10, 64)
+		return v
+	}
+
+	return &Record{
+		ID:              int64Of("id"),
+		AggregateKey:    stringOf("aggregate_key"),
+		DedupID:         stringOf("dedup_id"),
+		TopicType:       stringOf("topic_type"),
+		TopicID:         stringOf("topic_id"),
+		TopicAttributes: stringOf("topic_attributes"),
+		Header:          stringOf("header"),
+		Body:            []byte(stringOf("body")),
+		Status:          stringOf("status"),
+		Attempts:        int(int64Of("attempts")),
+		NextAttemptAt:   int64Of("next_attempt_at"),
+		LastError:       stringOf("last_error"),
+		CreatedAt:       int64Of("created_at"),
+		UpdatedAt:       int64Of("updated_at"),
+		LeaseOwner:      stringOf("lease_owner"),
+		LeaseUntil:      int64Of("lease_until"),
+	}
+}
+
+// MemoryStore is an in-memory outbox store for testing and local development,
+// it cannot join the business transaction.
+type MemoryStore struct {
+	lock    sync.RWMutex
+	nextID  int64
+	records map[int64]*Record
+}
+
+// NewMemoryStore creates an in-memory outbox store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[int64]*Record)}
+}
+
+// Save saves the copy of record, the dedup ID must be unique
+func (s *MemoryStore) Save(ctx context.Context, record *Record) *errors.Error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for _, r := range s.records {
+		if r.DedupID == record.DedupID {
+			return errors.Errorf(constant.OutboxError, "Duplicate dedup ID:[%s] of outbox record", record.DedupID)
+		}
+	}
+	s.nextID++
+	record.ID = s.nextID
+	saved := *record
+	s.records[record.ID] = &saved
+
+	return nil
+}
+
+// FetchPending returns the copies of pending records in the order of ID
+func (s *MemoryStore) FetchPending(ctx context.Context, limit int) ([]*Record, *errors.Error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.recordsOf(StatusPending, limit), nil
+}
+
+// Claim leases the record to the owner if the record is pending, unchanged and not leased by another owner
+func (s *MemoryStore) Claim(ctx context.Context, record *Record, owner string, now, leaseUntil int64) (bool, *errors.Error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	saved, ok := s.records[record.ID]
+	if !ok || StatusPending != saved.Status || saved.Attempts != record.Attempts || saved.UpdatedAt != record.UpdatedAt {
+		return false, nil
+	}
+	if "" != saved.LeaseOwner && owner != saved.LeaseOwner && saved.LeaseUntil >= now {
+		return false, nil
+	}
+	saved.LeaseOwner = owner
+	saved.LeaseUntil = leaseUntil
+	record.LeaseOwner = owner
+	record.LeaseUntil = leaseUntil
+
+	return true, nil
+}
+
+// Update updates the status, attempts, next attempt time, last error and lease of the record
+func (s *MemoryStore) Update(ctx context.Context, record *Record) *errors.Error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	saved, ok := s.records[record.ID]
+	if !ok {
+		return errors.Errorf(constant.OutboxError, "Cannot found outbox record with ID:[%d]", record.ID)
+	}
+	saved.Status = record.Status
+	saved.Attempts = record.Attempts
+	saved.NextAttemptAt = record.NextAttemptAt
+	saved.LastError = record.LastError
+	saved.UpdatedAt = record.UpdatedAt
+	saved.LeaseOwner = record.LeaseOwner
+	saved.LeaseUntil = record.LeaseUntil
+
+	return nil
+}
+
+// Purge deletes the published records updated before the time
+func (s *MemoryStore) Purge(ctx context.Context, before time.Time) (int64, *errors.Error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	deleted := int64(0)
+	beforeMilliseconds := before.UnixNano() / int64(time.Millisecond)
+	for id, r := range s.records {
+		if StatusPublished == r.Status && r.UpdatedAt < beforeMilliseconds {
+			delete(s.records, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// Records returns the copies of records with the status in the order of ID, empty status returns all the records
+func (s *MemoryStore) Records(status string) []*Record {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	return s.recordsOf(status, 0)
+}
+
+func (s *MemoryStore) recordsOf(status string, limit int) []*Record {
+	records := make([]*Record, 0)
+	for _, r := range s.records {
+		if "" == status || r.Status == status {
+			copied := *r
+			records = append(records, &copied)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].ID < records[j].ID
+	})
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+
+	return records
+}
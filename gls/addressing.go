@@ -0,0 +1,55 @@
+package gls
+
+import (
+	"context"
+
+	"git.multiverse.io/eventkit/kit/client/mesh/wrapper/addressing"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/model/glsdef"
+	"git.multiverse.io/eventkit/kit/constant"
+)
+
+// NewAddressingMissResolver creates a resolver for addressing.LookupBatch,
+// the elements not found in the addressing cache are looked up by LookupListUsingSUType.
+func NewAddressingMissResolver(operator ShardingDataOperator, opts ...Option) addressing.MissResolver {
+	return func(ctx context.Context, dim *glsdef.Dimension, elements []glsdef.Element) ([]string, *errors.Error) {
+		shardingDatas := make([]*ShardingData, 0, len(elements))
+		for _, element := range elements {
+			shardingDatas = append(shardingDatas, &ShardingData{
+				Type:  element.ElementType,
+				Class: element.ElementClass,
+				ID:    element.ElementID,
+			})
+		}
+
+		suInfoList, err := operator.LookupListUsingSUType(ctx, dim.SuType, shardingDatas, opts...)
+		if nil != err {
+			return nil, err
+		}
+		if len(suInfoList) != len(elements) {
+			return nil, errors.Errorf(constant.SystemInternalError,
+				"GLS lookup list returns %d SUs for %d elements", len(suInfoList), len(elements))
+		}
+
+		suIDs := make([]string, len(suInfoList))
+		for i, suInfo := range suInfoList {
+			if nil != suInfo {
+				suIDs[i] = suInfo.ID
+			}
+		}
+		return suIDs, nil
+	}
+}
+
+// NewSuListProvider creates a SU list provider for the failover of addressing by QuerySuListUsingSUType,
+// it can be set by addressing.SetSuListProvider.
+func NewSuListProvider(operator ShardingDataOperator, opts ...Option) addressing.SuListProvider {
+	return func(ctx context.Context, suType string) ([]string, *errors.Error) {
+		suTypeInfo, err := operator.QuerySuListUsingSUType(ctx, suType, opts...)
+		if nil != err {
+			return nil, err
+		}
+
+		return suTypeInfo.SuList, nil
+	}
+}
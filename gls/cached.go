@@ -0,0 +1,744 @@
+package gls
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/client/mesh"
+	"git.multiverse.io/eventkit/kit/client/mesh/wrapper/addressing"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/model/glsdef"
+	"git.multiverse.io/eventkit/kit/common/util"
+	"git.multiverse.io/eventkit/kit/contexts"
+	"git.multiverse.io/eventkit/kit/handler/remote"
+	"git.multiverse.io/eventkit/kit/log"
+)
+
+// Defines all the actions of GLS change event
+const (
+	ChangeActionBind    = "BIND"
+	ChangeActionUnBind  = "UNBIND"
+	ChangeActionReBind  = "REBIND"
+	ChangeActionAppend  = "APPEND"
+	ChangeActionRemove  = "REMOVE"
+	ChangeActionRefresh = "REFRESH"
+)
+
+// ChangeEvent is the event published on the ops topic after the bound relations changed,
+// the instances that received the event invalidate the cached results of the sharding datas.
+type ChangeEvent struct {
+	// Source is the instance ID of the publisher, the publisher ignores the events of itself
+	Source        string         `json:"source"`
+	Action        string         `json:"action"`
+	Tenant        string         `json:"tenant"`
+	Workspace     string         `json:"workspace"`
+	Environment   string         `json:"environment"`
+	ShardingDatas []ShardingData `json:"shardingDatas"`
+	// Timestamp is the publish time in milliseconds
+	Timestamp int64 `json:"timestamp"`
+}
+
+// CacheOptions defines the options of the CachedOperator
+type CacheOptions struct {
+	TTL           time.Duration
+	MaxEntries    int
+	InstanceID    string
+	Publisher     remote.CallInc
+	ChangeEventID string
+}
+
+// CacheOption sets the options of the CachedOperator
+type CacheOption func(*CacheOptions)
+
+// WithCacheTTL sets the max time that a result can be cached
+func WithCacheTTL(ttl time.Duration) CacheOption {
+	return func(o *CacheOptions) {
+		o.TTL = ttl
+	}
+}
+
+// WithCacheMaxEntries sets the max number of cached results, the least recently used results are evicted
+func WithCacheMaxEntries(maxEntries int) CacheOption {
+	return func(o *CacheOptions) {
+		o.MaxEntries = maxEntries
+	}
+}
+
+// WithCacheInstanceID sets the instance ID that used as the source of the published change events
+func WithCacheInstanceID(instanceID string) CacheOption {
+	return func(o *CacheOptions) {
+		o.InstanceID = instanceID
+	}
+}
+
+// WithChangeEventPublisher publishes the change events to the ops topic with event ID after the bound relations changed
+func WithChangeEventPublisher(publisher remote.CallInc, changeEventID string) CacheOption {
+	return func(o *CacheOptions) {
+		o.Publisher = publisher
+		o.ChangeEventID = changeEventID
+	}
+}
+
+// CacheStats is the statistics of the CachedOperator
+type CacheStats struct {
+	Hits          uint64
+	Misses        uint64
+	Invalidations uint64
+	ChangeEvents  uint64
+	Entries       int
+	// Staleness is the age of the oldest cached result
+	Staleness time.Duration
+	// LastChangeEventAt is the time that the last change event from other instances received
+	LastChangeEventAt time.Time
+	// LastChangeEventLag is the delay between the publish and the receive of the last change event
+	LastChangeEventLag time.Duration
+	LastRefreshAt      time.Time
+}
+
+type cachedResult struct {
+	key        string
+	elementKey string
+	value      interface{}
+	loadedAt   time.Time
+}
+
+// CachedOperator is a ShardingDataOperator decorator that serves the lookups from a local cache,
+// the Bind/UnBind/ReBind/Append/Remove operations write through and invalidate the cached results of the sharding datas.
+type CachedOperator struct {
+	ShardingDataOperator
+	options CacheOptions
+
+	lock      sync.Mutex
+	lru       *list.List
+	entries   map[string]*list.Element
+	byElement map[string]map[string]struct{}
+	// epoch is increased on every invalidation, the results loaded across an invalidation are not cached
+	epoch uint64
+	stats CacheStats
+}
+
+// NewCachedOperator creates a caching decorator of the operator
+func NewCachedOperator(operator ShardingDataOperator, opts ...CacheOption) *CachedOperator {
+	options := CacheOptions{
+		TTL:        5 * time.Minute,
+		MaxEntries: 100000,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+	if "" == options.InstanceID {
+		options.InstanceID = util.RandomString(16)
+	}
+
+	return &CachedOperator{
+		ShardingDataOperator: operator,
+		options:              options,
+		lru:                  list.New(),
+		entries:              make(map[string]*list.Element),
+		byElement:            make(map[string]map[string]struct{}),
+	}
+}
+
+func buildOptions(opts []Option) Options {
+	options := NewOptions()
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return options
+}
+
+func elementKeyOf(shardingData *ShardingData) string {
+	class := shardingData.Class
+	if "" == class {
+		class = shardingData.Type
+	}
+
+	return fmt.Sprintf("%s.%s.%s", shardingData.Type, class, shardingData.ID)
+}
+
+// dimensionKeyOf builds the key of the dimension used by the GLS call, which is the optional dimension
+// or the organization, workspace and environment of the handler contexts
+func dimensionKeyOf(ctx context.Context, topicInfo *TopicInfo, suType string, options Options) string {
+	key := "su:" + suType
+	if nil != topicInfo {
+		key = fmt.Sprintf("topic:%s.%s", topicInfo.Type, topicInfo.ID)
+	}
+	dimension := dimensionOf(ctx, options)
+
+	return fmt.Sprintf("%s|%s.%s.%s", key, dimension.Tenant, dimension.Workspace, dimension.Environment)
+}
+
+// get returns the cached result, the expired result is removed
+func (c *CachedOperator) get(key string) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return nil, false
+	}
+	result := e.Value.(*cachedResult)
+	if c.options.TTL > 0 && time.Since(result.loadedAt) > c.options.TTL {
+		c.removeElement(e)
+		c.stats.Misses++
+		return nil, false
+	}
+	c.lru.MoveToFront(e)
+	c.stats.Hits++
+
+	return result.value, true
+}
+
+// put caches the result if there is no invalidation since the epoch
+func (c *CachedOperator) put(epoch uint64, key, elementKey string, value interface{}) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if epoch != c.epoch {
+		return
+	}
+	if e, ok := c.entries[key]; ok {
+		c.removeElement(e)
+	}
+	c.entries[key] = c.lru.PushFront(&cachedResult{
+		key:        key,
+		elementKey: elementKey,
+		value:      value,
+		loadedAt:   time.Now(),
+	})
+	if "" != elementKey {
+		keys, ok := c.byElement[elementKey]
+		if !ok {
+			keys = make(map[string]struct{})
+			c.byElement[elementKey] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	for c.options.MaxEntries > 0 && c.lru.Len() > c.options.MaxEntries {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+func (c *CachedOperator) currentEpoch() uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.epoch
+}
+
+func (c *CachedOperator) removeElement(e *list.Element) {
+	result := c.lru.Remove(e).(*cachedResult)
+	delete(c.entries, result.key)
+	if keys, ok := c.byElement[result.elementKey]; ok {
+		delete(keys, result.key)
+		if len(keys) == 0 {
+			delete(c.byElement, result.elementKey)
+		}
+	}
+}
+
+// Invalidate removes all the cached results of the sharding datas
+func (c *CachedOperator) Invalidate(shardingDatas ...*ShardingData) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.epoch++
+	for _, shardingData := range shardingDatas {
+		if nil == shardingData {
+			continue
+		}
+		for key := range c.byElement[elementKeyOf(shardingData)] {
+			if e, ok := c.entries[key]; ok {
+				c.removeElement(e)
+				c.stats.Invalidations++
+			}
+		}
+	}
+}
+
+func (c *CachedOperator) purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.epoch++
+	c.stats.Invalidations += uint64(c.lru.Len())
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+	c.byElement = make(map[string]map[string]struct{})
+	c.stats.LastRefreshAt = time.Now()
+}
+
+// Refresh removes all the cached results and notifies the other instances to do the same
+func (c *CachedOperator) Refresh(ctx context.Context) *errors.Error {
+	c.purge()
+
+	return c.publish(ctx, ChangeActionRefresh, Options{}, nil)
+}
+
+// Stats returns the statistics of the cache, the Staleness reports the age of the oldest cached result
+func (c *CachedOperator) Stats() CacheStats {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	stats := c.stats
+	stats.Entries = c.lru.Len()
+	for e := c.lru.Front(); nil != e; e = e.Next() {
+		if age := time.Since(e.Value.(*cachedResult).loadedAt); age > stats.Staleness {
+			stats.Staleness = age
+		}
+	}
+
+	return stats
+}
+
+// HandleChangeEvent applies the change event received from the ops topic,
+// the handler of the change event ID should decode the request body into ChangeEvent and call it.
+func (c *CachedOperator) HandleChangeEvent(ctx context.Context, event *ChangeEvent) *errors.Error {
+	if nil == event || event.Source == c.options.InstanceID {
+		return nil
+	}
+
+	c.lock.Lock()
+	c.stats.ChangeEvents++
+	c.stats.LastChangeEventAt = time.Now()
+	if event.Timestamp > 0 {
+		c.stats.LastChangeEventLag = time.Since(time.Unix(0, event.Timestamp*int64(time.Millisecond)))
+	}
+	c.lock.Unlock()
+
+	if ChangeActionRefresh == event.Action {
+		c.purge()
+		log.Infof(ctx, "Purged the GLS cache by the refresh event from instance[%s]", event.Source)
+		return nil
+	}
+
+	shardingDatas := make([]*ShardingData, 0, len(event.ShardingDatas))
+	for i := range event.ShardingDatas {
+		shardingDatas = append(shardingDatas, &event.ShardingDatas[i])
+	}
+	c.Invalidate(shardingDatas...)
+	invalidateAddressing(&glsdef.Dimension{
+		Tenant:      event.Tenant,
+		Workspace:   event.Workspace,
+		Environment: event.Environment,
+	}, shardingDatas)
+	log.Debugf(ctx, "Invalidated the GLS cache by the %s event from instance[%s], sharding datas:[%d]",
+		event.Action, event.Source, len(shardingDatas))
+
+	return nil
+}
+
+// dimensionOf returns the tenant/workspace/environment of the operation,
+// the optional dimension takes precedence over the handler contexts.
+func dimensionOf(ctx context.Context, options Options) *glsdef.Dimension {
+	dimension := &glsdef.Dimension{}
+	if nil != options.OptionalDimension {
+		dimension.Tenant = options.OptionalDimension.Organization
+		dimension.Workspace = options.OptionalDimension.Workspace
+		dimension.Environment = options.OptionalDimension.Environment
+	} else if handlerContexts := contexts.HandlerContextsFromContext(ctx); nil != handlerContexts {
+		dimension.Tenant = handlerContexts.Org
+		dimension.Workspace = handlerContexts.Wks
+		dimension.Environment = handlerContexts.Env
+	}
+
+	return dimension
+}
+
+// invalidateAddressing removes the sharding datas from the local addressing cache if it's enabled
+func invalidateAddressing(dimension *glsdef.Dimension, shardingDatas []*ShardingData) {
+	for _, shardingData := range shardingDatas {
+		if nil == shardingData {
+			continue
+		}
+		addressing.InvalidateElement(dimension, glsdef.Element{
+			ElementType:  shardingData.Type,
+			ElementClass: shardingData.Class,
+			ElementID:    shardingData.ID,
+		})
+	}
+}
+
+func (c *CachedOperator) publish(ctx context.Context, action string, options Options, shardingDatas []*ShardingData) *errors.Error {
+	if nil == c.options.Publisher || "" == c.options.ChangeEventID {
+		return nil
+	}
+
+	dimension := dimensionOf(ctx, options)
+	event := &ChangeEvent{
+		Source:        c.options.InstanceID,
+		Action:        action,
+		Tenant:        dimension.Tenant,
+		Workspace:     dimension.Workspace,
+		Environment:   dimension.Environment,
+		ShardingDatas: make([]ShardingData, 0, len(shardingDatas)),
+		Timestamp:     time.Now().UnixNano() / int64(time.Millisecond),
+	}
+	for _, shardingData := range shardingDatas {
+		if nil != shardingData {
+			event.ShardingDatas = append(event.ShardingDatas, *shardingData)
+		}
+	}
+
+	request := mesh.NewMeshRequest(event, mesh.WithTopicTypeOps(), mesh.WithEventID(c.options.ChangeEventID))
+	if err := c.options.Publisher.AsyncCalls(ctx, request); nil != err {
+		log.Errorf(ctx, "Failed to publish the GLS change event[%++v], error:%s", event, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+// changed invalidates the sharding datas after a write operation and publishes the change event if the operation succeeded.
+// The sharding datas are invalidated even if the operation failed because it may be partially applied.
+func (c *CachedOperator) changed(ctx context.Context, action string, opts []Option, err *errors.Error, shardingDatas ...*ShardingData) {
+	options := buildOptions(opts)
+	c.Invalidate(shardingDatas...)
+	invalidateAddressing(dimensionOf(ctx, options), shardingDatas)
+	if nil != err {
+		return
+	}
+	// the write operation has been succeeded, the failure of publish is only logged
+	c.publish(ctx, action, options, shardingDatas)
+}
+
+func reBindShardingDatas(rebindShardingDatas []*ReBindShardingData) []*ShardingData {
+	shardingDatas := make([]*ShardingData, 0, 2*len(rebindShardingDatas))
+	for _, rebindShardingData := range rebindShardingDatas {
+		if nil != rebindShardingData {
+			shardingDatas = append(shardingDatas, &rebindShardingData.Source, &rebindShardingData.Target)
+		}
+	}
+
+	return shardingDatas
+}
+
+func copySuInfo(suInfo *SuInfo) *SuInfo {
+	if nil == suInfo {
+		return nil
+	}
+	c := *suInfo
+	return &c
+}
+
+func copySuTypeInfo(suTypeInfo *SuTypeInfo) *SuTypeInfo {
+	if nil == suTypeInfo {
+		return nil
+	}
+	c := *suTypeInfo
+	c.SuList = append([]string(nil), suTypeInfo.SuList...)
+	return &c
+}
+
+/* For `GlsCreate/GlsCreateDxc` start*/
+func (c *CachedOperator) BindWithTopicInfo(ctx context.Context, topicInfo *TopicInfo, shardingData *ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.BindWithTopicInfo(ctx, topicInfo, shardingData, opts...)
+	c.changed(ctx, ChangeActionBind, opts, err, shardingData)
+	return suInfo, err
+}
+
+func (c *CachedOperator) BindWithSUType(ctx context.Context, suType string, shardingData *ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.BindWithSUType(ctx, suType, shardingData, opts...)
+	c.changed(ctx, ChangeActionBind, opts, err, shardingData)
+	return suInfo, err
+}
+
+func (c *CachedOperator) BindListWithTopicInfo(ctx context.Context, topicInfo *TopicInfo, shardingDatas []*ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.BindListWithTopicInfo(ctx, topicInfo, shardingDatas, opts...)
+	c.changed(ctx, ChangeActionBind, opts, err, shardingDatas...)
+	return suInfo, err
+}
+
+func (c *CachedOperator) BindListWithSUType(ctx context.Context, suType string, shardingDatas []*ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.BindListWithSUType(ctx, suType, shardingDatas, opts...)
+	c.changed(ctx, ChangeActionBind, opts, err, shardingDatas...)
+	return suInfo, err
+}
+
+/*For `GlsCreate/GlsCreateDxc` end*/
+
+/* For `GlsUpdate/GlsUpdateDxc` start*/
+func (c *CachedOperator) UnBindWithTopicInfo(ctx context.Context, topicInfo *TopicInfo, shardingData *ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.UnBindWithTopicInfo(ctx, topicInfo, shardingData, opts...)
+	c.changed(ctx, ChangeActionUnBind, opts, err, shardingData)
+	return suInfo, err
+}
+
+func (c *CachedOperator) UnBindWithSUType(ctx context.Context, suType string, shardingData *ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.UnBindWithSUType(ctx, suType, shardingData, opts...)
+	c.changed(ctx, ChangeActionUnBind, opts, err, shardingData)
+	return suInfo, err
+}
+
+func (c *CachedOperator) UnBindListWithTopicInfo(ctx context.Context, topicInfo *TopicInfo, shardingDatas []*ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.UnBindListWithTopicInfo(ctx, topicInfo, shardingDatas, opts...)
+	c.changed(ctx, ChangeActionUnBind, opts, err, shardingDatas...)
+	return suInfo, err
+}
+
+func (c *CachedOperator) UnBindListWithSUType(ctx context.Context, suType string, shardingDatas []*ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.UnBindListWithSUType(ctx, suType, shardingDatas, opts...)
+	c.changed(ctx, ChangeActionUnBind, opts, err, shardingDatas...)
+	return suInfo, err
+}
+
+func (c *CachedOperator) ReBindWithTopicInfo(ctx context.Context, topicInfo *TopicInfo, rebindShardingData *ReBindShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.ReBindWithTopicInfo(ctx, topicInfo, rebindShardingData, opts...)
+	c.changed(ctx, ChangeActionReBind, opts, err, reBindShardingDatas([]*ReBindShardingData{rebindShardingData})...)
+	return suInfo, err
+}
+
+func (c *CachedOperator) ReBindWithSUType(ctx context.Context, suType string, rebindShardingData *ReBindShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.ReBindWithSUType(ctx, suType, rebindShardingData, opts...)
+	c.changed(ctx, ChangeActionReBind, opts, err, reBindShardingDatas([]*ReBindShardingData{rebindShardingData})...)
+	return suInfo, err
+}
+
+func (c *CachedOperator) ReBindListWithTopicInfo(ctx context.Context, topicInfo *TopicInfo, rebindShardingDatas []*ReBindShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.ReBindListWithTopicInfo(ctx, topicInfo, rebindShardingDatas, opts...)
+	c.changed(ctx, ChangeActionReBind, opts, err, reBindShardingDatas(rebindShardingDatas)...)
+	return suInfo, err
+}
+
+func (c *CachedOperator) ReBindListWithSUType(ctx context.Context, suType string, rebindShardingDatas []*ReBindShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.ReBindListWithSUType(ctx, suType, rebindShardingDatas, opts...)
+	c.changed(ctx, ChangeActionReBind, opts, err, reBindShardingDatas(rebindShardingDatas)...)
+	return suInfo, err
+}
+
+func (c *CachedOperator) AppendIntoBoundRelationWithTopicInfo(ctx context.Context, topicInfo *TopicInfo, appendTo *ShardingData, newShardingData *ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.AppendIntoBoundRelationWithTopicInfo(ctx, topicInfo, appendTo, newShardingData, opts...)
+	c.changed(ctx, ChangeActionAppend, opts, err, newShardingData)
+	return suInfo, err
+}
+
+func (c *CachedOperator) AppendIntoBoundRelationWithSUType(ctx context.Context, suType string, appendTo *ShardingData, newShardingData *ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.AppendIntoBoundRelationWithSUType(ctx, suType, appendTo, newShardingData, opts...)
+	c.changed(ctx, ChangeActionAppend, opts, err, newShardingData)
+	return suInfo, err
+}
+
+func (c *CachedOperator) AppendListIntoBoundRelationWithTopicInfo(ctx context.Context, topicInfo *TopicInfo, appendTo *ShardingData, newShardingDatas []*ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.AppendListIntoBoundRelationWithTopicInfo(ctx, topicInfo, appendTo, newShardingDatas, opts...)
+	c.changed(ctx, ChangeActionAppend, opts, err, newShardingDatas...)
+	return suInfo, err
+}
+
+func (c *CachedOperator) AppendListIntoBoundRelationWithSUType(ctx context.Context, suType string, appendTo *ShardingData, newShardingDatas []*ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.AppendListIntoBoundRelationWithSUType(ctx, suType, appendTo, newShardingDatas, opts...)
+	c.changed(ctx, ChangeActionAppend, opts, err, newShardingDatas...)
+	return suInfo, err
+}
+
+func (c *CachedOperator) AppendIntoSUWithTopicInfo(ctx context.Context, topicInfo *TopicInfo, appendToSU string, newShardingData *ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.AppendIntoSUWithTopicInfo(ctx, topicInfo, appendToSU, newShardingData, opts...)
+	c.changed(ctx, ChangeActionAppend, opts, err, newShardingData)
+	return suInfo, err
+}
+
+func (c *CachedOperator) AppendIntoSUWithSUType(ctx context.Context, suType string, appendToSU string, newShardingData *ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.AppendIntoSUWithSUType(ctx, suType, appendToSU, newShardingData, opts...)
+	c.changed(ctx, ChangeActionAppend, opts, err, newShardingData)
+	return suInfo, err
+}
+
+func (c *CachedOperator) AppendListIntoSUWithTopicInfo(ctx context.Context, topicInfo *TopicInfo, appendToSU string, newShardingDatas []*ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.AppendListIntoSUWithTopicInfo(ctx, topicInfo, appendToSU, newShardingDatas, opts...)
+	c.changed(ctx, ChangeActionAppend, opts, err, newShardingDatas...)
+	return suInfo, err
+}
+
+func (c *CachedOperator) AppendListIntoSUWithSUType(ctx context.Context, suType string, appendToSU string, newShardingDatas []*ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	suInfo, err := c.ShardingDataOperator.AppendListIntoSUWithSUType(ctx, suType, appendToSU, newShardingDatas, opts...)
+	c.changed(ctx, ChangeActionAppend, opts, err, newShardingDatas...)
+	return suInfo, err
+}
+
+/* For `GlsUpdate/GlsUpdateDxc` end*/
+
+/* For `GlsExist` start */
+func (c *CachedOperator) IsBoundWithTopicInfo(ctx context.Context, topicInfo *TopicInfo, shardingData *ShardingData, opts ...Option) (bool, *errors.Error) {
+	return c.isBound(ctx, topicInfo, "", shardingData, opts, func() (bool, *errors.Error) {
+		return c.ShardingDataOperator.IsBoundWithTopicInfo(ctx, topicInfo, shardingData, opts...)
+	})
+}
+
+func (c *CachedOperator) IsBoundWithSUType(ctx context.Context, suType string, shardingData *ShardingData, opts ...Option) (bool, *errors.Error) {
+	return c.isBound(ctx, nil, suType, shardingData, opts, func() (bool, *errors.Error) {
+		return c.ShardingDataOperator.IsBoundWithSUType(ctx, suType, shardingData, opts...)
+	})
+}
+
+func (c *CachedOperator) isBound(ctx context.Context, topicInfo *TopicInfo, suType string, shardingData *ShardingData, opts []Option, load func() (bool, *errors.Error)) (bool, *errors.Error) {
+	options := buildOptions(opts)
+	elementKey := elementKeyOf(shardingData)
+	key := "exist|" + dimensionKeyOf(ctx, topicInfo, suType, options) + "|" + elementKey
+	if !options.ForceRefresh {
+		if value, ok := c.get(key); ok {
+			return value.(bool), nil
+		}
+	}
+
+	epoch := c.currentEpoch()
+	isBound, err := load()
+	if nil != err {
+		return false, err
+	}
+	c.put(epoch, key, elementKey, isBound)
+
+	return isBound, nil
+}
+
+/* For `GlsExist` end */
+
+/* For `GlsRemove/GlsRemoveDxc` start */
+func (c *CachedOperator) RemoveAllBoundRelation(ctx context.Context, shardingData *ShardingData, opts ...Option) *errors.Error {
+	err := c.ShardingDataOperator.RemoveAllBoundRelation(ctx, shardingData, opts...)
+	c.changed(ctx, ChangeActionRemove, opts, err, shardingData)
+	return err
+}
+
+/* For `GlsRemove/GlsRemoveDxc` end */
+
+/* For `GlsSuList` start */
+func (c *CachedOperator) QuerySuListUsingTopicInfo(ctx context.Context, topicInfo *TopicInfo, opts ...Option) (*SuTypeInfo, *errors.Error) {
+	return c.querySuList(ctx, topicInfo, "", opts, func() (*SuTypeInfo, *errors.Error) {
+		return c.ShardingDataOperator.QuerySuListUsingTopicInfo(ctx, topicInfo, opts...)
+	})
+}
+
+func (c *CachedOperator) QuerySuListUsingSUType(ctx context.Context, suType string, opts ...Option) (*SuTypeInfo, *errors.Error) {
+	return c.querySuList(ctx, nil, suType, opts, func() (*SuTypeInfo, *errors.Error) {
+		return c.ShardingDataOperator.QuerySuListUsingSUType(ctx, suType, opts...)
+	})
+}
+
+// querySuList caches the SU list of SU type, it's not changed by the bound relations so that only expired by TTL or Refresh
+func (c *CachedOperator) querySuList(ctx context.Context, topicInfo *TopicInfo, suType string, opts []Option, load func() (*SuTypeInfo, *errors.Error)) (*SuTypeInfo, *errors.Error) {
+	options := buildOptions(opts)
+	key := "sulist|" + dimensionKeyOf(ctx, topicInfo, suType, options)
+	if !options.ForceRefresh {
+		if value, ok := c.get(key); ok {
+			return copySuTypeInfo(value.(*SuTypeInfo)), nil
+		}
+	}
+
+	epoch := c.currentEpoch()
+	suTypeInfo, err := load()
+	if nil != err {
+		return nil, err
+	}
+	if nil != suTypeInfo {
+		c.put(epoch, key, "", copySuTypeInfo(suTypeInfo))
+	}
+
+	return suTypeInfo, nil
+}
+
+/* For `GlsSuList` end */
+
+/* For `Lookup` start */
+func (c *CachedOperator) LookupUsingTopicInfo(ctx context.Context, topicInfo *TopicInfo, shardingData *ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	return c.lookup(ctx, topicInfo, "", shardingData, opts, func() (*SuInfo, *errors.Error) {
+		return c.ShardingDataOperator.LookupUsingTopicInfo(ctx, topicInfo, shardingData, opts...)
+	})
+}
+
+func (c *CachedOperator) LookupUsingSUType(ctx context.Context, suType string, shardingData *ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	return c.lookup(ctx, nil, suType, shardingData, opts, func() (*SuInfo, *errors.Error) {
+		return c.ShardingDataOperator.LookupUsingSUType(ctx, suType, shardingData, opts...)
+	})
+}
+
+func (c *CachedOperator) lookup(ctx context.Context, topicInfo *TopicInfo, suType string, shardingData *ShardingData, opts []Option, load func() (*SuInfo, *errors.Error)) (*SuInfo, *errors.Error) {
+	options := buildOptions(opts)
+	elementKey := elementKeyOf(shardingData)
+	key := "lookup|" + dimensionKeyOf(ctx, topicInfo, suType, options) + "|" + elementKey
+	if !options.ForceRefresh {
+		if value, ok := c.get(key); ok {
+			return copySuInfo(value.(*SuInfo)), nil
+		}
+	}
+
+	epoch := c.currentEpoch()
+	suInfo, err := load()
+	if nil != err {
+		return nil, err
+	}
+	if nil != suInfo {
+		c.put(epoch, key, elementKey, copySuInfo(suInfo))
+	}
+
+	return suInfo, nil
+}
+
+/* For `Lookup` end */
+
+/* For `Lookups` start */
+func (c *CachedOperator) LookupListUsingTopicInfo(ctx context.Context, topicInfo *TopicInfo, shardingDatas []*ShardingData, opts ...Option) ([]*SuInfo, *errors.Error) {
+	return c.lookupList(ctx, topicInfo, "", shardingDatas, opts, func(missed []*ShardingData) ([]*SuInfo, *errors.Error) {
+		return c.ShardingDataOperator.LookupListUsingTopicInfo(ctx, topicInfo, missed, opts...)
+	})
+}
+
+func (c *CachedOperator) LookupListUsingSUType(ctx context.Context, suType string, shardingDatas []*ShardingData, opts ...Option) ([]*SuInfo, *errors.Error) {
+	return c.lookupList(ctx, nil, suType, shardingDatas, opts, func(missed []*ShardingData) ([]*SuInfo, *errors.Error) {
+		return c.ShardingDataOperator.LookupListUsingSUType(ctx, suType, missed, opts...)
+	})
+}
+
+// lookupList serves the cached sharding datas locally and only looks up the missed sharding datas from GLS
+func (c *CachedOperator) lookupList(ctx context.Context, topicInfo *TopicInfo, suType string, shardingDatas []*ShardingData, opts []Option, load func([]*ShardingData) ([]*SuInfo, *errors.Error)) ([]*SuInfo, *errors.Error) {
+	options := buildOptions(opts)
+	dimensionKey := dimensionKeyOf(ctx, topicInfo, suType, options)
+
+	result := make([]*SuInfo, len(shardingDatas))
+	keys := make([]string, len(shardingDatas))
+	missedIndexes := make([]int, 0)
+	for i, shardingData := range shardingDatas {
+		keys[i] = "lookup|" + dimensionKey + "|" + elementKeyOf(shardingData)
+		if !options.ForceRefresh {
+			if value, ok := c.get(keys[i]); ok {
+				result[i] = copySuInfo(value.(*SuInfo))
+				continue
+			}
+		}
+		missedIndexes = append(missedIndexes, i)
+	}
+	if len(missedIndexes) == 0 {
+		return result, nil
+	}
+
+	epoch := c.currentEpoch()
+	missed := make([]*ShardingData, len(missedIndexes))
+	for j, i := range missedIndexes {
+		missed[j] = shardingDatas[i]
+	}
+	suInfoList, err := load(missed)
+	if nil != err {
+		return nil, err
+	}
+	// the results cannot be matched with the sharding datas, look up all the sharding datas without caching
+	if len(suInfoList) != len(missed) {
+		if len(missed) == len(shardingDatas) {
+			return suInfoList, nil
+		}
+		return load(shardingDatas)
+	}
+
+	for j, i := range missedIndexes {
+		result[i] = suInfoList[j]
+		if nil != suInfoList[j] {
+			c.put(epoch, keys[i], elementKeyOf(shardingDatas[i]), copySuInfo(suInfoList[j]))
+		}
+	}
+
+	return result, nil
+}
+
+/* For `Lookups` end */
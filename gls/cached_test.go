@@ -0,0 +1,149 @@
+package gls
+
+import (
+	"context"
+	"testing"
+
+	"git.multiverse.io/eventkit/kit/client"
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/contexts"
+	mockremote "git.multiverse.io/eventkit/kit/mocks/remote"
+	"github.com/golang/mock/gomock"
+)
+
+type fakeShardingDataOperator struct {
+	ShardingDataOperator
+	bindings    map[string]string
+	lookupTimes int
+	lookupItems int
+	isBoundErr  *errors.Error
+}
+
+func (f *fakeShardingDataOperator) LookupUsingSUType(ctx context.Context, suType string, shardingData *ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	f.lookupTimes++
+	su, ok := f.bindings[shardingData.ID]
+	if !ok {
+		return nil, errors.Errorf(RecordNotFound, "not found")
+	}
+	return &SuInfo{Type: suType, ID: su}, nil
+}
+
+func (f *fakeShardingDataOperator) LookupListUsingSUType(ctx context.Context, suType string, shardingDatas []*ShardingData, opts ...Option) ([]*SuInfo, *errors.Error) {
+	f.lookupTimes++
+	f.lookupItems += len(shardingDatas)
+	result := make([]*SuInfo, 0, len(shardingDatas))
+	for _, shardingData := range shardingDatas {
+		result = append(result, &SuInfo{Type: suType, ID: f.bindings[shardingData.ID]})
+	}
+	return result, nil
+}
+
+func (f *fakeShardingDataOperator) ReBindWithSUType(ctx context.Context, suType string, rebindShardingData *ReBindShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	f.bindings[rebindShardingData.Target.ID] = "su2"
+	return &SuInfo{Type: suType, ID: "su2"}, nil
+}
+
+func TestCachedOperator_Lookup(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	publisher := mockremote.NewMockCallInc(mockCtrl)
+	inner := &fakeShardingDataOperator{bindings: map[string]string{"001": "su1", "002": "su1"}}
+	operator := NewCachedOperator(inner, WithCacheInstanceID("ins1"), WithChangeEventPublisher(publisher, "GlsChanged"))
+	ctx := context.Background()
+	shardingData := &ShardingData{Type: "CUS", ID: "001"}
+
+	for i := 0; i < 3; i++ {
+		suInfo, err := operator.LookupUsingSUType(ctx, "ACC", shardingData)
+		assert.True(t, nil == err)
+		assert.Equal(t, "su1", suInfo.ID)
+	}
+	assert.Equal(t, 1, inner.lookupTimes)
+
+	// only the missed sharding datas are looked up
+	suInfoList, err := operator.LookupListUsingSUType(ctx, "ACC", []*ShardingData{shardingData, {Type: "CUS", ID: "002"}})
+	assert.True(t, nil == err)
+	assert.Equal(t, 2, len(suInfoList))
+	assert.Equal(t, 1, inner.lookupItems)
+
+	// errors are not cached
+	for i := 0; i < 2; i++ {
+		_, err = operator.LookupUsingSUType(ctx, "ACC", &ShardingData{Type: "CUS", ID: "003"})
+		assert.NotNil(t, err)
+	}
+	assert.Equal(t, 4, inner.lookupTimes)
+
+	// write through and publish the change event
+	publisher.EXPECT().AsyncCalls(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request client.Request, opts ...client.CallOption) *errors.Error {
+			assert.Equal(t, constant.TopicTypeOPS, request.RequestOptions().TopicType)
+			assert.Equal(t, "GlsChanged", request.RequestOptions().EventID)
+			event := request.Body().(*ChangeEvent)
+			assert.Equal(t, ChangeActionReBind, event.Action)
+			assert.Equal(t, "ins1", event.Source)
+			assert.Equal(t, 2, len(event.ShardingDatas))
+			return nil
+		}).Times(1)
+	_, err = operator.ReBindWithSUType(ctx, "ACC", &ReBindShardingData{Source: *shardingData, Target: *shardingData})
+	assert.True(t, nil == err)
+	suInfo, err := operator.LookupUsingSUType(ctx, "ACC", shardingData)
+	assert.True(t, nil == err)
+	assert.Equal(t, "su2", suInfo.ID)
+
+	// forced refresh
+	operator.LookupUsingSUType(ctx, "ACC", shardingData, WithForceRefresh())
+	assert.Equal(t, 6, inner.lookupTimes)
+
+	stats := operator.Stats()
+	assert.Equal(t, uint64(3), stats.Hits)
+	assert.Equal(t, 2, stats.Entries)
+}
+
+func TestCachedOperator_LookupWithHandlerContexts(t *testing.T) {
+	inner := &fakeShardingDataOperator{bindings: map[string]string{"001": "su1"}}
+	operator := NewCachedOperator(inner, WithCacheInstanceID("ins1"))
+	shardingData := &ShardingData{Type: "CUS", ID: "001"}
+	ctx1 := contexts.BuildContextFromParentWithHandlerContexts(context.Background(),
+		contexts.BuildHandlerContexts(contexts.ORG("org1"), contexts.WKS("wks"), contexts.ENV("env")))
+	ctx2 := contexts.BuildContextFromParentWithHandlerContexts(context.Background(),
+		contexts.BuildHandlerContexts(contexts.ORG("org2"), contexts.WKS("wks"), contexts.ENV("env")))
+
+	// the results of the different dimensions of the handler contexts are cached separately
+	for _, ctx := range []context.Context{ctx1, ctx2, ctx1, ctx2} {
+		_, err := operator.LookupUsingSUType(ctx, "ACC", shardingData)
+		assert.True(t, nil == err)
+	}
+	assert.Equal(t, 2, inner.lookupTimes)
+
+	// the optional dimension takes precedence over the handler contexts
+	_, err := operator.LookupUsingSUType(ctx2, "ACC", shardingData,
+		WithOptionalDimension(&OptionalDimension{Organization: "org1", Workspace: "wks", Environment: "env"}))
+	assert.True(t, nil == err)
+	assert.Equal(t, 2, inner.lookupTimes)
+}
+
+func TestCachedOperator_HandleChangeEvent(t *testing.T) {
+	inner := &fakeShardingDataOperator{bindings: map[string]string{"001": "su1", "002": "su1"}}
+	operator := NewCachedOperator(inner, WithCacheInstanceID("ins1"))
+	ctx := context.Background()
+
+	operator.LookupUsingSUType(ctx, "ACC", &ShardingData{Type: "CUS", ID: "001"})
+	operator.LookupUsingSUType(ctx, "ACC", &ShardingData{Type: "CUS", ID: "002"})
+	assert.Equal(t, 2, operator.Stats().Entries)
+
+	// the events of itself are ignored
+	operator.HandleChangeEvent(ctx, &ChangeEvent{Source: "ins1", Action: ChangeActionBind, ShardingDatas: []ShardingData{{Type: "CUS", ID: "001"}}})
+	assert.Equal(t, 2, operator.Stats().Entries)
+
+	// the class is defaulted to the type
+	operator.HandleChangeEvent(ctx, &ChangeEvent{Source: "ins2", Action: ChangeActionBind, ShardingDatas: []ShardingData{{Type: "CUS", Class: "CUS", ID: "001"}}})
+	stats := operator.Stats()
+	assert.Equal(t, 1, stats.Entries)
+	assert.Equal(t, uint64(1), stats.ChangeEvents)
+	assert.Equal(t, uint64(1), stats.Invalidations)
+
+	operator.HandleChangeEvent(ctx, &ChangeEvent{Source: "ins2", Action: ChangeActionRefresh})
+	assert.Equal(t, 0, operator.Stats().Entries)
+	assert.False(t, operator.Stats().LastRefreshAt.IsZero())
+}
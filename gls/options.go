@@ -12,6 +12,7 @@ type Options struct {
 	MaxRetryTimes                           int
 	DeleteTransactionPropagationInformation bool
 	TargetEventID                           string
+	ForceRefresh                            bool
 }
 
 type Option func(*Options)
@@ -69,3 +70,10 @@ func WithSyncCallTargetEventID(targetEventID string) Option {
 		options.TargetEventID = targetEventID
 	}
 }
+
+// WithForceRefresh bypasses the local cache of the CachedOperator and reloads the result from GLS
+func WithForceRefresh() Option {
+	return func(options *Options) {
+		options.ForceRefresh = true
+	}
+}
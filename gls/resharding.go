@@ -0,0 +1,596 @@
+package gls
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/cache/v1"
+	"git.multiverse.io/eventkit/kit/client/mesh"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/util"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/handler/remote"
+	"git.multiverse.io/eventkit/kit/log"
+	"github.com/go-redis/redis/v8"
+)
+
+// ReshardingStep is the last checkpointed step of a resharding
+type ReshardingStep int
+
+// Defines all steps of resharding, in the order of execution
+const (
+	ReshardingStepPlanned ReshardingStep = iota
+	ReshardingStepFrozen
+	ReshardingStepCopied
+	ReshardingStepRebound
+	ReshardingStepInvalidated
+	ReshardingStepUnfrozen
+)
+
+var reshardingStepNames = map[ReshardingStep]string{
+	ReshardingStepPlanned:     "PLANNED",
+	ReshardingStepFrozen:      "FROZEN",
+	ReshardingStepCopied:      "COPIED",
+	ReshardingStepRebound:     "REBOUND",
+	ReshardingStepInvalidated: "INVALIDATED",
+	ReshardingStepUnfrozen:    "UNFROZEN",
+}
+
+func (s ReshardingStep) String() string {
+	if name, ok := reshardingStepNames[s]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("UNKNOWN(%d)", int(s))
+}
+
+// Defines all status of resharding
+const (
+	ReshardingStatusRunning     = "RUNNING"
+	ReshardingStatusCompleted   = "COMPLETED"
+	ReshardingStatusFailed      = "FAILED"
+	ReshardingStatusRollingBack = "ROLLING_BACK"
+	ReshardingStatusRolledBack  = "ROLLED_BACK"
+)
+
+// Resharding is the checkpoint of moving a sharding data from the source SU to the target SU
+type Resharding struct {
+	ID                string             `json:"id"`
+	SuType            string             `json:"suType"`
+	ShardingData      ShardingData       `json:"shardingData"`
+	SourceSU          string             `json:"sourceSU"`
+	TargetSU          string             `json:"targetSU"`
+	OptionalDimension *OptionalDimension `json:"optionalDimension,omitempty"`
+	Step              ReshardingStep     `json:"step"`
+	Status            string             `json:"status"`
+	Error             string             `json:"error,omitempty"`
+	StartedAt         time.Time          `json:"startedAt"`
+	UpdatedAt         time.Time          `json:"updatedAt"`
+}
+
+// DataMover copies the data of the sharding data between SUs, both methods must be idempotent
+// because the step may be retried when the resharding resumed.
+type DataMover interface {
+	// Copy copies the data from the source SU to the target SU
+	Copy(ctx context.Context, resharding *Resharding) *errors.Error
+	// Rollback removes the data copied into the target SU
+	Rollback(ctx context.Context, resharding *Resharding) *errors.Error
+}
+
+// Freezer freezes the writes of the sharding data while it is moving
+type Freezer interface {
+	Freeze(ctx context.Context, suType string, shardingData *ShardingData) *errors.Error
+	Unfreeze(ctx context.Context, suType string, shardingData *ShardingData) *errors.Error
+	IsFrozen(ctx context.Context, suType string, shardingData *ShardingData) (bool, *errors.Error)
+}
+
+// CheckNotFrozen returns constant.ShardingDataFrozenError if the sharding data is frozen,
+// the handlers that write the sharding data should call it before writing.
+func CheckNotFrozen(ctx context.Context, freezer Freezer, suType string, shardingData *ShardingData) *errors.Error {
+	frozen, err := freezer.IsFrozen(ctx, suType, shardingData)
+	if nil != err {
+		return err
+	}
+	if frozen {
+		return errors.Errorf(constant.ShardingDataFrozenError,
+			"The sharding data[%s] of SU type[%s] is frozen for resharding", elementKeyOf(shardingData), suType)
+	}
+
+	return nil
+}
+
+func freezeKeyOf(suType string, shardingData *ShardingData) string {
+	return fmt.Sprintf("%s%s.%s", constant.ReshardingFreezeKeyPrefix, suType, elementKeyOf(shardingData))
+}
+
+type localFreezer struct {
+	frozen sync.Map
+}
+
+// NewLocalFreezer creates an in-process freezer, only works when all the writes are in the same instance
+func NewLocalFreezer() Freezer {
+	return &localFreezer{}
+}
+
+func (l *localFreezer) Freeze(ctx context.Context, suType string, shardingData *ShardingData) *errors.Error {
+	l.frozen.Store(freezeKeyOf(suType, shardingData), struct{}{})
+	return nil
+}
+
+func (l *localFreezer) Unfreeze(ctx context.Context, suType string, shardingData *ShardingData) *errors.Error {
+	l.frozen.Delete(freezeKeyOf(suType, shardingData))
+	return nil
+}
+
+func (l *localFreezer) IsFrozen(ctx context.Context, suType string, shardingData *ShardingData) (bool, *errors.Error) {
+	_, ok := l.frozen.Load(freezeKeyOf(suType, shardingData))
+	return ok, nil
+}
+
+type cacheFreezer struct {
+	operator cache.Operator
+	ttl      time.Duration
+}
+
+// NewCacheFreezer creates a freezer that shares the frozen flags across instances by the cache operator,
+// the flag expires after the ttl so that the sharding data will not be frozen forever if the resharding is abandoned.
+// The local addressing cache must enable the keyspace notification, otherwise the other instances read the stale flags.
+func NewCacheFreezer(operator cache.Operator, ttl time.Duration) Freezer {
+	return &cacheFreezer{
+		operator: operator,
+		ttl:      ttl,
+	}
+}
+
+func (c *cacheFreezer) Freeze(ctx context.Context, suType string, shardingData *ShardingData) *errors.Error {
+	if err := c.operator.Set(ctx, freezeKeyOf(suType, shardingData), "1", c.ttl); nil != err {
+		return errors.Wrap(constant.SystemInternalError, err, 0)
+	}
+	return nil
+}
+
+func (c *cacheFreezer) Unfreeze(ctx context.Context, suType string, shardingData *ShardingData) *errors.Error {
+	// the cache operator has no `DEL`, overwrite the flag with a short expiration instead
+	if err := c.operator.Set(ctx, freezeKeyOf(suType, shardingData), "", time.Second); nil != err {
+		return errors.Wrap(constant.SystemInternalError, err, 0)
+	}
+	return nil
+}
+
+func (c *cacheFreezer) IsFrozen(ctx context.Context, suType string, shardingData *ShardingData) (bool, *errors.Error) {
+	v, err := c.operator.Get(ctx, freezeKeyOf(suType, shardingData))
+	if redis.Nil == err {
+		return false, nil
+	}
+	if nil != err {
+		return false, errors.Wrap(constant.SystemInternalError, err, 0)
+	}
+
+	return "1" == v, nil
+}
+
+// CheckpointStore saves the checkpoints of the reshardings
+type CheckpointStore interface {
+	Save(ctx context.Context, resharding *Resharding) *errors.Error
+	// Load returns constant.RecordsNotFound if the resharding does not exist
+	Load(ctx context.Context, id string) (*Resharding, *errors.Error)
+}
+
+type memoryCheckpointStore struct {
+	lock        sync.RWMutex
+	reshardings map[string]Resharding
+}
+
+// NewMemoryCheckpointStore creates an in-process checkpoint store
+func NewMemoryCheckpointStore() CheckpointStore {
+	return &memoryCheckpointStore{
+		reshardings: make(map[string]Resharding),
+	}
+}
+
+func (m *memoryCheckpointStore) Save(ctx context.Context, resharding *Resharding) *errors.Error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.reshardings[resharding.ID] = *resharding
+	return nil
+}
+
+func (m *memoryCheckpointStore) Load(ctx context.Context, id string) (*Resharding, *errors.Error) {
+	m.lock.RLock()
+	defer m.lock.RUnlock()
+
+	resharding, ok := m.reshardings[id]
+	if !ok {
+		return nil, errors.Errorf(constant.RecordsNotFound, "Cannot found resharding[%s]", id)
+	}
+	return &resharding, nil
+}
+
+type cacheCheckpointStore struct {
+	operator  cache.Operator
+	keyPrefix string
+	ttl       time.Duration
+}
+
+// NewCacheCheckpointStore creates a checkpoint store that saves the checkpoints as JSON by the cache operator
+func NewCacheCheckpointStore(operator cache.Operator, keyPrefix string, ttl time.Duration) CheckpointStore {
+	return &cacheCheckpointStore{
+		operator:  operator,
+		keyPrefix: keyPrefix,
+		ttl:       ttl,
+	}
+}
+
+func (c *cacheCheckpointStore) Save(ctx context.Context, resharding *Resharding) *errors.Error {
+	content, e := json.Marshal(resharding)
+	if nil != e {
+		return errors.Wrap(constant.SystemInternalError, e, 0)
+	}
+	if e = c.operator.Set(ctx, c.keyPrefix+resharding.ID, string(content), c.ttl); nil != e {
+		return errors.Wrap(constant.SystemInternalError, e, 0)
+	}
+	return nil
+}
+
+func (c *cacheCheckpointStore) Load(ctx context.Context, id string) (*Resharding, *errors.Error) {
+	content, e := c.operator.Get(ctx, c.keyPrefix+id)
+	if redis.Nil == e {
+		return nil, errors.Errorf(constant.RecordsNotFound, "Cannot found resharding[%s]", id)
+	}
+	if nil != e {
+		return nil, errors.Wrap(constant.SystemInternalError, e, 0)
+	}
+
+	resharding := &Resharding{}
+	if e = json.Unmarshal([]byte(content), resharding); nil != e {
+		return nil, errors.Wrap(constant.SystemInternalError, e, 0)
+	}
+	return resharding, nil
+}
+
+// ReshardingProgress is the progress published on the alert topic after each step
+type ReshardingProgress struct {
+	Resharding
+	Action string `json:"action"`
+}
+
+// ReshardingOptions defines the options of the Resharder
+type ReshardingOptions struct {
+	Freezer         Freezer
+	CheckpointStore CheckpointStore
+	AlertPublisher  remote.CallInc
+	AlertEventID    string
+	// FreezeWaitTime is the time waiting for the in-flight writes to be finished after frozen
+	FreezeWaitTime time.Duration
+}
+
+// ReshardingOption sets the options of the Resharder
+type ReshardingOption func(*ReshardingOptions)
+
+// WithFreezer sets the freezer, defaults to NewLocalFreezer
+func WithFreezer(freezer Freezer) ReshardingOption {
+	return func(o *ReshardingOptions) {
+		o.Freezer = freezer
+	}
+}
+
+// WithCheckpointStore sets the checkpoint store, defaults to NewMemoryCheckpointStore
+func WithCheckpointStore(checkpointStore CheckpointStore) ReshardingOption {
+	return func(o *ReshardingOptions) {
+		o.CheckpointStore = checkpointStore
+	}
+}
+
+// WithAlertPublisher publishes the progress of reshardings to the alert topic with event ID
+func WithAlertPublisher(publisher remote.CallInc, alertEventID string) ReshardingOption {
+	return func(o *ReshardingOptions) {
+		o.AlertPublisher = publisher
+		o.AlertEventID = alertEventID
+	}
+}
+
+// WithFreezeWaitTime sets the time waiting for the in-flight writes to be finished after frozen
+func WithFreezeWaitTime(freezeWaitTime time.Duration) ReshardingOption {
+	return func(o *ReshardingOptions) {
+		o.FreezeWaitTime = freezeWaitTime
+	}
+}
+
+// Resharder moves the sharding datas between SUs with the steps:
+// freeze writes -> copy data by the DataMover -> rebind in GLS -> invalidate caches -> unfreeze writes.
+// Every step is checkpointed so that the resharding can be resumed or rolled back.
+type Resharder struct {
+	operator ShardingDataOperator
+	mover    DataMover
+	options  ReshardingOptions
+}
+
+// NewResharder creates a resharder
+func NewResharder(operator ShardingDataOperator, mover DataMover, opts ...ReshardingOption) *Resharder {
+	options := ReshardingOptions{}
+	for _, o := range opts {
+		o(&options)
+	}
+	if nil == options.Freezer {
+		options.Freezer = NewLocalFreezer()
+	}
+	if nil == options.CheckpointStore {
+		options.CheckpointStore = NewMemoryCheckpointStore()
+	}
+
+	return &Resharder{
+		operator: operator,
+		mover:    mover,
+		options:  options,
+	}
+}
+
+// Freezer returns the freezer of the resharder, the handlers should check it by CheckNotFrozen before writing
+func (r *Resharder) Freezer() Freezer {
+	return r.options.Freezer
+}
+
+// Plan looks up the source SU of the sharding data and saves the resharding as planned
+func (r *Resharder) Plan(ctx context.Context, suType string, shardingData *ShardingData, targetSU string, opts ...Option) (*Resharding, *errors.Error) {
+	options := buildOptions(opts)
+	sourceSuInfo, err := r.operator.LookupUsingSUType(ctx, suType, shardingData, opts...)
+	if nil != err {
+		return nil, err
+	}
+	if sourceSuInfo.ID == targetSU {
+		return nil, errors.Errorf(constant.ReshardingError,
+			"The sharding data[%s] has already been bound to SU[%s]", elementKeyOf(shardingData), targetSU)
+	}
+
+	now := time.Now()
+	resharding := &Resharding{
+		ID:                fmt.Sprintf("%s.%s.%s", suType, elementKeyOf(shardingData), util.RandomString(8)),
+		SuType:            suType,
+		ShardingData:      *shardingData,
+		SourceSU:          sourceSuInfo.ID,
+		TargetSU:          targetSU,
+		OptionalDimension: options.OptionalDimension,
+		Step:              ReshardingStepPlanned,
+		Status:            ReshardingStatusRunning,
+		StartedAt:         now,
+		UpdatedAt:         now,
+	}
+	if err = r.options.CheckpointStore.Save(ctx, resharding); nil != err {
+		return nil, err
+	}
+	r.report(ctx, resharding, "PLAN")
+
+	return resharding, nil
+}
+
+// Reshard plans and runs the resharding
+func (r *Resharder) Reshard(ctx context.Context, suType string, shardingData *ShardingData, targetSU string, opts ...Option) (*Resharding, *errors.Error) {
+	resharding, err := r.Plan(ctx, suType, shardingData, targetSU, opts...)
+	if nil != err {
+		return nil, err
+	}
+
+	return resharding, r.Run(ctx, resharding)
+}
+
+// Resume loads the resharding from the checkpoint store and runs the remaining steps,
+// the resharding that failed to roll back is never run forward, the rollback is continued instead.
+func (r *Resharder) Resume(ctx context.Context, id string) (*Resharding, *errors.Error) {
+	resharding, err := r.options.CheckpointStore.Load(ctx, id)
+	if nil != err {
+		return nil, err
+	}
+	switch resharding.Status {
+	case ReshardingStatusCompleted, ReshardingStatusRolledBack:
+		return resharding, nil
+	case ReshardingStatusRollingBack:
+		return resharding, r.rollback(ctx, resharding)
+	}
+
+	return resharding, r.Run(ctx, resharding)
+}
+
+// Run runs the steps after the checkpointed step, the resharding is marked as failed and keeps the checkpoint if any step failed,
+// it can be resumed by Resume or rolled back by Rollback later.
+func (r *Resharder) Run(ctx context.Context, resharding *Resharding) *errors.Error {
+	if ReshardingStatusRollingBack == resharding.Status || ReshardingStatusRolledBack == resharding.Status {
+		return errors.Errorf(constant.ReshardingError, "The resharding[%s] is %s, it can't be run", resharding.ID, resharding.Status)
+	}
+	steps := []struct {
+		step ReshardingStep
+		fn   func(ctx context.Context, resharding *Resharding) *errors.Error
+	}{
+		{ReshardingStepFrozen, r.freeze},
+		{ReshardingStepCopied, r.mover.Copy},
+		{ReshardingStepRebound, r.rebind},
+		{ReshardingStepInvalidated, r.invalidate},
+		{ReshardingStepUnfrozen, r.unfreeze},
+	}
+
+	resharding.Status = ReshardingStatusRunning
+	resharding.Error = ""
+	for _, s := range steps {
+		if s.step <= resharding.Step {
+			continue
+		}
+		if err := s.fn(ctx, resharding); nil != err {
+			resharding.Status = ReshardingStatusFailed
+			resharding.Error = err.Error()
+			r.checkpoint(ctx, resharding)
+			r.report(ctx, resharding, s.step.String())
+			return errors.Errorf(constant.ReshardingError, "Failed to %s the resharding[%s], error:%s", s.step, resharding.ID, err.Error())
+		}
+		resharding.Step = s.step
+		if ReshardingStepUnfrozen == s.step {
+			resharding.Status = ReshardingStatusCompleted
+		}
+		if err := r.checkpoint(ctx, resharding); nil != err {
+			return err
+		}
+		r.report(ctx, resharding, s.step.String())
+	}
+
+	log.Infof(ctx, "Resharding[%s] completed, sharding data[%s] moved from SU[%s] to SU[%s]",
+		resharding.ID, elementKeyOf(&resharding.ShardingData), resharding.SourceSU, resharding.TargetSU)
+	return nil
+}
+
+// Rollback undoes the checkpointed steps in reverse order:
+// rebinds the sharding data to the source SU, removes the copied data by the DataMover and unfreezes the writes,
+// the copied data is removed once the writes are frozen because the failed copy may be partially applied.
+// The resharding is marked as rolling back until all the steps are undone, so that Resume continues the rollback.
+func (r *Resharder) Rollback(ctx context.Context, id string) (*Resharding, *errors.Error) {
+	resharding, err := r.options.CheckpointStore.Load(ctx, id)
+	if nil != err {
+		return nil, err
+	}
+	if ReshardingStatusCompleted == resharding.Status {
+		return resharding, errors.Errorf(constant.ReshardingError,
+			"The resharding[%s] has been completed, reshard it to SU[%s] instead of rolling back", resharding.ID, resharding.SourceSU)
+	}
+
+	return resharding, r.rollback(ctx, resharding)
+}
+
+func (r *Resharder) rollback(ctx context.Context, resharding *Resharding) *errors.Error {
+	if ReshardingStatusRolledBack == resharding.Status {
+		return nil
+	}
+	resharding.Status = ReshardingStatusRollingBack
+	if err := r.checkpoint(ctx, resharding); nil != err {
+		return err
+	}
+
+	var err *errors.Error
+	for resharding.Step > ReshardingStepPlanned {
+		step := resharding.Step
+		switch resharding.Step {
+		case ReshardingStepInvalidated, ReshardingStepRebound:
+			if err = r.bindTo(ctx, resharding, resharding.SourceSU); nil == err {
+				err = r.invalidate(ctx, resharding)
+			}
+			resharding.Step = ReshardingStepCopied
+		case ReshardingStepCopied, ReshardingStepFrozen:
+			// the copy may be partially applied even if the copy step failed, the copied data is always removed
+			if err = r.mover.Rollback(ctx, resharding); nil == err {
+				err = r.unfreeze(ctx, resharding)
+			}
+			resharding.Step = ReshardingStepPlanned
+		default:
+			resharding.Step--
+		}
+		if nil != err {
+			// keep the step that failed to roll back so that it can be rolled back again
+			resharding.Step = step
+			resharding.Error = err.Error()
+			r.checkpoint(ctx, resharding)
+			r.report(ctx, resharding, "ROLLBACK")
+			return errors.Errorf(constant.ReshardingError, "Failed to rollback the resharding[%s], error:%s", resharding.ID, err.Error())
+		}
+		if err = r.checkpoint(ctx, resharding); nil != err {
+			return err
+		}
+	}
+
+	resharding.Status = ReshardingStatusRolledBack
+	resharding.Error = ""
+	if err = r.checkpoint(ctx, resharding); nil != err {
+		return err
+	}
+	r.report(ctx, resharding, "ROLLBACK")
+
+	return nil
+}
+
+func (r *Resharder) glsOptions(resharding *Resharding) []Option {
+	if nil == resharding.OptionalDimension {
+		return nil
+	}
+	return []Option{WithOptionalDimension(resharding.OptionalDimension)}
+}
+
+func (r *Resharder) freeze(ctx context.Context, resharding *Resharding) *errors.Error {
+	if err := r.options.Freezer.Freeze(ctx, resharding.SuType, &resharding.ShardingData); nil != err {
+		return err
+	}
+	if r.options.FreezeWaitTime > 0 {
+		select {
+		case <-time.After(r.options.FreezeWaitTime):
+		case <-ctx.Done():
+			return errors.Errorf(constant.ReshardingError, "Context done while waiting for the in-flight writes, error:%v", ctx.Err())
+		}
+	}
+
+	return nil
+}
+
+func (r *Resharder) unfreeze(ctx context.Context, resharding *Resharding) *errors.Error {
+	return r.options.Freezer.Unfreeze(ctx, resharding.SuType, &resharding.ShardingData)
+}
+
+func (r *Resharder) rebind(ctx context.Context, resharding *Resharding) *errors.Error {
+	return r.bindTo(ctx, resharding, resharding.TargetSU)
+}
+
+// bindTo binds the sharding data to the SU, it's idempotent so that the step can be retried after partially applied.
+// The sharding data is appended only if it's unbound, any lookup error fails the step instead of binding it twice.
+func (r *Resharder) bindTo(ctx context.Context, resharding *Resharding, su string) *errors.Error {
+	opts := append(r.glsOptions(resharding), WithForceRefresh())
+	bound, err := r.operator.IsBoundWithSUType(ctx, resharding.SuType, &resharding.ShardingData, opts...)
+	if nil != err {
+		return err
+	}
+	if bound {
+		suInfo, err := r.operator.LookupUsingSUType(ctx, resharding.SuType, &resharding.ShardingData, opts...)
+		if nil != err {
+			return err
+		}
+		if suInfo.ID == su {
+			return nil
+		}
+		if _, err = r.operator.UnBindWithSUType(ctx, resharding.SuType, &resharding.ShardingData, r.glsOptions(resharding)...); nil != err {
+			return err
+		}
+	}
+	_, err = r.operator.AppendIntoSUWithSUType(ctx, resharding.SuType, su, &resharding.ShardingData, r.glsOptions(resharding)...)
+
+	return err
+}
+
+func (r *Resharder) invalidate(ctx context.Context, resharding *Resharding) *errors.Error {
+	if cachedOperator, ok := r.operator.(*CachedOperator); ok {
+		cachedOperator.Invalidate(&resharding.ShardingData)
+	}
+	invalidateAddressing(dimensionOf(ctx, buildOptions(r.glsOptions(resharding))), []*ShardingData{&resharding.ShardingData})
+
+	return nil
+}
+
+func (r *Resharder) checkpoint(ctx context.Context, resharding *Resharding) *errors.Error {
+	resharding.UpdatedAt = time.Now()
+	if err := r.options.CheckpointStore.Save(ctx, resharding); nil != err {
+		log.Errorf(ctx, "Failed to save the checkpoint of resharding[%s], error:%s", resharding.ID, err.Error())
+		return err
+	}
+
+	return nil
+}
+
+func (r *Resharder) report(ctx context.Context, resharding *Resharding, action string) {
+	if nil == r.options.AlertPublisher || "" == r.options.AlertEventID {
+		return
+	}
+
+	progress := &ReshardingProgress{
+		Resharding: *resharding,
+		Action:     action,
+	}
+	request := mesh.NewMeshRequest(progress, mesh.WithTopicTypeAlert(), mesh.WithEventID(r.options.AlertEventID))
+	if err := r.options.AlertPublisher.AsyncCalls(ctx, request); nil != err {
+		log.Errorf(ctx, "Failed to publish the progress of resharding[%s], error:%s", resharding.ID, err.Error())
+	}
+}
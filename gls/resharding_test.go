@@ -0,0 +1,202 @@
+package gls
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/cache/v1/memory"
+	"git.multiverse.io/eventkit/kit/client"
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/constant"
+	mockremote "git.multiverse.io/eventkit/kit/mocks/remote"
+	"github.com/golang/mock/gomock"
+)
+
+func (f *fakeShardingDataOperator) UnBindWithSUType(ctx context.Context, suType string, shardingData *ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	delete(f.bindings, shardingData.ID)
+	return &SuInfo{Type: suType}, nil
+}
+
+func (f *fakeShardingDataOperator) IsBoundWithSUType(ctx context.Context, suType string, shardingData *ShardingData, opts ...Option) (bool, *errors.Error) {
+	if nil != f.isBoundErr {
+		return false, f.isBoundErr
+	}
+	_, ok := f.bindings[shardingData.ID]
+	return ok, nil
+}
+
+func (f *fakeShardingDataOperator) AppendIntoSUWithSUType(ctx context.Context, suType string, appendToSU string, newShardingData *ShardingData, opts ...Option) (*SuInfo, *errors.Error) {
+	f.bindings[newShardingData.ID] = appendToSU
+	return &SuInfo{Type: suType, ID: appendToSU}, nil
+}
+
+type fakeDataMover struct {
+	copyErr     *errors.Error
+	rollbackErr *errors.Error
+	copied      bool
+	freezer     Freezer
+	wasFrozen   bool
+}
+
+func (f *fakeDataMover) Copy(ctx context.Context, resharding *Resharding) *errors.Error {
+	if nil != f.copyErr {
+		return f.copyErr
+	}
+	f.wasFrozen = nil != CheckNotFrozen(ctx, f.freezer, resharding.SuType, &resharding.ShardingData)
+	f.copied = true
+	return nil
+}
+
+func (f *fakeDataMover) Rollback(ctx context.Context, resharding *Resharding) *errors.Error {
+	if nil != f.rollbackErr {
+		return f.rollbackErr
+	}
+	f.copied = false
+	return nil
+}
+
+func TestResharder_Reshard(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	publisher := mockremote.NewMockCallInc(mockCtrl)
+	operator := &fakeShardingDataOperator{bindings: map[string]string{"001": "su1"}}
+	freezer := NewCacheFreezer(memory.NewOperator(), time.Minute)
+	mover := &fakeDataMover{freezer: freezer}
+	resharder := NewResharder(operator, mover,
+		WithFreezer(freezer),
+		WithCheckpointStore(NewCacheCheckpointStore(memory.NewOperator(), "RESHARDING.", 0)),
+		WithAlertPublisher(publisher, "ReshardingProgress"))
+	ctx := context.Background()
+
+	actions := make([]string, 0)
+	publisher.EXPECT().AsyncCalls(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request client.Request, opts ...client.CallOption) *errors.Error {
+			assert.Equal(t, constant.TopicTypeAlert, request.RequestOptions().TopicType)
+			actions = append(actions, request.Body().(*ReshardingProgress).Action)
+			return nil
+		}).AnyTimes()
+
+	resharding, err := resharder.Reshard(ctx, "ACC", &ShardingData{Type: "CUS", ID: "001"}, "su2")
+	assert.True(t, nil == err)
+	assert.Equal(t, ReshardingStatusCompleted, resharding.Status)
+	assert.Equal(t, "su1", resharding.SourceSU)
+	assert.Equal(t, "su2", operator.bindings["001"])
+	assert.True(t, mover.wasFrozen)
+	assert.True(t, nil == CheckNotFrozen(ctx, freezer, "ACC", &ShardingData{Type: "CUS", ID: "001"}))
+	assert.Equal(t, []string{"PLAN", "FROZEN", "COPIED", "REBOUND", "INVALIDATED", "UNFROZEN"}, actions)
+
+	// already bound
+	_, err = resharder.Reshard(ctx, "ACC", &ShardingData{Type: "CUS", ID: "001"}, "su2")
+	assert.NotNil(t, err)
+}
+
+func TestResharder_ResumeAndRollback(t *testing.T) {
+	operator := &fakeShardingDataOperator{bindings: map[string]string{"001": "su1"}}
+	mover := &fakeDataMover{copyErr: errors.Errorf(constant.SystemInternalError, "copy failed")}
+	resharder := NewResharder(operator, mover)
+	mover.freezer = resharder.Freezer()
+	ctx := context.Background()
+	shardingData := &ShardingData{Type: "CUS", ID: "001"}
+
+	resharding, err := resharder.Reshard(ctx, "ACC", shardingData, "su2")
+	assert.NotNil(t, err)
+	assert.Equal(t, ReshardingStatusFailed, resharding.Status)
+	assert.Equal(t, ReshardingStepFrozen, resharding.Step)
+	assert.NotNil(t, CheckNotFrozen(ctx, resharder.Freezer(), "ACC", shardingData))
+
+	// the copy may be partially applied, the copied data is removed when rolling back from the frozen step
+	mover.copied = true
+	rolledBack, err := resharder.Rollback(ctx, resharding.ID)
+	assert.True(t, nil == err)
+	assert.Equal(t, ReshardingStatusRolledBack, rolledBack.Status)
+	assert.False(t, mover.copied)
+	assert.True(t, nil == CheckNotFrozen(ctx, resharder.Freezer(), "ACC", shardingData))
+
+	// resume from the checkpoint
+	resharding, err = resharder.Reshard(ctx, "ACC", shardingData, "su2")
+	assert.NotNil(t, err)
+	mover.copyErr = nil
+	resharding, err = resharder.Resume(ctx, resharding.ID)
+	assert.True(t, nil == err)
+	assert.Equal(t, ReshardingStatusCompleted, resharding.Status)
+	assert.Equal(t, "su2", operator.bindings["001"])
+
+	// rollback
+	operator.bindings["002"] = "su1"
+	resharding, _ = resharder.Plan(ctx, "ACC", &ShardingData{Type: "CUS", ID: "002"}, "su2")
+	resharding.Step = ReshardingStepInvalidated
+	operator.bindings["002"] = "su2"
+	mover.copied = true
+	resharder.freeze(ctx, resharding)
+	resharder.checkpoint(ctx, resharding)
+
+	resharding, err = resharder.Rollback(ctx, resharding.ID)
+	assert.True(t, nil == err)
+	assert.Equal(t, ReshardingStatusRolledBack, resharding.Status)
+	assert.Equal(t, ReshardingStepPlanned, resharding.Step)
+	assert.Equal(t, "su1", operator.bindings["002"])
+	assert.False(t, mover.copied)
+	assert.True(t, nil == CheckNotFrozen(ctx, resharder.Freezer(), "ACC", &ShardingData{Type: "CUS", ID: "002"}))
+}
+
+func TestResharder_ResumeFailedRollback(t *testing.T) {
+	operator := &fakeShardingDataOperator{bindings: map[string]string{"001": "su1"}}
+	mover := &fakeDataMover{}
+	resharder := NewResharder(operator, mover)
+	mover.freezer = resharder.Freezer()
+	ctx := context.Background()
+	shardingData := &ShardingData{Type: "CUS", ID: "001"}
+
+	resharding, _ := resharder.Plan(ctx, "ACC", shardingData, "su2")
+	resharding.Step = ReshardingStepRebound
+	operator.bindings["001"] = "su2"
+	mover.copied = true
+	resharder.freeze(ctx, resharding)
+	resharder.checkpoint(ctx, resharding)
+
+	// the rebinding is undone, but the copied data fails to be removed
+	mover.rollbackErr = errors.Errorf(constant.SystemInternalError, "rollback failed")
+	resharding, err := resharder.Rollback(ctx, resharding.ID)
+	assert.NotNil(t, err)
+	assert.Equal(t, ReshardingStatusRollingBack, resharding.Status)
+	assert.Equal(t, ReshardingStepCopied, resharding.Step)
+	assert.Equal(t, "su1", operator.bindings["001"])
+
+	// the half-rolled-back resharding is never run forward
+	assert.NotNil(t, resharder.Run(ctx, resharding))
+	assert.Equal(t, "su1", operator.bindings["001"])
+
+	// resume continues the rollback
+	mover.rollbackErr = nil
+	resharding, err = resharder.Resume(ctx, resharding.ID)
+	assert.True(t, nil == err)
+	assert.Equal(t, ReshardingStatusRolledBack, resharding.Status)
+	assert.Equal(t, ReshardingStepPlanned, resharding.Step)
+	assert.Equal(t, "su1", operator.bindings["001"])
+	assert.False(t, mover.copied)
+	assert.True(t, nil == CheckNotFrozen(ctx, resharder.Freezer(), "ACC", shardingData))
+}
+
+func TestResharder_RebindWithLookupError(t *testing.T) {
+	operator := &fakeShardingDataOperator{bindings: map[string]string{"001": "su1"}}
+	resharder := NewResharder(operator, &fakeDataMover{})
+	ctx := context.Background()
+	resharding, err := resharder.Plan(ctx, "ACC", &ShardingData{Type: "CUS", ID: "001"}, "su2")
+	assert.True(t, nil == err)
+
+	// the sharding data isn't appended to the target SU if it cannot be checked whether it's bound
+	operator.isBoundErr = errors.Errorf(constant.SystemInternalError, "timeout")
+	assert.NotNil(t, resharder.rebind(ctx, resharding))
+	assert.Equal(t, "su1", operator.bindings["001"])
+
+	operator.isBoundErr = nil
+	assert.True(t, nil == resharder.rebind(ctx, resharding))
+	assert.Equal(t, "su2", operator.bindings["001"])
+
+	// the unbound sharding data is appended
+	delete(operator.bindings, "001")
+	assert.True(t, nil == resharder.rebind(ctx, resharding))
+	assert.Equal(t, "su2", operator.bindings["001"])
+}
@@ -0,0 +1,71 @@
+package base
+
+import (
+	"context"
+
+	"git.multiverse.io/eventkit/kit/handler/remote"
+)
+
+type handlerContextKey struct{}
+
+// NewContext returns a new context that carries the handler, the context-first handler functions
+// read the request header, the topic attributes and the remote call from the context
+func NewContext(ctx context.Context, h *Handler) context.Context {
+	return context.WithValue(ctx, handlerContextKey{}, h)
+}
+
+// FromContext returns the handler carried by the context, returns nil if the context doesn't carry the handler
+func FromContext(ctx context.Context) *Handler {
+	if nil == ctx {
+		return nil
+	}
+	h, _ := ctx.Value(handlerContextKey{}).(*Handler)
+
+	return h
+}
+
+// RequestHeaderFromContext returns the request header carried by the context
+func RequestHeaderFromContext(ctx context.Context) map[string]string {
+	if h := FromContext(ctx); nil != h {
+		return h.GetRequestHeader()
+	}
+
+	return nil
+}
+
+// RequestHeaderValueFromContext returns the value of the request header carried by the context
+func RequestHeaderValueFromContext(ctx context.Context, key string) string {
+	return RequestHeaderFromContext(ctx)[key]
+}
+
+// TopicAttributesFromContext returns the topic attributes carried by the context
+func TopicAttributesFromContext(ctx context.Context) map[string]string {
+	if h := FromContext(ctx); nil != h {
+		return h.GetTopicAttributes()
+	}
+
+	return nil
+}
+
+// RemoteCallFromContext returns the remote call instance carried by the context
+func RemoteCallFromContext(ctx context.Context) remote.CallInc {
+	if h := FromContext(ctx); nil != h {
+		return h.RemoteCallInc
+	}
+
+	return nil
+}
+
+// AddResponseHeaderToContext adds the pair of key-value to the response header of the handler carried by the context
+func AddResponseHeaderToContext(ctx context.Context, key, value string) {
+	if h := FromContext(ctx); nil != h {
+		h.AddResponseHeader(key, value)
+	}
+}
+
+// DiscardResponseOfContext marks to discard the response of the handler carried by the context
+func DiscardResponseOfContext(ctx context.Context) {
+	if h := FromContext(ctx); nil != h {
+		h.DiscardResponse()
+	}
+}
@@ -0,0 +1,41 @@
+package base
+
+import (
+	"context"
+	"testing"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/mocks/remote"
+	"github.com/golang/mock/gomock"
+)
+
+func TestNewContext(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	callInc := remote.NewMockCallInc(mockCtrl)
+
+	h := &Handler{}
+	h.SetRequestHeader(map[string]string{"tenant": "T1"})
+	h.SetTopicAttributes(map[string]string{constant.TopicID: "TOPIC1"})
+	h.SetRemoteCall(callInc)
+	ctx := NewContext(context.Background(), h)
+
+	assert.Equal(t, h, FromContext(ctx))
+	assert.Equal(t, "T1", RequestHeaderValueFromContext(ctx, "tenant"))
+	assert.Equal(t, "TOPIC1", TopicAttributesFromContext(ctx)[constant.TopicID])
+	assert.Equal(t, callInc, RemoteCallFromContext(ctx))
+
+	AddResponseHeaderToContext(ctx, "k", "v")
+	DiscardResponseOfContext(ctx)
+	assert.Equal(t, "v", h.GetResponseHeader()["k"])
+	assert.True(t, h.IsDiscardResponse())
+
+	// the context without the handler
+	ctx = context.Background()
+	assert.True(t, nil == FromContext(ctx))
+	assert.Equal(t, "", RequestHeaderValueFromContext(ctx, "tenant"))
+	assert.True(t, nil == TopicAttributesFromContext(ctx))
+	assert.True(t, nil == RemoteCallFromContext(ctx))
+	AddResponseHeaderToContext(ctx, "k", "v")
+}
@@ -0,0 +1,153 @@
+// Package binding binds the path parameters and the query arguments of the HTTP requests into the request struct.
+// The fields are bound by the tags `path` and `query`, e.g.
+//
+//	type GetAccountRequest struct {
+//		ID     string   `path:"id"`
+//		Fields []string `query:"fields"`
+//		Limit  int      `query:"limit"`
+//	}
+package binding
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/constant"
+)
+
+// The following elements represent the tags of the bound fields.
+const (
+	PathTag  = "path"
+	QueryTag = "query"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Bind binds the path parameters and the query arguments into the fields of the struct that v points to,
+// v that doesn't point to a struct is ignored.
+func Bind(v interface{}, pathParams map[string]string, queryParams map[string][]string) *errors.Error {
+	if 0 == len(pathParams) && 0 == len(queryParams) {
+		return nil
+	}
+	value := reflect.ValueOf(v)
+	if reflect.Ptr != value.Kind() || value.IsNil() {
+		return nil
+	}
+	value = value.Elem()
+	if reflect.Struct != value.Kind() {
+		return nil
+	}
+
+	return bindStruct(value, pathParams, queryParams)
+}
+
+func bindStruct(value reflect.Value, pathParams map[string]string, queryParams map[string][]string) *errors.Error {
+	valueType := value.Type()
+	for i := 0; i < valueType.NumField(); i++ {
+		field := valueType.Field(i)
+		fieldValue := value.Field(i)
+		if field.Anonymous && reflect.Struct == field.Type.Kind() {
+			if err := bindStruct(fieldValue, pathParams, queryParams); nil != err {
+				return err
+			}
+			continue
+		}
+		if !fieldValue.CanSet() {
+			continue
+		}
+		if name := field.Tag.Get(PathTag); "" != name {
+			if param, ok := pathParams[name]; ok {
+				if err := setValues(fieldValue, []string{param}); nil != err {
+					return errors.Errorf(constant.UpstreamServiceMessageDecodeError, "Invalid path parameter[%s]=[%s], error=%v", name, param, err)
+				}
+			}
+		}
+		if name := field.Tag.Get(QueryTag); "" != name {
+			if values, ok := queryParams[name]; ok && len(values) > 0 {
+				if err := setValues(fieldValue, values); nil != err {
+					return errors.Errorf(constant.UpstreamServiceMessageDecodeError, "Invalid query argument[%s]=%v, error=%v", name, values, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func setValues(value reflect.Value, values []string) error {
+	switch value.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(value.Type().Elem())
+		if err := setValues(elem.Elem(), values); nil != err {
+			return err
+		}
+		value.Set(elem)
+		return nil
+	case reflect.Slice:
+		if reflect.Uint8 == value.Type().Elem().Kind() {
+			value.SetBytes([]byte(values[0]))
+			return nil
+		}
+		slice := reflect.MakeSlice(value.Type(), len(values), len(values))
+		for i, v := range values {
+			if err := setValue(slice.Index(i), v); nil != err {
+				return err
+			}
+		}
+		value.Set(slice)
+		return nil
+	default:
+		return setValue(value, values[0])
+	}
+}
+
+func setValue(value reflect.Value, s string) error {
+	if durationType == value.Type() {
+		d, err := time.ParseDuration(s)
+		if nil != err {
+			return err
+		}
+		value.SetInt(int64(d))
+		return nil
+	}
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if nil != err {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, value.Type().Bits())
+		if nil != err {
+			return err
+		}
+		value.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, value.Type().Bits())
+		if nil != err {
+			return err
+		}
+		value.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, value.Type().Bits())
+		if nil != err {
+			return err
+		}
+		value.SetFloat(f)
+	case reflect.Ptr:
+		elem := reflect.New(value.Type().Elem())
+		if err := setValue(elem.Elem(), s); nil != err {
+			return err
+		}
+		value.Set(elem)
+	default:
+		return errors.Errorf(constant.SystemInternalError, "unsupported type:%s", value.Type())
+	}
+
+	return nil
+}
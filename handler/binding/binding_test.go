@@ -0,0 +1,64 @@
+package binding
+
+import (
+	"testing"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/constant"
+)
+
+type Paging struct {
+	Limit  int    `query:"limit"`
+	Offset *int64 `query:"offset"`
+}
+
+type GetAccountRequest struct {
+	Paging
+	ID       string        `path:"id" json:"id"`
+	Seq      uint32        `path:"seq"`
+	Fields   []string      `query:"fields"`
+	Active   bool          `query:"active"`
+	Rate     float64       `query:"rate"`
+	Timeout  time.Duration `query:"timeout"`
+	Name     string        `json:"name"`
+	internal string        `query:"internal"`
+}
+
+func TestBind(t *testing.T) {
+	request := &GetAccountRequest{Name: "from body"}
+	err := Bind(request, map[string]string{"id": "10001", "seq": "3"}, map[string][]string{
+		"fields":   {"name", "balance"},
+		"active":   {"true"},
+		"rate":     {"0.5"},
+		"timeout":  {"3s"},
+		"limit":    {"20"},
+		"offset":   {"40"},
+		"internal": {"x"},
+	})
+	assert.True(t, nil == err)
+	assert.Equal(t, "10001", request.ID)
+	assert.Equal(t, uint32(3), request.Seq)
+	assert.Equal(t, []string{"name", "balance"}, request.Fields)
+	assert.True(t, request.Active)
+	assert.Equal(t, 0.5, request.Rate)
+	assert.Equal(t, 3*time.Second, request.Timeout)
+	assert.Equal(t, 20, request.Limit)
+	assert.Equal(t, int64(40), *request.Offset)
+	assert.Equal(t, "from body", request.Name)
+	assert.Equal(t, "", request.internal)
+
+	// the values that are not struct are ignored
+	s := "body"
+	assert.True(t, nil == Bind(&s, map[string]string{"id": "1"}, nil))
+	assert.Equal(t, "body", s)
+}
+
+func TestBindInvalidValue(t *testing.T) {
+	err := Bind(&GetAccountRequest{}, nil, map[string][]string{"limit": {"ten"}})
+	assert.NotNil(t, err)
+	assert.Equal(t, constant.UpstreamServiceMessageDecodeError, err.ErrorCode)
+
+	err = Bind(&GetAccountRequest{}, map[string]string{"seq": "-1"}, nil)
+	assert.NotNil(t, err)
+}
@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"strings"
 	"sync"
+	"time"
 
 	"git.multiverse.io/eventkit/kit/constant"
 	"git.multiverse.io/eventkit/kit/log"
@@ -115,6 +116,9 @@ type ServiceConfigs struct {
 	ServerAddress               string                                     `json:"serverAddress"`
 	Port                        int                                        `json:"port"`
 	CallbackPort                int                                        `json:"callbackPort"`
+	CallbackSocketPath          string                                     `json:"callbackSocketPath"`
+	EnableBinaryFrame           bool                                       `json:"enableBinaryFrame"`
+	Concurrency                 Concurrency                                `json:"concurrency"`
 	CommType                    string                                     `json:"commType"`
 	Service                     Service                                    `json:"service"`
 	ClientSideStatusFSM         bool                                       `json:"clientSideStatusFSM"`
@@ -215,6 +219,116 @@ type Service struct {
 	SkipRemoteCallInterceptorsMap map[string]bool        `json:"skipRemoteCallInterceptorsMap"`
 	ResponseCodeMapping           map[string]string      `json:"responseCodeMapping"`
 	DuplicateErrorCodeTo          string                 `json:"duplicateErrorCodeTo"`
+	// ResponseCompression compresses the responses of the callbacks, the responses of the URL path handlers are not compressed
+	ResponseCompression Compression `json:"responseCompression"`
+	// HTTPStatusMapping maps the error codes to the HTTP status of the responses of the URL path handlers
+	HTTPStatusMapping map[string]int `json:"httpStatusMapping"`
+	// DefaultErrorHTTPStatus is the HTTP status of the error responses without mapping, 0 means 200
+	DefaultErrorHTTPStatus int `json:"defaultErrorHTTPStatus"`
+	// OpenAPI is the config of the OpenAPI document generated from the registered handlers
+	OpenAPI OpenAPI `json:"openAPI"`
+	// HTTPServer is the config of the HTTP server serving the URL path handlers
+	HTTPServer HTTPServer `json:"httpServer"`
+	// Routes is the switches of the routes keyed by the event ID(or prefix, suffix, matcher expression) or the URL path(case-insensitive),
+	// "*" means all the routes, the switches are hot reloaded.
+	Routes map[string]RouteSwitch `json:"routes"`
+	// HandlerTimeout is the config of the timeout enforcement of the handlers
+	HandlerTimeout HandlerTimeout `json:"handlerTimeout"`
+}
+
+// HandlerTimeout stores the config of the timeout enforcement of the handlers
+type HandlerTimeout struct {
+	// Enable cancels the handlers when the timeout propagated by the upstream runs out
+	Enable bool `json:"enable"`
+	// SafetyMarginMilliseconds is subtracted from the propagated timeout so that the response is replied before the upstream gives up,
+	// 0 means the default margin(100ms)
+	SafetyMarginMilliseconds int `json:"safetyMarginMilliseconds"`
+}
+
+// The following elements represent the modes of the route switch.
+const (
+	RouteModeEnabled     = "enabled"
+	RouteModeDisabled    = "disabled"
+	RouteModeMaintenance = "maintenance"
+	RouteModeReadOnly    = "readonly"
+)
+
+// RouteSwitch stores the switch of a route
+type RouteSwitch struct {
+	// Route is the event ID or the URL path of the switch instead of the key,
+	// it's necessary for the routes containing dots which are split by the config loader.
+	Route string `json:"route"`
+	// Mode is one of enabled/disabled/maintenance/readonly(case-insensitive), empty means enabled
+	Mode string `json:"mode"`
+	// ErrorCode and ErrorMessage are the error responded by the route in the maintenance mode
+	ErrorCode    string `json:"errorCode"`
+	ErrorMessage string `json:"errorMessage"`
+}
+
+// IsEnabled returns whether the route is enabled
+func (r RouteSwitch) IsEnabled() bool {
+	return "" == r.Mode || RouteModeEnabled == strings.ToLower(r.Mode)
+}
+
+// IsValid returns whether the mode of the switch is known
+func (r RouteSwitch) IsValid() bool {
+	switch strings.ToLower(r.Mode) {
+	case "", RouteModeEnabled, RouteModeDisabled, RouteModeMaintenance, RouteModeReadOnly:
+		return true
+	}
+
+	return false
+}
+
+// HTTPServer stores the config of the HTTP server serving the URL path handlers
+type HTTPServer struct {
+	TLS                      TLS `json:"tls"`
+	ReadTimeoutMilliseconds  int `json:"readTimeoutMilliseconds"`
+	WriteTimeoutMilliseconds int `json:"writeTimeoutMilliseconds"`
+	IdleTimeoutMilliseconds  int `json:"idleTimeoutMilliseconds"`
+	// MaxRequestBodySize is the max size(bytes) of the request bodies, 0 means the default size(1 GiB)
+	MaxRequestBodySize int `json:"maxRequestBodySize"`
+	// RouteMaxRequestBodySize is the max size(bytes) of the request bodies keyed by the registered URL path(case-insensitive)
+	RouteMaxRequestBodySize map[string]int  `json:"routeMaxRequestBodySize"`
+	CORS                    CORS            `json:"cors"`
+	SecurityHeaders         SecurityHeaders `json:"securityHeaders"`
+}
+
+// TLS stores the certificate and the private key of the HTTP server, the TLS is disabled if empty
+type TLS struct {
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+}
+
+// CORS stores the config of the cross-origin resource sharing
+type CORS struct {
+	Enable bool `json:"enable"`
+	// AllowOrigins is the allowed origins, "*" means any origin, "https://*.example.com" means any subdomain,
+	// "*" is ignored if AllowCredentials is true
+	AllowOrigins []string `json:"allowOrigins"`
+	// AllowMethods is the allowed methods, empty means all the methods of the URL path handlers
+	AllowMethods []string `json:"allowMethods"`
+	// AllowHeaders is the allowed request headers, empty means the headers requested by the preflight
+	AllowHeaders     []string `json:"allowHeaders"`
+	ExposeHeaders    []string `json:"exposeHeaders"`
+	AllowCredentials bool     `json:"allowCredentials"`
+	MaxAgeSeconds    int      `json:"maxAgeSeconds"`
+}
+
+// SecurityHeaders stores the config of the security headers of the responses
+type SecurityHeaders struct {
+	Enable bool `json:"enable"`
+	// Headers overrides the default security headers, the header with empty value is removed
+	Headers map[string]string `json:"headers"`
+}
+
+// OpenAPI stores the config of the OpenAPI document
+type OpenAPI struct {
+	// Path is the URL path that serves the OpenAPI document, the document isn't served if empty
+	Path        string `json:"path"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
 }
 
 // CustomResponseTemplate stores the custom response telmpate
@@ -259,6 +373,50 @@ func (s Service) IsMarkedAsSkippedRemoteCallInterceptor(interceptorName string)
 	return false
 }
 
+// LocalCache stores configuration of [addressing.localCache] section,
+// the local cache is an in-process LRU cache in front of the addressing cache(redis)
+type LocalCache struct {
+	Enable                       bool     `json:"enable"`
+	MaxEntries                   int      `json:"maxEntries"`
+	TTLMilliseconds              int      `json:"ttlMilliseconds"`
+	NegativeTTLMilliseconds      int      `json:"negativeTTLMilliseconds"`
+	EnableKeyspaceNotification   bool     `json:"enableKeyspaceNotification"`
+	KeyspaceNotificationPatterns []string `json:"keyspaceNotificationPatterns"`
+}
+
+// Equals returns whether the self and other are equals
+func (l LocalCache) Equals(o *LocalCache) bool {
+	return reflect.DeepEqual(&l, o)
+}
+
+// Failover stores configuration of [addressing.failover] section,
+// the requests of the failover-capable topics are routed to the standby SU or CommonSu when the addressed SU is down.
+type Failover struct {
+	Enable bool `json:"enable"`
+	// Topics are the failover-capable topic IDs
+	Topics []string `json:"topics"`
+	// StandbySUs maps the primary SU to its standby SU
+	StandbySUs         map[string]string `json:"standbySUs"`
+	FallbackToCommonSu bool              `json:"fallbackToCommonSu"`
+	// FailureThreshold is the number of consecutive failures that marks the SU as down, defaults to 3
+	FailureThreshold int `json:"failureThreshold"`
+	// RecoveryMilliseconds is the time that the SU is kept down before it's retried, defaults to 30 seconds
+	RecoveryMilliseconds int `json:"recoveryMilliseconds"`
+	// SuListRefreshMilliseconds is the refresh interval of the SU list queried from GLS, defaults to 60 seconds
+	SuListRefreshMilliseconds int `json:"suListRefreshMilliseconds"`
+	// GlsSu is the SU of GLS that the SU list is queried from, empty means it's routed by the mesh
+	GlsSu string `json:"glsSu"`
+	// ErrorCodes are the error codes that count as the failures of SU, defaults to the timeout and connection errors
+	ErrorCodes []string `json:"errorCodes"`
+	// AlertEventID is the event ID that the failover alerts are published to, the alerts are only logged if it's empty
+	AlertEventID string `json:"alertEventID"`
+}
+
+// Equals returns whether the self and other are equals
+func (f Failover) Equals(o *Failover) bool {
+	return reflect.DeepEqual(&f, o)
+}
+
 // Addressing stores configuration of [addressing] section
 type Addressing struct {
 	Enable                       bool           `json:"enable"`
@@ -272,6 +430,8 @@ type Addressing struct {
 	RandomTopicIDList            []string       `json:"randomTopicIDList"`
 	RandomTopicIDMap             map[string]int `json:"randomTopicIDMap"`
 	Cache                        GLSCache       `json:"cache"`
+	LocalCache                   LocalCache     `json:"localCache"`
+	Failover                     Failover       `json:"failover"`
 }
 
 // Equals returns whether the self and other are equals
@@ -617,9 +777,34 @@ type Downstream struct {
 	CircuitBreaker                   CircuitBreaker       `json:"circuitBreaker"`
 	CustomConfigurations             CustomConfigurations `json:"customConfigurations"`
 	EnableLogging                    bool                 `json:"enableLogging"`
+	Compression                      Compression          `json:"compression"`
 	//Masker                           Masker               `json:"masker"`
 }
 
+// Compression stores configuration of the payload compression
+type Compression struct {
+	// Algorithm is one of gzip/zstd/snappy, empty means the compression is disabled
+	Algorithm string `json:"algorithm"`
+	// Threshold is the minimum payload size(bytes) to compress
+	Threshold int `json:"threshold"`
+}
+
+// Concurrency stores configuration of [concurrency] section, the concurrency control of the callbacks
+type Concurrency struct {
+	// MaxConcurrency is the max callbacks executed concurrently, 0 means unlimited
+	MaxConcurrency int `json:"maxConcurrency"`
+	// TopicTypes is the concurrency limits and priorities keyed by the topic type(case-insensitive), "*" means the other topic types
+	TopicTypes map[string]TopicTypeConcurrency `json:"topicTypes"`
+}
+
+// TopicTypeConcurrency stores configuration of [concurrency.topicTypes.XXX] section
+type TopicTypeConcurrency struct {
+	MaxConcurrency           int   `json:"maxConcurrency"`
+	MaxQueueSize             int   `json:"maxQueueSize"`
+	Priority                 int   `json:"priority"`
+	QueueTimeoutMilliseconds int64 `json:"queueTimeoutMilliseconds"`
+}
+
 // Masker stores configuration of [downstream.XXXXX.masker] section
 type Masker struct {
 	MaskRules             []string `json:"maskRules"`
@@ -696,6 +881,21 @@ func (s *ServiceConfigs) GenCallbackOptions() []callback.Option {
 		func(options *callback.Options) {
 			options.Port = s.Port
 			options.CallbackPort = s.CallbackPort
+			options.CallbackSocketPath = s.CallbackSocketPath
+			options.EnableBinaryFrame = s.EnableBinaryFrame
+			options.MaxConcurrency = s.Concurrency.MaxConcurrency
+			if len(s.Concurrency.TopicTypes) > 0 {
+				options.ConcurrencyLimits = make(map[string]callback.ConcurrencyLimit, len(s.Concurrency.TopicTypes))
+				// the keys are lowercased by the config loader, the topic types are uppercase
+				for topicType, c := range s.Concurrency.TopicTypes {
+					options.ConcurrencyLimits[strings.ToUpper(topicType)] = callback.ConcurrencyLimit{
+						MaxConcurrency: c.MaxConcurrency,
+						MaxQueueSize:   c.MaxQueueSize,
+						Priority:       c.Priority,
+						QueueTimeout:   time.Duration(c.QueueTimeoutMilliseconds) * time.Millisecond,
+					}
+				}
+			}
 			options.ServerAddress = s.ServerAddress
 			options.CommType = s.CommType
 			options.EnableClientSideStatusFSM = s.ClientSideStatusFSM
@@ -96,6 +96,12 @@ func (b *Loader) LoadConfig(filePath string) (*config.ServiceConfigs, error) {
 
 	viper.SetDefault("addressing.topicSuTitle", "TOP.GLSTOPIC")
 	viper.SetDefault("addressing.topicIDOfServer", "GlsAppConfig")
+	viper.SetDefault("addressing.localCache.maxEntries", 100000)
+	viper.SetDefault("addressing.localCache.ttlMilliseconds", 60*1000)
+	viper.SetDefault("addressing.localCache.negativeTTLMilliseconds", 1000)
+	viper.SetDefault("addressing.failover.failureThreshold", constant.DefaultFailoverFailureThreshold)
+	viper.SetDefault("addressing.failover.recoveryMilliseconds", constant.DefaultFailoverRecoveryMilliseconds)
+	viper.SetDefault("addressing.failover.suListRefreshMilliseconds", constant.DefaultFailoverSuListRefreshMilliseconds)
 
 	viper.SetDefault("apm.rootPath", "/data/logs/")
 	viper.SetDefault("apm.version", "v2")
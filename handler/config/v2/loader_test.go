@@ -0,0 +1,63 @@
+package v2
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/handler/config"
+	"git.multiverse.io/eventkit/kit/handler/router"
+	"git.multiverse.io/eventkit/kit/sed/callback"
+)
+
+func loadConfig(t *testing.T, content string) *config.ServiceConfigs {
+	filePath := filepath.Join(t.TempDir(), "service.toml")
+	assert.True(t, nil == os.WriteFile(filePath, []byte(content), 0644))
+	cfg, err := (&Loader{}).LoadConfig(filePath)
+	assert.True(t, nil == err)
+
+	return cfg
+}
+
+func TestLoadConfigWithConcurrencyTopicTypes(t *testing.T) {
+	cfg := loadConfig(t, `
+[concurrency]
+maxConcurrency = 10
+[concurrency.topicTypes.TRN]
+maxConcurrency = 2
+[concurrency.topicTypes."*"]
+maxQueueSize = 10
+`)
+	options := &callback.Options{}
+	for _, o := range cfg.GenCallbackOptions() {
+		o(options)
+	}
+
+	// the topic types lowercased by the loader are restored
+	assert.Equal(t, 10, options.MaxConcurrency)
+	assert.Equal(t, 2, options.ConcurrencyLimits["TRN"].MaxConcurrency)
+	assert.Equal(t, 10, options.ConcurrencyLimits[callback.AnyTopicType].MaxQueueSize)
+	_, ok := options.ConcurrencyLimits["trn"]
+	assert.False(t, ok)
+}
+
+func TestLoadConfigWithRouteSwitches(t *testing.T) {
+	cfg := loadConfig(t, `
+[service.routes.ORDER]
+mode = "Disabled"
+[service.routes.refund]
+route = "REFUND.V1"
+mode = "maintenance"
+`)
+	handlerRouter := &router.HandlerRouter{}
+	changes, rejected := handlerRouter.SetRouteSwitches(cfg.Service.Routes)
+	assert.Equal(t, 2, len(changes))
+	assert.Equal(t, 0, len(rejected))
+
+	// the keys lowercased or split by the loader are matched with the event IDs
+	assert.Equal(t, constant.RouteDisabledError, handlerRouter.CheckRouteSwitch(&router.Options{EventExpression: "ORDER"}, "ORDER").ErrorCode)
+	assert.Equal(t, constant.RouteUnderMaintenanceError, handlerRouter.CheckRouteSwitch(&router.Options{EventExpression: "REFUND."}, "REFUND.V1").ErrorCode)
+	assert.True(t, nil == handlerRouter.CheckRouteSwitch(&router.Options{EventExpression: "REFUND."}, "REFUND.V2"))
+}
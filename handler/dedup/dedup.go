@@ -0,0 +1,169 @@
+// Package dedup implements the consumer side deduplication for the at-least-once delivered messages.
+// The message ID is reserved in the dedup store before the handler is executed,
+// committed with the TTL only when the handler succeeds and released when the handler fails,
+// so that the failed messages can be redelivered and the succeeded messages are skipped.
+package dedup
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/msg"
+	"git.multiverse.io/eventkit/kit/constant"
+)
+
+// State is the state of the message ID in the dedup store
+type State int
+
+const (
+	// StateAbsent means the message ID is absent(or expired) and it's reserved by the caller now
+	StateAbsent State = iota
+	// StateProcessing means the message is being processed by another consumer
+	StateProcessing
+	// StateProcessed means the message has been processed successfully
+	StateProcessed
+)
+
+const (
+	valueProcessing = "PROCESSING"
+	valueProcessed  = "PROCESSED"
+)
+
+// Store is the storage of the message IDs
+type Store interface {
+	// Reserve marks the key as processing for the TTL if the key is absent or expired, returns the state before reserving
+	Reserve(ctx context.Context, key string, ttl time.Duration) (State, *errors.Error)
+	// Commit marks the key as processed for the TTL
+	Commit(ctx context.Context, key string, ttl time.Duration) *errors.Error
+	// Release deletes the processing key so that the message can be redelivered
+	Release(ctx context.Context, key string) *errors.Error
+}
+
+// Options defines the options of the deduplication
+type Options struct {
+	Store Store
+	// Header is the request header that contains the message ID, defaults to constant.MessageDedupID,
+	// the msg.Message.ID is used if the header is absent.
+	Header string
+	// TTL is the time that the processed message IDs are kept
+	TTL time.Duration
+	// ProcessingTTL is the max processing time of the message, the reservation expires after that
+	ProcessingTTL time.Duration
+	// KeyPrefix is the prefix of the key in the dedup store
+	KeyPrefix string
+}
+
+// Option sets the options of the deduplication
+type Option func(*Options)
+
+// WithHeader sets the request header that contains the message ID
+func WithHeader(header string) Option {
+	return func(o *Options) {
+		o.Header = header
+	}
+}
+
+// WithTTL sets the time that the processed message IDs are kept
+func WithTTL(ttl time.Duration) Option {
+	return func(o *Options) {
+		o.TTL = ttl
+	}
+}
+
+// WithProcessingTTL sets the max processing time of the message
+func WithProcessingTTL(processingTTL time.Duration) Option {
+	return func(o *Options) {
+		o.ProcessingTTL = processingTTL
+	}
+}
+
+// WithKeyPrefix sets the prefix of the key in the dedup store
+func WithKeyPrefix(keyPrefix string) Option {
+	return func(o *Options) {
+		o.KeyPrefix = keyPrefix
+	}
+}
+
+// NewOptions creates the options of the deduplication with the store
+func NewOptions(store Store, opts ...Option) *Options {
+	options := &Options{
+		Store:         store,
+		Header:        constant.MessageDedupID,
+		TTL:           24 * time.Hour,
+		ProcessingTTL: time.Minute,
+		KeyPrefix:     "DEDUP.",
+	}
+	for _, o := range opts {
+		o(options)
+	}
+
+	return options
+}
+
+// KeyOf returns the key of the message in the dedup store, returns empty if the message has no message ID
+func (o *Options) KeyOf(request *msg.Message) string {
+	if nil == request {
+		return ""
+	}
+	messageID := ""
+	if "" != o.Header {
+		messageID = request.GetAppPropertySilence(o.Header)
+	}
+	if "" == messageID && 0 != request.ID {
+		messageID = strconv.FormatUint(request.ID, 10)
+	}
+	if "" == messageID {
+		return ""
+	}
+
+	return o.KeyPrefix + request.GetMsgTopicId() + "." + messageID
+}
+
+// Reserve reserves the message ID before the handler is executed,
+// returns the key to commit or release, duplicated is true if the message has been processed,
+// returns the DuplicateMessageInFlightError if the message is being processed so that the message can be redelivered later.
+func (o *Options) Reserve(ctx context.Context, request *msg.Message) (key string, duplicated bool, err *errors.Error) {
+	key = o.KeyOf(request)
+	if "" == key {
+		return "", false, nil
+	}
+
+	state, err := o.Store.Reserve(ctx, key, o.ProcessingTTL)
+	if nil != err {
+		return "", false, err
+	}
+	switch state {
+	case StateProcessed:
+		return key, true, nil
+	case StateProcessing:
+		return key, false, errors.Errorf(constant.DuplicateMessageInFlightError, "The message[%s] is being processed", key)
+	default:
+		return key, false, nil
+	}
+}
+
+// Finish commits the key if the handler succeeded, otherwise releases the key
+func (o *Options) Finish(ctx context.Context, key string, succeeded bool) *errors.Error {
+	if "" == key {
+		return nil
+	}
+	if succeeded {
+		return o.Store.Commit(ctx, key, o.TTL)
+	}
+
+	return o.Store.Release(ctx, key)
+}
+
+// DuplicateResponse returns the response of the duplicate message, it's an empty successful response
+// with the header constant.DuplicateMessage marked as "1".
+func DuplicateResponse(request *msg.Message) *msg.Message {
+	response := &msg.Message{
+		ID:          request.ID,
+		SessionName: request.SessionName,
+	}
+	response.SetAppProperty(constant.DuplicateMessage, constant.Enable)
+
+	return response
+}
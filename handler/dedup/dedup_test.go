@@ -0,0 +1,66 @@
+package dedup
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/msg"
+	"git.multiverse.io/eventkit/kit/constant"
+)
+
+func TestOptions_KeyOf(t *testing.T) {
+	options := NewOptions(NewMemoryStore(), WithKeyPrefix("P."))
+	message := &msg.Message{TopicAttribute: map[string]string{constant.TopicID: "T1"}}
+	assert.Equal(t, "", options.KeyOf(message))
+
+	message.ID = 10
+	assert.Equal(t, "P.T1.10", options.KeyOf(message))
+
+	message.SetAppProperty(constant.MessageDedupID, "d1")
+	assert.Equal(t, "P.T1.d1", options.KeyOf(message))
+
+	options = NewOptions(NewMemoryStore(), WithHeader("bizNo"), WithKeyPrefix("P."))
+	message.SetAppProperty("bizNo", "b1")
+	assert.Equal(t, "P.T1.b1", options.KeyOf(message))
+}
+
+func TestOptions_ReserveAndFinish(t *testing.T) {
+	ctx := context.Background()
+	options := NewOptions(NewMemoryStore(), WithProcessingTTL(50*time.Millisecond), WithTTL(time.Minute))
+	message := &msg.Message{ID: 1, TopicAttribute: map[string]string{constant.TopicID: "T1"}}
+
+	key, duplicated, err := options.Reserve(ctx, message)
+	assert.True(t, nil == err)
+	assert.False(t, duplicated)
+
+	// being processed
+	_, _, err = options.Reserve(ctx, message)
+	assert.NotNil(t, err)
+	assert.Equal(t, constant.DuplicateMessageInFlightError, err.ErrorCode)
+
+	// released when failed
+	assert.True(t, nil == options.Finish(ctx, key, false))
+	key, duplicated, err = options.Reserve(ctx, message)
+	assert.True(t, nil == err)
+	assert.False(t, duplicated)
+
+	// committed when succeeded
+	assert.True(t, nil == options.Finish(ctx, key, true))
+	_, duplicated, err = options.Reserve(ctx, message)
+	assert.True(t, nil == err)
+	assert.True(t, duplicated)
+
+	// the reservation expires after the processing TTL
+	message.ID = 2
+	_, _, err = options.Reserve(ctx, message)
+	assert.True(t, nil == err)
+	time.Sleep(60 * time.Millisecond)
+	_, duplicated, err = options.Reserve(ctx, message)
+	assert.True(t, nil == err)
+	assert.False(t, duplicated)
+
+	response := DuplicateResponse(message)
+	assert.Equal(t, constant.Enable, response.GetAppPropertySilence(constant.DuplicateMessage))
+}
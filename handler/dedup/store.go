@@ -0,0 +1,249 @@
+package dedup
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/constant"
+	redis2 "github.com/go-redis/redis/v8"
+	"github.com/xormplus/xorm"
+)
+
+// maxReserveAttempts is the max attempts of reserving the key that expired between SETNX and GET
+const maxReserveAttempts = 3
+
+// releaseScript deletes the key only if it's still processing, the processed key committed by others is never deleted
+var releaseScript = redis2.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type redisStore struct {
+	client redis2.UniversalClient
+}
+
+// NewRedisStore creates a dedup store with the redis client(e.g. cache/v2 GetRedisClient)
+func NewRedisStore(client redis2.UniversalClient) Store {
+	return &redisStore{client: client}
+}
+
+func (s *redisStore) Reserve(ctx context.Context, key string, ttl time.Duration) (State, *errors.Error) {
+	for i := 0; i < maxReserveAttempts; i++ {
+		reserved, err := s.client.SetNX(ctx, key, valueProcessing, ttl).Result()
+		if nil != err {
+			return StateAbsent, errors.Wrap(constant.SystemInternalError, err, 0)
+		}
+		if reserved {
+			return StateAbsent, nil
+		}
+
+		value, err := s.client.Get(ctx, key).Result()
+		if redis2.Nil == err {
+			// expired between SETNX and GET, try again
+			continue
+		}
+		if nil != err {
+			return StateAbsent, errors.Wrap(constant.SystemInternalError, err, 0)
+		}
+		if valueProcessed == value {
+			return StateProcessed, nil
+		}
+
+		return StateProcessing, nil
+	}
+
+	return StateAbsent, errors.Errorf(constant.SystemInternalError, "Failed to reserve the message[%s] after %d attempts", key, maxReserveAttempts)
+}
+
+func (s *redisStore) Commit(ctx context.Context, key string, ttl time.Duration) *errors.Error {
+	if err := s.client.Set(ctx, key, valueProcessed, ttl).Err(); nil != err {
+		return errors.Wrap(constant.SystemInternalError, err, 0)
+	}
+
+	return nil
+}
+
+func (s *redisStore) Release(ctx context.Context, key string) *errors.Error {
+	if err := releaseScript.Run(ctx, s.client, []string{key}, valueProcessing).Err(); nil != err && redis2.Nil != err {
+		return errors.Wrap(constant.SystemInternalError, err, 0)
+	}
+
+	return nil
+}
+
+// TableName is the name of the dedup table of the SQL store
+const TableName = "eventkit_dedup"
+
+// Record is the row of the dedup table
+type Record struct {
+	MessageKey string `xorm:"pk VARCHAR(255) 'message_key'"`
+	Status     string `xorm:"VARCHAR(16) 'status'"`
+	// ExpireAt is the expiration time in milliseconds
+	ExpireAt int64 `xorm:"BIGINT index 'expire_at'"`
+}
+
+// TableName returns the table name of the record for XORM
+func (r *Record) TableName() string {
+	return TableName
+}
+
+type xormStore struct {
+	engine *xorm.Engine
+}
+
+// NewXormStore creates a dedup store with the XORM engine(e.g. db.GetXormEngine),
+// the expired rows are reused by the following reservations, call Purge to delete them periodically.
+func NewXormStore(engine *xorm.Engine) Store {
+	return &xormStore{engine: engine}
+}
+
+// CreateTableWithXorm creates or syncs the dedup table with the XORM engine
+func CreateTableWithXorm(engine *xorm.Engine) *errors.Error {
+	if err := engine.Sync2(new(Record)); nil != err {
+		return errors.Wrap(constant.SystemInternalError, err, 0)
+	}
+
+	return nil
+}
+
+// PurgeWithXorm deletes the expired rows of the dedup table
+func PurgeWithXorm(ctx context.Context, engine *xorm.Engine) (int64, *errors.Error) {
+	deleted, err := engine.Context(ctx).Where("expire_at < ?", nowMilliseconds()).Delete(new(Record))
+	if nil != err {
+		return 0, errors.Wrap(constant.SystemInternalError, err, 0)
+	}
+
+	return deleted, nil
+}
+
+func nowMilliseconds() int64 {
+	return time.Now().UnixNano() / int64(time.Millisecond)
+}
+
+func expireAtOf(ttl time.Duration) int64 {
+	return nowMilliseconds() + int64(ttl/time.Millisecond)
+}
+
+func (s *xormStore) Reserve(ctx context.Context, key string, ttl time.Duration) (State, *errors.Error) {
+	record := &Record{MessageKey: key, Status: valueProcessing, ExpireAt: expireAtOf(ttl)}
+	if _, err := s.engine.Context(ctx).Insert(record); nil == err {
+		return StateAbsent, nil
+	}
+
+	// the key exists(or the insertion failed for other reasons), check the existing row
+	existing := &Record{}
+	found, err := s.engine.Context(ctx).ID(key).Get(existing)
+	if nil != err {
+		return StateAbsent, errors.Wrap(constant.SystemInternalError, err, 0)
+	}
+	if !found {
+		return StateAbsent, errors.Errorf(constant.SystemInternalError, "Failed to reserve the message[%s] in dedup table", key)
+	}
+	if existing.ExpireAt >= nowMilliseconds() {
+		if valueProcessed == existing.Status {
+			return StateProcessed, nil
+		}
+		return StateProcessing, nil
+	}
+
+	// take over the expired row, the condition of expire_at prevents the concurrent reservations
+	affected, err := s.engine.Context(ctx).Where("message_key = ? AND expire_at = ?", key, existing.ExpireAt).
+		Cols("status", "expire_at").Update(record)
+	if nil != err {
+		return StateAbsent, errors.Wrap(constant.SystemInternalError, err, 0)
+	}
+	if 0 == affected {
+		return StateProcessing, nil
+	}
+
+	return StateAbsent, nil
+}
+
+func (s *xormStore) Commit(ctx context.Context, key string, ttl time.Duration) *errors.Error {
+	record := &Record{Status: valueProcessed, ExpireAt: expireAtOf(ttl)}
+	if _, err := s.engine.Context(ctx).ID(key).Cols("status", "expire_at").Update(record); nil != err {
+		return errors.Wrap(constant.SystemInternalError, err, 0)
+	}
+
+	return nil
+}
+
+func (s *xormStore) Release(ctx context.Context, key string) *errors.Error {
+	if _, err := s.engine.Context(ctx).Where("message_key = ? AND status = ?", key, valueProcessing).Delete(new(Record)); nil != err {
+		return errors.Wrap(constant.SystemInternalError, err, 0)
+	}
+
+	return nil
+}
+
+type memoryEntry struct {
+	value    string
+	expireAt time.Time
+}
+
+// MemoryStore is an in-memory dedup store, it's only effective in single instance
+type MemoryStore struct {
+	lock    sync.Mutex
+	entries map[string]*memoryEntry
+}
+
+// NewMemoryStore creates an in-memory dedup store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*memoryEntry)}
+}
+
+// Reserve marks the key as processing for the TTL if the key is absent or expired
+func (s *MemoryStore) Reserve(ctx context.Context, key string, ttl time.Duration) (State, *errors.Error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	if entry, ok := s.entries[key]; ok && now.Before(entry.expireAt) {
+		if valueProcessed == entry.value {
+			return StateProcessed, nil
+		}
+		return StateProcessing, nil
+	}
+	s.entries[key] = &memoryEntry{value: valueProcessing, expireAt: now.Add(ttl)}
+
+	return StateAbsent, nil
+}
+
+// Commit marks the key as processed for the TTL
+func (s *MemoryStore) Commit(ctx context.Context, key string, ttl time.Duration) *errors.Error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.entries[key] = &memoryEntry{value: valueProcessed, expireAt: time.Now().Add(ttl)}
+
+	return nil
+}
+
+// Release deletes the processing key
+func (s *MemoryStore) Release(ctx context.Context, key string) *errors.Error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if entry, ok := s.entries[key]; ok && valueProcessing == entry.value {
+		delete(s.entries, key)
+	}
+
+	return nil
+}
+
+// Purge deletes the expired keys
+func (s *MemoryStore) Purge() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	for key, entry := range s.entries {
+		if !now.Before(entry.expireAt) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"reflect"
@@ -17,13 +18,18 @@ import (
 	"git.multiverse.io/eventkit/kit/client/mesh/wrapper/logging"
 	"git.multiverse.io/eventkit/kit/client/mesh/wrapper/trace"
 	"git.multiverse.io/eventkit/kit/codec"
+	"git.multiverse.io/eventkit/kit/common/compressor"
 	"git.multiverse.io/eventkit/kit/common/errors"
 	"git.multiverse.io/eventkit/kit/common/msg"
+	"git.multiverse.io/eventkit/kit/common/shutdown"
 	"git.multiverse.io/eventkit/kit/common/util"
 	"git.multiverse.io/eventkit/kit/constant"
 	"git.multiverse.io/eventkit/kit/contexts"
+	"git.multiverse.io/eventkit/kit/gls"
 	"git.multiverse.io/eventkit/kit/handler/base"
+	"git.multiverse.io/eventkit/kit/handler/binding"
 	"git.multiverse.io/eventkit/kit/handler/config"
+	"git.multiverse.io/eventkit/kit/handler/dedup"
 	"git.multiverse.io/eventkit/kit/handler/remote"
 	"git.multiverse.io/eventkit/kit/handler/router"
 	"git.multiverse.io/eventkit/kit/handler/transaction/imports"
@@ -186,10 +192,16 @@ func (e *EventCallback) Init() error {
 		return err
 	}
 
+	if nil != e.serviceConfig {
+		e.applyRouteSwitches(e.serviceConfig.Routes)
+	}
+
 	if err := e.initCircuitBreakerIfNecessary(); nil != err {
 		return err
 	}
 
+	e.initAddressingFailover()
+
 	// watch service config channel
 	go func() {
 		defer func() {
@@ -230,6 +242,7 @@ func (e *EventCallback) Init() error {
 							e.responseAutoParseKeyMapping = c.Service.ResponseAutoParseKeyMapping
 						}
 
+						e.applyRouteSwitches(c.Service.Routes)
 						e.serviceConfig = &c.Service
 
 						if !c.Transaction.Equals(e.transactionConfig) {
@@ -290,6 +303,8 @@ func (e *EventCallback) Init() error {
 		}
 	}()
 
+	e.registerHealthComponents()
+
 	// register configuration on change hook function.
 	config.RegisterConfigOnChangeHookFunc("executor", rotateServiceConfigWhenConfigChanged, false)
 	return nil
@@ -363,6 +378,31 @@ func (e *EventCallback) initCircuitBreakerIfNecessary() error {
 	return nil
 }
 
+// initAddressingFailover sets the SU list provider of the addressing failover that queries GLS by QuerySuListUsingSUType,
+// and the alerter that publishes the failover alerts to the alert event ID if it's configured.
+func (e *EventCallback) initAddressingFailover() {
+	configs := config.GetConfigs()
+	if nil == configs || !configs.Addressing.Failover.Enable {
+		return
+	}
+	failover := configs.Addressing.Failover
+
+	isLocalCallCheckFunc := func(eventId string) bool {
+		return nil != e.handlerRouter && nil != e.handlerRouter.MatchHandler(eventId)
+	}
+	var downstreamServiceConfigs map[string]config.Downstream
+	if v, ok := e.extConfigs[constant.ExtConfigDownstreamService]; ok {
+		downstreamServiceConfigs = v.(map[string]config.Downstream)
+	}
+	remoteCall := remote.NewDefaultRemoteCall(e.client, e, isLocalCallCheckFunc, downstreamServiceConfigs)
+
+	addressing.SetSuListProvider(gls.NewSuListProvider(gls.NewOperator(remoteCall, failover.GlsSu)))
+	if "" != failover.AlertEventID {
+		log.Infosf("Addressing failover alerts are published to event[%s]", failover.AlertEventID)
+		addressing.SetFailoverAlerter(remote.NewFailoverAlerter(remoteCall, failover.AlertEventID))
+	}
+}
+
 func (e *EventCallback) enableCircuitBreakerIfNecessary() {
 	if !e.isEnabledCircuitBreakerMonitor {
 		hystrixStreamHandler := hystrix.NewStreamHandler()
@@ -380,8 +420,8 @@ func (e *EventCallback) enableCircuitBreakerIfNecessary() {
 
 func (e *EventCallback) enableHTTPRouterIfNecessary() error {
 	// Auto enable register if necessary
-	if nil != e.handlerRouter && len(e.handlerRouter.URLPathHandlers) > 0 {
-		httpEndpointController := func(ctx *fasthttp.RequestCtx) {
+	if nil != e.handlerRouter && (len(e.handlerRouter.URLPathHandlers) > 0 || "" != e.openAPIPath()) {
+		httpEndpointController := withShutdown(func(ctx *fasthttp.RequestCtx) {
 			topicAttributes := make(map[string]string)
 			appProps := make(map[string]string)
 			ctx.Request.Header.VisitAll(func(key, value []byte) {
@@ -393,13 +433,16 @@ func (e *EventCallback) enableHTTPRouterIfNecessary() error {
 				}
 			})
 
+			queryParams := make(map[string][]string)
 			ctx.QueryArgs().VisitAll(func(key, value []byte) {
 				appProps[string(key)] = string(value)
+				queryParams[string(key)] = append(queryParams[string(key)], string(value))
 			})
 
 			request := &msg.Message{
 				TopicAttribute: topicAttributes,
 				RequestURL:     string(ctx.URI().Path()),
+				QueryParams:    queryParams,
 				Body:           ctx.Request.Body(),
 			}
 			request.SetAppProps(appProps)
@@ -420,19 +463,21 @@ func (e *EventCallback) enableHTTPRouterIfNecessary() error {
 						ctx.Response.Header.Set(constant.AttributesPrefix+key, value)
 					}
 				}
+				ctx.SetStatusCode(e.httpStatusOf(response))
 				ctx.Write(response.Body)
 			}
-		}
+		}, shutdown.Default())
 
 		// enable http server, if necessary
 		endpointAddr := fmt.Sprintf("0.0.0.0:%d", e.opts.Port)
 		router := fasthttprouter.New()
-		server := &fasthttp.Server{
-			Handler:                       router.Handler,
-			MaxRequestBodySize:            1024 * 1024 * 1024,
-			DisableHeaderNamesNormalizing: true,
+		serverConfig := e.httpServerConfig()
+		paths := make([]string, 0, len(e.handlerRouter.URLPathHandlers))
+		for path := range e.handlerRouter.URLPathHandlers {
+			paths = append(paths, path)
 		}
-		log.Infosf(`Configured for Endpoint. => address: %s => port:%d`, "0.0.0.0", e.opts.Port)
+		server := newHTTPServer(router.Handler, serverConfig, paths)
+		log.Infosf(`Configured for Endpoint. => address: %s => port:%d => TLS:%v`, "0.0.0.0", e.opts.Port, isTLSEnabled(serverConfig))
 		for path, opts := range e.handlerRouter.URLPathHandlers {
 			switch opts.HandlerOptions.HTTPMethod {
 			case constant.HTTPMethodHead:
@@ -472,8 +517,35 @@ func (e *EventCallback) enableHTTPRouterIfNecessary() error {
 
 		}
 
+		// serve the OpenAPI document generated at startup
+		if path := e.openAPIPath(); "" != path {
+			if _, ok := e.handlerRouter.URLPathHandlers[path]; ok {
+				log.Warnsf("The OpenAPI document is not served, the URL path[%s] has been registered by handler", path)
+			} else if document, err := json.Marshal(e.OpenAPIDocument()); nil != err {
+				log.Errorsf("failed to generate the OpenAPI document, error=%++v", err)
+			} else {
+				log.Infosf("*                         => GET %s (OpenAPI document)", path)
+				router.GET(path, func(ctx *fasthttp.RequestCtx) {
+					ctx.SetContentType(constant.DefaultContentTypeJSON)
+					ctx.Write(document)
+				})
+			}
+		}
+
+		// the HTTP server is closed after the in-flight requests are drained
+		shutdown.RegisterCloser("HTTP endpoint", func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), httpServerShutdownTimeout)
+			defer cancel()
+			return server.ShutdownWithContext(ctx)
+		})
 		go func(srv *fasthttp.Server, addr string) {
-			if err := srv.ListenAndServe(addr); err != nil {
+			var err error
+			if isTLSEnabled(serverConfig) {
+				err = srv.ListenAndServeTLS(addr, serverConfig.TLS.CertFile, serverConfig.TLS.KeyFile)
+			} else {
+				err = srv.ListenAndServe(addr)
+			}
+			if err != nil {
 				log.Errorsf("failed to Start: start http server error:%++v", err.Error())
 				panic(err)
 			}
@@ -503,7 +575,7 @@ func (e EventCallback) judgeUserLangWithDefaultValue(request *msg.Message) strin
 }
 
 // Handle executes the main process of each callback, matches the corresponding handler according to the eventID,
-// and decodes the message and executes the corresponding method through reflection
+// and decodes the message and executes the corresponding method through reflection or invokes the handler function
 func (e *EventCallback) Handle(parentCtx context.Context, request *msg.Message) (response *msg.Message, err error) {
 	var gerr error
 	var ctx context.Context
@@ -529,6 +601,17 @@ func (e *EventCallback) Handle(parentCtx context.Context, request *msg.Message)
 	}
 	remoteCall := remote.NewDefaultRemoteCall(e.client, e, isLocalCallCheckFunc, downstreamServiceConfigs)
 
+	// compress the response payload if necessary, it's executed after all the other deferred functions.
+	// The responses of the HTTP URL path requests are never compressed, the algorithms are not HTTP content codings.
+	defer func() {
+		if nil != response && nil != e.serviceConfig && (nil == request || "" == request.RequestURL) {
+			compression := e.serviceConfig.ResponseCompression
+			if cerr := response.CompressBody(&compressor.Options{Algorithm: compression.Algorithm, Threshold: compression.Threshold}); nil != cerr {
+				log.Errorsf("failed to compress the response, error=%s", errors.ErrorToString(cerr))
+			}
+		}
+	}()
+
 	lang := e.judgeUserLangWithDefaultValue(request)
 	defer func() {
 		if r := recover(); r != nil {
@@ -573,6 +656,14 @@ func (e *EventCallback) Handle(parentCtx context.Context, request *msg.Message)
 		}
 	}()
 
+	// decompress the request payload if necessary, the compression header of the HTTP URL path requests is untrusted
+	if nil != request && "" == request.RequestURL {
+		if derr := request.DecompressBody(); nil != derr {
+			ctx = parentCtx
+			return nil, derr
+		}
+	}
+
 	if nil != e.callbackHandleWrapper {
 		skip, tmpCtx, preRequest, preErr := e.callbackHandleWrapper.PreHandle(parentCtx, request)
 		if skip || nil != preErr {
@@ -721,17 +812,21 @@ func (e *EventCallback) Handle(parentCtx context.Context, request *msg.Message)
 	}(lang)
 
 	if "" != request.RequestURL {
-		hp = e.handlerRouter.MatchHandlerWithURLPath(request.RequestURL)
+		hp, request.PathParams = e.handlerRouter.MatchURLPath(request.RequestURL)
 		if nil == hp {
 			return nil, errors.Errorf(constant.CannotFoundHandlerWithURLError, "Service ID: %s - Cannot found handler with URL: %s", e.serviceConfig.ServiceID, request.RequestURL)
 		}
+		// the versions registered under the same event ID share the URL path
+		if "" != hp.HandlerOptions.Version {
+			hp = e.handlerRouter.MatchHandlerWithVersion(hp.EventExpression, request)
+		}
 	} else {
 		if !request.IsValidTopicType() {
 			return nil, errors.Errorf(constant.InvalidEventTypeError, "Service ID: %s - Invalid event type: %++v", e.serviceConfig.ServiceID, request)
 		}
 
 		eventID := request.GetMsgTopicId()
-		hp = e.handlerRouter.MatchHandler(eventID)
+		hp = e.handlerRouter.MatchHandlerWithVersion(eventID, request)
 		if nil == hp {
 			return nil, errors.Errorf(constant.CannotFoundHandlerWithEventIDError, "Service ID: %s - Cannot found handler with event id: %s", e.serviceConfig.ServiceID, eventID)
 		}
@@ -744,6 +839,15 @@ func (e *EventCallback) Handle(parentCtx context.Context, request *msg.Message)
 		customErrorWrapperFn = hp.HandlerOptions.CustomErrorWrapperFn
 	}
 
+	// the disabled routes are short-circuited before the interceptors
+	switchEventID := ""
+	if "" == request.RequestURL {
+		switchEventID = request.GetMsgTopicId()
+	}
+	if serr := e.handlerRouter.CheckRouteSwitch(hp, switchEventID); nil != serr {
+		return nil, serr
+	}
+
 	// Do preHandle of interceptors
 	for i := 0; i < len(hp.HandlerOptions.Interceptors); i++ {
 		interceptor := hp.HandlerOptions.Interceptors[i]
@@ -758,7 +862,63 @@ func (e *EventCallback) Handle(parentCtx context.Context, request *msg.Message)
 		indexOfInterceptorsExecuted++
 	}
 
-	decoder := hp.HandlerOptions.Codec.Decoder()
+	// abandoned is closed when the handler canceled at the deadline returns
+	var abandoned <-chan struct{}
+	if deduplication := hp.HandlerOptions.Deduplication; nil != deduplication {
+		dedupKey, duplicated, derr := deduplication.Reserve(ctx, request)
+		if nil != derr {
+			return nil, derr
+		}
+		if duplicated {
+			log.Infof(ctx, "Skip the duplicate message[%s]", dedupKey)
+			return dedup.DuplicateResponse(request), nil
+		}
+		defer func() {
+			r := recover()
+			if nil != abandoned {
+				// the message is kept reserved until the abandoned handler returns, the redelivery cannot run it concurrently
+				done := shutdown.Track()
+				go func(abandoned <-chan struct{}) {
+					defer done()
+					<-abandoned
+					if ferr := deduplication.Finish(ctx, dedupKey, false); nil != ferr {
+						log.Errorf(ctx, "Failed to finish the deduplication of message[%s], error:%s", dedupKey, ferr.Error())
+					}
+				}(abandoned)
+			} else if ferr := deduplication.Finish(ctx, dedupKey, nil == r && nil == err); nil != ferr {
+				log.Errorf(ctx, "Failed to finish the deduplication of message[%s], error:%s", dedupKey, ferr.Error())
+			}
+			if nil != r {
+				panic(r)
+			}
+		}()
+	}
+
+	// the handler is canceled when the timeout propagated by the upstream runs out
+	deadline, ok := e.handlerDeadline(hp, request, st)
+	if !ok {
+		ins, response, err = e.invokeHandler(ctx, hp, request, remoteCall, handlerContexts.Lang)
+		return response, err
+	}
+
+	response, abandoned, err = e.invokeHandlerWithDeadline(ctx, deadline, hp, request, remoteCall, handlerContexts.Lang, &ins)
+	return response, err
+}
+
+// invokeHandler decodes the request, invokes the handler and encodes the response, returns the handler instance
+// whose response header is replied even if the handler fails
+func (e *EventCallback) invokeHandler(ctx context.Context, hp *router.Options, request *msg.Message, remoteCall remote.CallInc, lang string) (base.HandlerInterface, *msg.Message, error) {
+	if nil != hp.HandlerOptions.Func {
+		h := &base.Handler{}
+		ctx = base.NewContext(ctx, h)
+		e.initHandlerInstance(ctx, h, hp, request, remoteCall, lang)
+		response, err := e.invokeFuncHandler(ctx, hp, request, h)
+		return h, response, err
+	}
+
+	var gerr error
+	var ins base.HandlerInterface
+
 	lengthOfInParams := len(hp.HandlerOptions.HandlerMethodInParams)
 	parameterInValues := make([]reflect.Value, lengthOfInParams)
 
@@ -771,8 +931,8 @@ func (e *EventCallback) Handle(parentCtx context.Context, request *msg.Message)
 			pValue = reflect.New(pType)
 		}
 
-		if gerr = decoder.Decode(request.Body, pValue.Interface()); gerr != nil {
-			return nil, errors.New(constant.UpstreamServiceMessageDecodeError, gerr)
+		if gerr = decodeRequest(hp, request, pValue.Interface()); nil != gerr {
+			return ins, nil, gerr
 		}
 
 		if pType.Kind() == reflect.Ptr {
@@ -790,21 +950,8 @@ func (e *EventCallback) Handle(parentCtx context.Context, request *msg.Message)
 	}
 
 	ins = instance.Interface().(base.HandlerInterface)
-	ins.SetRemoteCall(remoteCall)
-	ins.SetLang(handlerContexts.Lang)
-	ins.SetContext(ctx)
-	ins.SetBody(request.Body)
-	ins.SetExtConfigs(e.extConfigs)
-
-	if nil != hp.HandlerOptions.CustomValidationOptions {
-		ins.SetCombineErrors(hp.HandlerOptions.CustomValidationOptions.CombineErrors)
-		ins.SetCustomValidationRegisterFunctions(hp.HandlerOptions.CustomValidationOptions.CustomValidationRegisterFunctions)
-	}
+	e.initHandlerInstance(ctx, ins, hp, request, remoteCall, lang)
 
-	ins.SetTopicAttributes(request.TopicAttribute)
-	ins.SetRequestHeader(request.CloneAppProps())
-
-	var responseBody []byte
 	var returnValues []reflect.Value
 
 	if hp.HandlerOptions.EnableValidation && 0 != lengthOfInParams {
@@ -813,7 +960,7 @@ func (e *EventCallback) Handle(parentCtx context.Context, request *msg.Message)
 		validationReturnValues := validationMethod.Call(parameterInValues)
 		validationReturnValue := validationReturnValues[0]
 		if !valueIsNil(validationReturnValue) {
-			return nil, validationReturnValue.Interface().(error)
+			return ins, nil, validationReturnValue.Interface().(error)
 		}
 	}
 
@@ -823,7 +970,7 @@ func (e *EventCallback) Handle(parentCtx context.Context, request *msg.Message)
 		preHandleReturnValues := preHandleMethod.Call(parameterInValues)
 		preHandleReturnValue := preHandleReturnValues[0]
 		if !valueIsNil(preHandleReturnValue) {
-			return nil, preHandleReturnValue.Interface().(error)
+			return ins, nil, preHandleReturnValue.Interface().(error)
 		}
 	}
 
@@ -836,7 +983,7 @@ func (e *EventCallback) Handle(parentCtx context.Context, request *msg.Message)
 		proxy, r := proxy.NewTransactionProxy(transactionContext, instance, hp.Compensable)
 		if r != nil {
 			gerr = errors.Errorf(constant.NewTransactionProxyError, "Service ID: %s - new transaction proxy error: %v", e.serviceConfig.ServiceID, r)
-			return nil, gerr
+			return ins, nil, gerr
 		}
 
 		convertFunc := func(inputValues []reflect.Value) []interface{} {
@@ -853,18 +1000,107 @@ func (e *EventCallback) Handle(parentCtx context.Context, request *msg.Message)
 	lastReturnValue := returnValues[len(returnValues)-1]
 	if !valueIsNil(lastReturnValue) {
 		gerr = lastReturnValue.Interface().(error)
-		return nil, gerr
+		return ins, nil, gerr
 	} else if len(returnValues) == 1 {
-		responseBody = nil
-	} else {
-		encoder := hp.HandlerOptions.Codec.Encoder()
+		return ins, newResponse(request, ins, nil, ""), nil
+	}
+
+	responseBody, contentType, gerr := e.encodeResponse(hp, request, returnValues[0].Interface())
+	if nil != gerr {
+		return ins, nil, gerr
+	}
+
+	return ins, newResponse(request, ins, responseBody, contentType), nil
+}
+
+// decodeRequest decodes the body of the request and binds the URL path parameters and the query arguments into the target
+func decodeRequest(hp *router.Options, request *msg.Message, target interface{}) error {
+	// the HTTP requests(e.g. GET) may carry the parameters only in the URL path and query arguments
+	if "" == request.RequestURL || len(request.Body) > 0 {
+		c, _ := requestCodec(hp, request)
+		if err := c.Decoder().Decode(request.Body, target); nil != err {
+			return errors.New(constant.UpstreamServiceMessageDecodeError, err)
+		}
+	}
+	if berr := binding.Bind(target, request.PathParams, request.QueryParams); nil != berr {
+		return berr
+	}
+
+	return nil
+}
+
+// initHandlerInstance sets the context, the request and the configs into the handler instance
+func (e *EventCallback) initHandlerInstance(ctx context.Context, ins base.HandlerInterface, hp *router.Options, request *msg.Message, remoteCall remote.CallInc, lang string) {
+	ins.SetRemoteCall(remoteCall)
+	ins.SetLang(lang)
+	ins.SetContext(ctx)
+	ins.SetBody(request.Body)
+	ins.SetExtConfigs(e.extConfigs)
+
+	if nil != hp.HandlerOptions.CustomValidationOptions {
+		ins.SetCombineErrors(hp.HandlerOptions.CustomValidationOptions.CombineErrors)
+		ins.SetCustomValidationRegisterFunctions(hp.HandlerOptions.CustomValidationOptions.CustomValidationRegisterFunctions)
+	}
+
+	ins.SetTopicAttributes(request.TopicAttribute)
+	ins.SetRequestHeader(request.CloneAppProps())
+}
 
-		if responseBody, gerr = encoder.Encode(returnValues[0].Interface()); nil != gerr {
-			return nil, errors.New(constant.UpstreamServiceMessageEncodeError, gerr)
+// invokeFuncHandler invokes the context-first handler function without reflection,
+// the handler carried by the context provides the request header, the topic attributes and the remote call.
+func (e *EventCallback) invokeFuncHandler(ctx context.Context, hp *router.Options, request *msg.Message, h *base.Handler) (*msg.Message, error) {
+	fn := hp.HandlerOptions.Func
+	req := fn.NewRequest()
+	if err := decodeRequest(hp, request, req); nil != err {
+		return nil, err
+	}
+	if hp.HandlerOptions.EnableValidation {
+		if err := h.Validation(req); nil != err {
+			return nil, err
 		}
 	}
 
-	response = &msg.Message{
+	result, err := fn.Invoke(ctx, req)
+	if nil != err {
+		return nil, err
+	}
+	responseBody, contentType, err := e.encodeResponse(hp, request, result)
+	if nil != err {
+		return nil, err
+	}
+
+	return newResponse(request, h, responseBody, contentType), nil
+}
+
+// encodeResponse encodes the response by the codec of the content type of the request,
+// the response of the HTTP request is encoded by the negotiated codec only if the `Accept` header excludes the media type
+// of the codec, the codecs without the declared content type are regarded as JSON.
+func (e *EventCallback) encodeResponse(hp *router.Options, request *msg.Message, value interface{}) ([]byte, string, error) {
+	c, contentType := requestCodec(hp, request)
+	if "" != request.RequestURL {
+		mediaType := contentType
+		if "" == mediaType {
+			mediaType = constant.DefaultContentTypeJSON
+		}
+		if accept := headerValue(request, "Accept"); !acceptsMediaType(accept, mediaType) {
+			for _, nc := range negotiateCodecs(accept) {
+				if body, nerr := nc.codec.Encoder().Encode(value); nil == nerr {
+					return body, nc.mediaType, nil
+				}
+			}
+		}
+	}
+	responseBody, err := c.Encoder().Encode(value)
+	if nil != err {
+		return nil, "", errors.New(constant.UpstreamServiceMessageEncodeError, err)
+	}
+
+	return responseBody, contentType, nil
+}
+
+// newResponse creates the response with the response header of the handler instance
+func newResponse(request *msg.Message, ins base.HandlerInterface, responseBody []byte, contentType string) *msg.Message {
+	response := &msg.Message{
 		ID:          request.ID,
 		SessionName: request.SessionName,
 		Body:        responseBody,
@@ -877,8 +1113,17 @@ func (e *EventCallback) Handle(parentCtx context.Context, request *msg.Message)
 
 		responseHeader[constant.DiscardResponse] = "1"
 	}
+	if "" != contentType {
+		if nil == responseHeader {
+			responseHeader = make(map[string]string)
+		}
+		if _, ok := responseHeader[constant.HTTPContentTypeKey]; !ok {
+			responseHeader[constant.HTTPContentTypeKey] = contentType
+		}
+	}
 	response.SetAppProps(responseHeader)
-	return response, nil
+
+	return response
 }
 
 func valueIsNil(value reflect.Value) bool {
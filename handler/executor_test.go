@@ -4,11 +4,19 @@ import (
 	"context"
 	"fmt"
 	"git.multiverse.io/eventkit/kit/codec"
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/compressor"
 	"git.multiverse.io/eventkit/kit/common/errors"
 	"git.multiverse.io/eventkit/kit/common/msg"
 	"git.multiverse.io/eventkit/kit/constant"
 	"git.multiverse.io/eventkit/kit/handler/base"
+	"git.multiverse.io/eventkit/kit/handler/config"
+	"git.multiverse.io/eventkit/kit/handler/dedup"
 	"git.multiverse.io/eventkit/kit/handler/router"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"net/http"
+	"strings"
 	"testing"
 	"time"
 )
@@ -322,3 +330,258 @@ func TestInvokeHandler(t *testing.T) {
 
 	t.Log(callbackExecutor.Handle(context.Background(), message))
 }
+
+var dedupHandlerInvokedTimes int
+
+type DedupHandler struct {
+	base.Handler
+}
+
+func (c *DedupHandler) Handle(request *Request) (*Response, *errors.Error) {
+	dedupHandlerInvokedTimes++
+	if "fail" == request.A {
+		return nil, errors.New("BIZ0001", "failed")
+	}
+
+	return &Response{B: request.A}, nil
+}
+
+func TestInvokeHandlerWithDeduplication(t *testing.T) {
+	callbackExecutor := NewCallbackExecutor()
+	routerRegister := &router.HandlerRouter{}
+	store := dedup.NewMemoryStore()
+	routerRegister.Router("DEDUP", &DedupHandler{},
+		router.Method("Handle"),
+		router.EnableDeduplication(store),
+	)
+	callbackExecutor.SetRouter(routerRegister)
+	callbackExecutor.serviceConfig = &config.Service{ServiceID: "test"}
+	topicAttribute, _ := BuildBussinessTopicAttributes("ORG001", "WKS1", "ENV1", "SU001", "V1", "DEDUP")
+	newMessage := func(id uint64, body string) *msg.Message {
+		return &msg.Message{
+			ID:             id,
+			TopicAttribute: topicAttribute,
+			Body:           []byte(body),
+		}
+	}
+
+	// the failed message is not committed and can be redelivered
+	response, _ := callbackExecutor.Handle(context.Background(), newMessage(1, `{"A":"fail"}`))
+	assert.Equal(t, "BIZ0001", response.GetAppPropertySilence(constant.ReturnErrorCode))
+	response, _ = callbackExecutor.Handle(context.Background(), newMessage(1, `{"A":"ok"}`))
+	assert.Equal(t, "", response.GetAppPropertySilence(constant.DuplicateMessage))
+	assert.True(t, strings.Contains(string(response.Body), `{"B":"ok"}`))
+	assert.Equal(t, 2, dedupHandlerInvokedTimes)
+
+	// the duplicate is skipped
+	response, _ = callbackExecutor.Handle(context.Background(), newMessage(1, `{"A":"ok"}`))
+	assert.Equal(t, constant.Enable, response.GetAppPropertySilence(constant.DuplicateMessage))
+	assert.Equal(t, 2, dedupHandlerInvokedTimes)
+
+	// the dedup ID in header takes precedence over the message ID
+	message := newMessage(1, `{"A":"ok"}`)
+	message.SetAppProperty(constant.MessageDedupID, "d1")
+	callbackExecutor.Handle(context.Background(), message)
+	assert.Equal(t, 3, dedupHandlerInvokedTimes)
+
+	// the message being processed is rejected with retryable error
+	_, _ = store.Reserve(context.Background(), "DEDUP.DEDUP.2", time.Minute)
+	response, _ = callbackExecutor.Handle(context.Background(), newMessage(2, `{"A":"ok"}`))
+	assert.Equal(t, constant.DuplicateMessageInFlightError, response.GetAppPropertySilence(constant.ReturnErrorCode))
+	assert.Equal(t, 3, dedupHandlerInvokedTimes)
+}
+
+func TestInvokeHandlerWithCompression(t *testing.T) {
+	callbackExecutor := NewCallbackExecutor()
+	routerRegister := &router.HandlerRouter{}
+	routerRegister.Router("COMPRESSION", &DedupHandler{},
+		router.Method("Handle"),
+	)
+	callbackExecutor.SetRouter(routerRegister)
+	callbackExecutor.serviceConfig = &config.Service{
+		ServiceID:           "test",
+		ResponseCompression: config.Compression{Algorithm: compressor.Gzip, Threshold: 1},
+	}
+	topicAttribute, _ := BuildBussinessTopicAttributes("ORG001", "WKS1", "ENV1", "SU001", "V1", "COMPRESSION")
+
+	// the compressed request is decompressed transparently
+	request := &msg.Message{TopicAttribute: topicAttribute, Body: []byte(`{"A":"ok"}`)}
+	assert.True(t, nil == request.CompressBody(&compressor.Options{Algorithm: compressor.Snappy, Threshold: 1}))
+	assert.Equal(t, compressor.Snappy, request.GetAppPropertySilence(constant.ContentEncoding))
+
+	response, err := callbackExecutor.Handle(context.Background(), request)
+	assert.True(t, nil == err)
+
+	// the response is compressed with the response compression of the service
+	assert.Equal(t, compressor.Gzip, response.GetAppPropertySilence(constant.ContentEncoding))
+	assert.True(t, nil == response.DecompressBody())
+	assert.True(t, strings.Contains(string(response.Body), `{"B":"ok"}`))
+
+	// the request that cannot be decompressed is rejected
+	request = &msg.Message{TopicAttribute: topicAttribute, Body: []byte(`{"A":"ok"}`)}
+	request.SetAppProperty(constant.ContentEncoding, compressor.Gzip)
+	response, _ = callbackExecutor.Handle(context.Background(), request)
+	assert.True(t, nil == response.DecompressBody())
+	assert.Equal(t, constant.CompressionError, response.GetAppPropertySilence(constant.ReturnErrorCode))
+
+	// the compression header of the HTTP URL path requests is ignored and the responses are not compressed
+	routerRegister.Router("COMPRESSION_HTTP", &DedupHandler{},
+		router.Method("Handle"),
+		router.HandlePost("/v1/compression"),
+	)
+	request = &msg.Message{RequestURL: "/v1/compression", Body: []byte(`{"A":"ok"}`)}
+	request.SetAppProperty(constant.ContentEncoding, compressor.Gzip)
+	response, err = callbackExecutor.Handle(context.Background(), request)
+	assert.True(t, nil == err)
+	assert.Equal(t, "", response.GetAppPropertySilence(constant.ContentEncoding))
+	assert.True(t, strings.Contains(string(response.Body), `{"B":"ok"}`))
+}
+
+func TestInvokeVersionedHandler(t *testing.T) {
+	callbackExecutor := NewCallbackExecutor()
+	routerRegister := &router.HandlerRouter{}
+	routerRegister.Router("VERSIONED_TOPIC", &SampleHandler2{}, router.Method("EventHandleMethod1"), router.WithVersion("V1"))
+	routerRegister.Router("VERSIONED_TOPIC", &SampleHandler2{}, router.Method("EventHandleMethod2"), router.WithVersion("V2"),
+		router.WithCanaryHeader("canary", "1"))
+	callbackExecutor.SetRouter(routerRegister)
+	callbackExecutor.serviceConfig = &config.Service{ServiceID: "test"}
+
+	expectations := map[string]string{"V1": "format to JSON", "V2": "format to XML", "V3": "format to JSON"}
+	for version, expected := range expectations {
+		topicAttribute, _ := BuildBussinessTopicAttributes("ORG001", "WKS1", "ENV1", "SU001", version, "VERSIONED_TOPIC")
+		response, err := callbackExecutor.Handle(context.Background(), &msg.Message{TopicAttribute: topicAttribute, Body: []byte(`{"A":"test1"}`)})
+		assert.True(t, nil == err)
+		assert.True(t, strings.Contains(string(response.Body), expected))
+	}
+
+	// the canary header
+	topicAttribute, _ := BuildBussinessTopicAttributes("ORG001", "WKS1", "ENV1", "SU001", "", "VERSIONED_TOPIC")
+	request := &msg.Message{TopicAttribute: topicAttribute, Body: []byte(`{"A":"test1"}`)}
+	request.SetAppProperty("canary", "1")
+	response, _ := callbackExecutor.Handle(context.Background(), request)
+	assert.True(t, strings.Contains(string(response.Body), "format to XML"))
+}
+
+func TestInvokeHandlerWithRouteSwitches(t *testing.T) {
+	callbackExecutor := NewCallbackExecutor()
+	routerRegister := &router.HandlerRouter{}
+	routerRegister.Router("SWITCH_TOPIC", &SampleHandler2{}, router.Method("EventHandleMethod1"))
+	callbackExecutor.SetRouter(routerRegister)
+	callbackExecutor.serviceConfig = &config.Service{ServiceID: "test"}
+
+	topicAttribute, _ := BuildBussinessTopicAttributes("ORG001", "WKS1", "ENV1", "SU001", "V1", "SWITCH_TOPIC")
+	handle := func() *msg.Message {
+		response, err := callbackExecutor.Handle(context.Background(), &msg.Message{TopicAttribute: topicAttribute, Body: []byte(`{"A":"test1"}`)})
+		assert.True(t, nil == err)
+		return response
+	}
+
+	callbackExecutor.applyRouteSwitches(map[string]config.RouteSwitch{"SWITCH_TOPIC": {Mode: config.RouteModeDisabled}})
+	response := handle()
+	assert.Equal(t, constant.RouteDisabledError, response.GetAppPropertySilence(constant.ReturnErrorCode))
+	assert.Equal(t, http.StatusServiceUnavailable, callbackExecutor.httpStatusOf(response))
+
+	callbackExecutor.applyRouteSwitches(map[string]config.RouteSwitch{"*": {Mode: config.RouteModeMaintenance, ErrorCode: "MAINTENANCE", ErrorMessage: "back soon"}})
+	response = handle()
+	assert.Equal(t, "MAINTENANCE", response.GetAppPropertySilence(constant.ReturnErrorCode))
+	assert.True(t, strings.Contains(response.GetAppPropertySilence(constant.ReturnErrorMsg), "back soon"))
+
+	callbackExecutor.applyRouteSwitches(nil)
+	response = handle()
+	assert.Equal(t, "", response.GetAppPropertySilence(constant.ReturnErrorCode))
+	assert.True(t, strings.Contains(string(response.Body), "format to JSON"))
+}
+
+type ValidatedRequest struct {
+	A string `validate:"required"`
+}
+
+func handleFunc(ctx context.Context, request *ValidatedRequest) (*Response, error) {
+	if "fail" == request.A {
+		return nil, errors.New("FUNC_ERROR", "failed")
+	}
+	base.AddResponseHeaderToContext(ctx, "handled-by", "func")
+	topicID := base.TopicAttributesFromContext(ctx)[constant.TopicID]
+
+	return &Response{B: request.A + "-" + base.RequestHeaderValueFromContext(ctx, "tenant") + "-" + topicID}, nil
+}
+
+func TestInvokeFuncHandler(t *testing.T) {
+	callbackExecutor := NewCallbackExecutor()
+	routerRegister := &router.HandlerRouter{}
+	routerRegister.Router("FUNC_TOPIC", router.Func(handleFunc), router.EnableValidation(false))
+	callbackExecutor.SetRouter(routerRegister)
+	callbackExecutor.serviceConfig = &config.Service{ServiceID: "test"}
+	callbackExecutor.extConfigs = map[string]interface{}{constant.ExtConfigService: callbackExecutor.serviceConfig}
+
+	topicAttribute, _ := BuildBussinessTopicAttributes("ORG001", "WKS1", "ENV1", "SU001", "V1", "FUNC_TOPIC")
+	handle := func(body string) *msg.Message {
+		request := &msg.Message{TopicAttribute: topicAttribute, Body: []byte(body)}
+		request.SetAppProperty("tenant", "T1")
+		response, err := callbackExecutor.Handle(context.Background(), request)
+		assert.True(t, nil == err)
+		return response
+	}
+
+	response := handle(`{"A":"a"}`)
+	assert.True(t, strings.Contains(string(response.Body), `{"B":"a-T1-FUNC_TOPIC"}`))
+	assert.Equal(t, "func", response.GetAppPropertySilence("handled-by"))
+
+	// the error returned by the function
+	response = handle(`{"A":"fail"}`)
+	assert.Equal(t, "FUNC_ERROR", response.GetAppPropertySilence(constant.ReturnErrorCode))
+
+	// the validation
+	response = handle(`{}`)
+	assert.Equal(t, constant.ValidationError, response.GetAppPropertySilence(constant.ReturnErrorCode))
+}
+
+func handleProtobufFunc(ctx context.Context, request *wrapperspb.StringValue) (*wrapperspb.StringValue, error) {
+	return wrapperspb.String("hello " + request.GetValue()), nil
+}
+
+func handleTextFunc(ctx context.Context, request *string) (*string, error) {
+	response := "hello " + *request
+	return &response, nil
+}
+
+func TestHandleWithContentTypeCodec(t *testing.T) {
+	callbackExecutor := NewCallbackExecutor()
+	routerRegister := &router.HandlerRouter{}
+	routerRegister.Router("PROTO_TOPIC", router.Func(handleProtobufFunc), router.EnableValidation(false),
+		router.WithContentTypes(constant.DefaultContentTypeJSON, constant.ContentTypeProtobuf))
+	routerRegister.Router("TEXT_TOPIC", router.Func(handleTextFunc), router.WithCodec(codec.BuildTextCodec()))
+	routerRegister.Router("STRUCT_TOPIC", router.Func(handleFunc))
+	callbackExecutor.SetRouter(routerRegister)
+	callbackExecutor.serviceConfig = &config.Service{ServiceID: "test"}
+	callbackExecutor.extConfigs = map[string]interface{}{constant.ExtConfigService: callbackExecutor.serviceConfig}
+
+	// the request is decoded and the response is encoded by the codec registered for the content type
+	topicAttribute, _ := BuildBussinessTopicAttributes("ORG001", "WKS1", "ENV1", "SU001", "V1", "PROTO_TOPIC")
+	body, err := proto.Marshal(wrapperspb.String("kit"))
+	assert.True(t, nil == err)
+	request := &msg.Message{TopicAttribute: topicAttribute, Body: body}
+	request.SetAppProperty(constant.HTTPContentTypeKey, constant.ContentTypeProtobuf)
+	response, herr := callbackExecutor.Handle(context.Background(), request)
+	assert.True(t, nil == herr)
+	assert.Equal(t, constant.ContentTypeProtobuf, response.GetAppPropertySilence(constant.HTTPContentTypeKey))
+	value := &wrapperspb.StringValue{}
+	assert.True(t, nil == proto.Unmarshal(response.Body, value))
+	assert.Equal(t, "hello kit", value.GetValue())
+
+	// the content types not accepted by the handler are handled by the codec of the handler
+	topicAttribute, _ = BuildBussinessTopicAttributes("ORG001", "WKS1", "ENV1", "SU001", "V1", "TEXT_TOPIC")
+	request = &msg.Message{TopicAttribute: topicAttribute, Body: []byte("kit")}
+	request.SetAppProperty(constant.HTTPContentTypeKey, constant.DefaultContentTypeJSON)
+	response, herr = callbackExecutor.Handle(context.Background(), request)
+	assert.True(t, nil == herr)
+	assert.True(t, strings.Contains(string(response.Body), "hello kit"))
+
+	topicAttribute, _ = BuildBussinessTopicAttributes("ORG001", "WKS1", "ENV1", "SU001", "V1", "STRUCT_TOPIC")
+	request = &msg.Message{TopicAttribute: topicAttribute, Body: []byte(`{"A":"a"}`)}
+	request.SetAppProperty(constant.HTTPContentTypeKey, "text/plain;charset=UTF-8")
+	request.SetAppProperty("tenant", "T1")
+	response, herr = callbackExecutor.Handle(context.Background(), request)
+	assert.True(t, nil == herr)
+	assert.True(t, strings.Contains(string(response.Body), `{"B":"a-T1-STRUCT_TOPIC"}`))
+}
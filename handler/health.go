@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"sort"
+	"strings"
+
+	"git.multiverse.io/eventkit/kit/cache/v1/tiered"
+	"git.multiverse.io/eventkit/kit/client/mesh/wrapper/addressing"
+	"git.multiverse.io/eventkit/kit/common/health"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/handler/config"
+	"github.com/afex/hystrix-go/hystrix"
+)
+
+// HandlerStats is the number of the registered handlers
+type HandlerStats struct {
+	URLPath    int `json:"urlPath"`
+	Event      int `json:"event"`
+	Expression int `json:"expression"`
+}
+
+// CircuitBreakerStats is the status of the circuit breakers of the downstream services
+type CircuitBreakerStats struct {
+	Enabled int      `json:"enabled"`
+	Open    []string `json:"open,omitempty"`
+}
+
+// AddressingCacheStats is the status of the local addressing cache
+type AddressingCacheStats struct {
+	Enabled bool `json:"enabled"`
+	tiered.Stats
+}
+
+// registerHealthComponents registers the handlers, circuit breakers and addressing cache into the health registry
+func (e *EventCallback) registerHealthComponents() {
+	health.RegisterComponent("handlers", func() interface{} {
+		return e.handlerStats()
+	})
+	health.RegisterComponent("circuitBreakers", func() interface{} {
+		return e.circuitBreakerStats()
+	})
+	health.RegisterComponent("addressingCache", func() interface{} {
+		stats, enabled := addressing.LocalCacheStats()
+		return AddressingCacheStats{Enabled: enabled, Stats: stats}
+	})
+}
+
+func (e *EventCallback) handlerStats() HandlerStats {
+	if nil == e.handlerRouter {
+		return HandlerStats{}
+	}
+	e.handlerRouter.RLock()
+	defer e.handlerRouter.RUnlock()
+
+	return HandlerStats{
+		URLPath:    len(e.handlerRouter.URLPathHandlers),
+		Event:      len(e.handlerRouter.DefiniteEventHandlers),
+		Expression: len(e.handlerRouter.ExpressionEventHandlers),
+	}
+}
+
+func (e *EventCallback) circuitBreakerStats() CircuitBreakerStats {
+	stats := CircuitBreakerStats{}
+	downstreamService, ok := e.extConfigs[constant.ExtConfigDownstreamService].(map[string]config.Downstream)
+	if !ok {
+		return stats
+	}
+	for k, v := range downstreamService {
+		if !v.CircuitBreaker.Enable {
+			continue
+		}
+		stats.Enabled++
+		serviceKey := strings.ToLower(k)
+		if circuit, _, err := hystrix.GetCircuit(serviceKey); nil == err && circuit.IsOpen() {
+			stats.Open = append(stats.Open, serviceKey)
+		}
+	}
+	sort.Strings(stats.Open)
+
+	return stats
+}
@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"testing"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/handler/config"
+	"git.multiverse.io/eventkit/kit/handler/router"
+)
+
+func TestEventCallback_HealthComponents(t *testing.T) {
+	e := &EventCallback{}
+	assert.Equal(t, HandlerStats{}, e.handlerStats())
+	assert.Equal(t, CircuitBreakerStats{}, e.circuitBreakerStats())
+
+	routerRegister := &router.HandlerRouter{}
+	routerRegister.Router("TOPIC1", &SampleHandler2{}, router.Method("EventHandleMethod1"))
+	routerRegister.RouterExpression(".*", &SampleHandler2{}, router.Method("OtherMethod"))
+	e.SetRouter(routerRegister)
+	assert.Equal(t, HandlerStats{Event: 1, Expression: 1}, e.handlerStats())
+
+	downstream := map[string]config.Downstream{
+		"HealthTestService": {},
+		"OtherService":      {},
+	}
+	cb := downstream["HealthTestService"]
+	cb.CircuitBreaker.Enable = true
+	downstream["HealthTestService"] = cb
+	e.extConfigs = map[string]interface{}{constant.ExtConfigDownstreamService: downstream}
+
+	stats := e.circuitBreakerStats()
+	assert.Equal(t, 1, stats.Enabled)
+	assert.Equal(t, 0, len(stats.Open))
+}
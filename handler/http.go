@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"git.multiverse.io/eventkit/kit/codec"
+	"git.multiverse.io/eventkit/kit/codec/registry"
+	"git.multiverse.io/eventkit/kit/common/msg"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/handler/openapi"
+	"git.multiverse.io/eventkit/kit/handler/router"
+)
+
+// DefaultHTTPStatusMapping maps the error codes of the kit to the HTTP status,
+// it can be overridden by the `httpStatusMapping` of the service config.
+var DefaultHTTPStatusMapping = map[string]int{
+	constant.CannotFoundHandlerWithURLError:    http.StatusNotFound,
+	constant.UpstreamServiceMessageDecodeError: http.StatusBadRequest,
+	constant.ValidationError:                   http.StatusBadRequest,
+	constant.ShuttingDownError:                 http.StatusServiceUnavailable,
+	constant.CallbackOverloadedError:           http.StatusServiceUnavailable,
+	constant.RequestEntityTooLargeError:        http.StatusRequestEntityTooLarge,
+	constant.RouteDisabledError:                http.StatusServiceUnavailable,
+	constant.RouteUnderMaintenanceError:        http.StatusServiceUnavailable,
+	constant.RouteReadOnlyError:                http.StatusServiceUnavailable,
+	constant.HandlerTimeoutError:               http.StatusGatewayTimeout,
+}
+
+// httpStatusOf returns the HTTP status of the response by the error code
+func (e *EventCallback) httpStatusOf(response *msg.Message) int {
+	if nil == response || "F" != response.GetAppPropertySilence(constant.ReturnStatus) {
+		return http.StatusOK
+	}
+	errorCode := response.GetAppPropertySilence(constant.ReturnErrorCode)
+	if nil != e.serviceConfig {
+		// the error codes are lowercased by the config loader
+		if status, ok := e.serviceConfig.HTTPStatusMapping[errorCode]; ok {
+			return status
+		} else if status, ok := e.serviceConfig.HTTPStatusMapping[strings.ToLower(errorCode)]; ok {
+			return status
+		}
+	}
+	if status, ok := DefaultHTTPStatusMapping[errorCode]; ok {
+		return status
+	}
+	if nil != e.serviceConfig && e.serviceConfig.DefaultErrorHTTPStatus > 0 {
+		return e.serviceConfig.DefaultErrorHTTPStatus
+	}
+
+	return http.StatusOK
+}
+
+// errorHTTPStatuses returns the HTTP statuses of the error responses of the URL path handlers
+func (e *EventCallback) errorHTTPStatuses() []int {
+	statusSet := make(map[int]bool)
+	for _, status := range DefaultHTTPStatusMapping {
+		statusSet[status] = true
+	}
+	if nil != e.serviceConfig {
+		for _, status := range e.serviceConfig.HTTPStatusMapping {
+			statusSet[status] = true
+		}
+		if e.serviceConfig.DefaultErrorHTTPStatus > 0 {
+			statusSet[e.serviceConfig.DefaultErrorHTTPStatus] = true
+		}
+	}
+	// the errors without mapping are sent with 200 by default
+	delete(statusSet, http.StatusOK)
+	statuses := make([]int, 0, len(statusSet))
+	for status := range statusSet {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+
+	return statuses
+}
+
+// openAPIPath returns the URL path that serves the OpenAPI document, empty if not configured
+func (e *EventCallback) openAPIPath() string {
+	if nil == e.serviceConfig {
+		return ""
+	}
+
+	return e.serviceConfig.OpenAPI.Path
+}
+
+// OpenAPIDocument generates the OpenAPI document from the handlers registered into the router
+func (e *EventCallback) OpenAPIDocument() *openapi.Document {
+	opts := []openapi.Option{openapi.WithErrorHTTPStatuses(e.errorHTTPStatuses()...)}
+	if nil != e.serviceConfig {
+		title := e.serviceConfig.OpenAPI.Title
+		if "" == title {
+			title = e.serviceConfig.ServiceID
+		}
+		if "" != title {
+			opts = append(opts, openapi.WithTitle(title))
+		}
+		if "" != e.serviceConfig.OpenAPI.Description {
+			opts = append(opts, openapi.WithDescription(e.serviceConfig.OpenAPI.Description))
+		}
+		if "" != e.serviceConfig.OpenAPI.Version {
+			opts = append(opts, openapi.WithVersion(e.serviceConfig.OpenAPI.Version))
+		}
+	}
+
+	return openapi.Generate(e.handlerRouter, opts...)
+}
+
+// mediaTypeCodec is a codec that encodes the responses for the media type
+type mediaTypeCodec struct {
+	mediaType string
+	codec     codec.Codec
+}
+
+type acceptedMediaType struct {
+	mediaType string
+	quality   float64
+}
+
+// parseAccept parses the media ranges and the qualities of the `Accept` header
+func parseAccept(accept string) []acceptedMediaType {
+	accepted := make([]acceptedMediaType, 0)
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		quality := 1.0
+		for _, param := range params[1:] {
+			kv := strings.SplitN(strings.TrimSpace(param), "=", 2)
+			if 2 == len(kv) && "q" == strings.TrimSpace(kv[0]) {
+				if q, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64); nil == err {
+					quality = q
+				}
+			}
+		}
+		if "" != mediaType {
+			accepted = append(accepted, acceptedMediaType{mediaType: mediaType, quality: quality})
+		}
+	}
+
+	return accepted
+}
+
+// acceptsMediaType checks whether the media type is acceptable by the `Accept` header with the quality of
+// the most specific media range matching the media type, the empty `Accept` header accepts any media type.
+func acceptsMediaType(accept, mediaType string) bool {
+	if "" == strings.TrimSpace(accept) {
+		return true
+	}
+	specificity, quality := -1, 0.0
+	for _, a := range parseAccept(accept) {
+		s := -1
+		if a.mediaType == mediaType {
+			s = 2
+		} else if "*/*" == a.mediaType {
+			s = 0
+		} else if strings.HasSuffix(a.mediaType, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(a.mediaType, "*")) {
+			s = 1
+		}
+		if s > specificity {
+			specificity, quality = s, a.quality
+		}
+	}
+
+	return quality > 0
+}
+
+// negotiateCodecs returns the registered codecs acceptable by the `Accept` header ordered by the quality,
+// returns nil if the `Accept` header is empty or accepts any media type first.
+func negotiateCodecs(accept string) []mediaTypeCodec {
+	if "" == strings.TrimSpace(accept) {
+		return nil
+	}
+	accepted := make([]acceptedMediaType, 0)
+	for _, a := range parseAccept(accept) {
+		if a.quality > 0 {
+			accepted = append(accepted, a)
+		}
+	}
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return accepted[i].quality > accepted[j].quality
+	})
+
+	codecs := make([]mediaTypeCodec, 0)
+	for _, a := range accepted {
+		if "*/*" == a.mediaType {
+			break
+		}
+		for _, mediaType := range registry.ContentTypes() {
+			if mediaType == a.mediaType ||
+				(strings.HasSuffix(a.mediaType, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(a.mediaType, "*"))) {
+				if c, ok := registry.Lookup(mediaType); ok {
+					codecs = append(codecs, mediaTypeCodec{mediaType: mediaType, codec: c})
+				}
+			}
+		}
+	}
+
+	return codecs
+}
+
+// requestCodec returns the codec registered for the content type of the request and the media type
+// if the handler accepts the content type(see router.WithContentTypes), otherwise returns the codec of the handler
+// and the content type declared by the codec.
+func requestCodec(hp *router.Options, request *msg.Message) (codec.Codec, string) {
+	if len(hp.HandlerOptions.ContentTypes) > 0 {
+		if contentType := headerValue(request, constant.HTTPContentTypeKey); "" != contentType {
+			mediaType := registry.MediaType(contentType)
+			for _, accepted := range hp.HandlerOptions.ContentTypes {
+				if registry.MediaType(accepted) != mediaType {
+					continue
+				}
+				if c, ok := registry.Lookup(mediaType); ok {
+					return c, mediaType
+				}
+			}
+		}
+	}
+
+	return hp.HandlerOptions.Codec, codec.ContentTypeOf(hp.HandlerOptions.Codec)
+}
+
+// headerValue returns the value of the request header case-insensitively
+func headerValue(request *msg.Message, key string) string {
+	if v := request.GetAppPropertySilence(key); "" != v {
+		return v
+	}
+	value := ""
+	request.RangeAppProps(func(k string, v string) {
+		if "" == value && strings.EqualFold(k, key) {
+			value = v
+		}
+	})
+
+	return value
+}
@@ -0,0 +1,312 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/shutdown"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/handler/config"
+	"git.multiverse.io/eventkit/kit/log"
+	"github.com/valyala/fasthttp"
+)
+
+// DefaultMaxRequestBodySize is the default max size of the request bodies of the URL path handlers
+const DefaultMaxRequestBodySize = 1024 * 1024 * 1024
+
+// httpServerShutdownTimeout is the max time waiting for the connections of the HTTP server to be closed when shutting down
+const httpServerShutdownTimeout = 5 * time.Second
+
+// DefaultCORSAllowMethods is the default allowed methods of the cross-origin requests
+var DefaultCORSAllowMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// DefaultSecurityHeaders is the default security headers of the responses,
+// `Strict-Transport-Security` is only sent when the TLS is enabled.
+var DefaultSecurityHeaders = map[string]string{
+	"X-Content-Type-Options":  "nosniff",
+	"X-Frame-Options":         "DENY",
+	"Referrer-Policy":         "no-referrer",
+	"Content-Security-Policy": "default-src 'none'; frame-ancestors 'none'",
+}
+
+const strictTransportSecurity = "Strict-Transport-Security"
+
+// httpServerConfig returns the config of the HTTP server, the zero value if the service config isn't set
+func (e *EventCallback) httpServerConfig() config.HTTPServer {
+	if nil == e.serviceConfig {
+		return config.HTTPServer{}
+	}
+
+	return e.serviceConfig.HTTPServer
+}
+
+func isTLSEnabled(serverConfig config.HTTPServer) bool {
+	return "" != serverConfig.TLS.CertFile && "" != serverConfig.TLS.KeyFile
+}
+
+func maxRequestBodySize(serverConfig config.HTTPServer) int {
+	if serverConfig.MaxRequestBodySize > 0 {
+		return serverConfig.MaxRequestBodySize
+	}
+
+	return DefaultMaxRequestBodySize
+}
+
+// newHTTPServer creates the HTTP server with the timeouts, the body limits, the CORS and the security headers,
+// the body limits of the URL paths are applied after receiving the request header so that the larger bodies are never read.
+func newHTTPServer(handler fasthttp.RequestHandler, serverConfig config.HTTPServer, paths []string) *fasthttp.Server {
+	limits := newRouteBodyLimits(serverConfig, paths)
+
+	return &fasthttp.Server{
+		Handler:            withHTTPPolicies(handler, serverConfig),
+		MaxRequestBodySize: maxRequestBodySize(serverConfig),
+		HeaderReceived: func(header *fasthttp.RequestHeader) fasthttp.RequestConfig {
+			return fasthttp.RequestConfig{MaxRequestBodySize: limits.limitOf(string(header.RequestURI()))}
+		},
+		ErrorHandler: func(ctx *fasthttp.RequestCtx, err error) {
+			handleServerError(ctx, err, limits)
+		},
+		ReadTimeout:                   time.Duration(serverConfig.ReadTimeoutMilliseconds) * time.Millisecond,
+		WriteTimeout:                  time.Duration(serverConfig.WriteTimeoutMilliseconds) * time.Millisecond,
+		IdleTimeout:                   time.Duration(serverConfig.IdleTimeoutMilliseconds) * time.Millisecond,
+		DisableHeaderNamesNormalizing: true,
+	}
+}
+
+// handleServerError writes the error response of the requests failed to read
+func handleServerError(ctx *fasthttp.RequestCtx, err error, limits *routeBodyLimits) {
+	if fasthttp.ErrBodyTooLarge != err {
+		ctx.Error(err.Error(), http.StatusBadRequest)
+		return
+	}
+	ctx.Response.Header.Set(constant.ReturnStatus, "F")
+	ctx.Response.Header.Set(constant.ReturnErrorCode, constant.RequestEntityTooLargeError)
+	ctx.Response.Header.Set(constant.ReturnErrorMsg, fmt.Sprintf("The request body is larger than %d bytes", limits.limitOf(string(ctx.Request.Header.RequestURI()))))
+	ctx.SetStatusCode(http.StatusRequestEntityTooLarge)
+}
+
+type routeBodyLimit struct {
+	segments []string
+	limit    int
+}
+
+// routeBodyLimits matches the request paths to the body limits of the URL paths(e.g. `/v1/files/:id`)
+type routeBodyLimits struct {
+	routes       []routeBodyLimit
+	defaultLimit int
+}
+
+func newRouteBodyLimits(serverConfig config.HTTPServer, paths []string) *routeBodyLimits {
+	limits := &routeBodyLimits{defaultLimit: maxRequestBodySize(serverConfig)}
+	for _, path := range paths {
+		// the URL paths are lowercased by the config loader
+		limit, ok := serverConfig.RouteMaxRequestBodySize[path]
+		if !ok {
+			limit = serverConfig.RouteMaxRequestBodySize[strings.ToLower(path)]
+		}
+		if limit > 0 {
+			limits.routes = append(limits.routes, routeBodyLimit{segments: strings.Split(strings.Trim(path, "/"), "/"), limit: limit})
+		}
+	}
+
+	return limits
+}
+
+// limitOf returns the body limit of the request URI
+func (r *routeBodyLimits) limitOf(requestURI string) int {
+	if index := strings.IndexByte(requestURI, '?'); index >= 0 {
+		requestURI = requestURI[:index]
+	}
+	parts := strings.Split(strings.Trim(requestURI, "/"), "/")
+	for _, route := range r.routes {
+		if matchURLPath(route.segments, parts) {
+			return route.limit
+		}
+	}
+
+	return r.defaultLimit
+}
+
+// matchURLPath matches the segments of the request path with the segments of the URL path,
+// the named parameters(`:name`) match any segment and the catch-all parameters(`*name`) match the rest.
+func matchURLPath(segments, parts []string) bool {
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "*") {
+			return true
+		}
+		if i >= len(parts) || (!strings.HasPrefix(segment, ":") && segment != parts[i]) {
+			return false
+		}
+	}
+
+	return len(segments) == len(parts)
+}
+
+// withShutdown rejects the new requests when shutting down, the in-flight requests are drained by the shutdown coordinator
+func withShutdown(handler fasthttp.RequestHandler, coordinator *shutdown.Coordinator) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if !coordinator.Enter() {
+			ctx.Response.Header.Set(constant.ReturnStatus, "F")
+			ctx.Response.Header.Set(constant.ReturnErrorCode, constant.ShuttingDownError)
+			ctx.Response.Header.Set(constant.ReturnErrorMsg, "The service is shutting down")
+			ctx.SetStatusCode(http.StatusServiceUnavailable)
+			return
+		}
+		defer coordinator.Exit()
+
+		handler(ctx)
+	}
+}
+
+// withHTTPPolicies applies the CORS and the security headers to all the routes
+func withHTTPPolicies(handler fasthttp.RequestHandler, serverConfig config.HTTPServer) fasthttp.RequestHandler {
+	cors := serverConfig.CORS
+	securityHeaders := make(map[string]string)
+	if serverConfig.SecurityHeaders.Enable {
+		for k, v := range DefaultSecurityHeaders {
+			securityHeaders[k] = v
+		}
+		if isTLSEnabled(serverConfig) {
+			securityHeaders[strictTransportSecurity] = "max-age=31536000; includeSubDomains"
+		}
+		for k, v := range serverConfig.SecurityHeaders.Headers {
+			if "" == v {
+				delete(securityHeaders, k)
+			} else {
+				securityHeaders[k] = v
+			}
+		}
+	}
+	if !cors.Enable && 0 == len(securityHeaders) {
+		return handler
+	}
+	if cors.Enable && cors.AllowCredentials {
+		for _, allowed := range cors.AllowOrigins {
+			if "*" == allowed {
+				log.Warnsf("The wildcard origin \"*\" of CORS is ignored because the credentials are allowed, please specify the allowed origins")
+				break
+			}
+		}
+	}
+
+	return func(ctx *fasthttp.RequestCtx) {
+		origin := requestHeader(ctx, "Origin")
+		if cors.Enable && "" != origin {
+			if ctx.IsOptions() && "" != requestHeader(ctx, "Access-Control-Request-Method") {
+				handlePreflight(ctx, cors, origin)
+				setSecurityHeaders(ctx, securityHeaders)
+				return
+			}
+			handler(ctx)
+			if isAllowedOrigin(cors, origin) {
+				setAllowOrigin(ctx, cors, origin)
+				if len(cors.ExposeHeaders) > 0 {
+					ctx.Response.Header.Set("Access-Control-Expose-Headers", strings.Join(cors.ExposeHeaders, ", "))
+				}
+			}
+		} else {
+			handler(ctx)
+		}
+		setSecurityHeaders(ctx, securityHeaders)
+	}
+}
+
+// requestHeader returns the request header ignoring the case, the header names are not normalized by the server
+func requestHeader(ctx *fasthttp.RequestCtx, key string) string {
+	if value := ctx.Request.Header.Peek(key); len(value) > 0 {
+		return string(value)
+	}
+	var value string
+	ctx.Request.Header.VisitAll(func(k, v []byte) {
+		if "" == value && strings.EqualFold(string(k), key) {
+			value = string(v)
+		}
+	})
+
+	return value
+}
+
+// setSecurityHeaders sets the security headers that are not set by the handler
+func setSecurityHeaders(ctx *fasthttp.RequestCtx, securityHeaders map[string]string) {
+	for k, v := range securityHeaders {
+		if 0 == len(ctx.Response.Header.Peek(k)) {
+			ctx.Response.Header.Set(k, v)
+		}
+	}
+}
+
+// isAllowedOrigin checks the origin with the allowed origins, the wildcard origin "*" doesn't allow any origin
+// if the credentials are allowed, otherwise any website could send the credentialed requests.
+func isAllowedOrigin(cors config.CORS, origin string) bool {
+	for _, allowed := range cors.AllowOrigins {
+		if ("*" == allowed && !cors.AllowCredentials) || allowed == origin {
+			return true
+		}
+		// the wildcard subdomain, e.g. https://*.example.com
+		if idx := strings.Index(allowed, "*."); idx >= 0 {
+			prefix, suffix := allowed[:idx], allowed[idx+1:]
+			if strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) && len(origin) > len(prefix)+len(suffix) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func isAllowedMethod(cors config.CORS, method string) bool {
+	allowMethods := cors.AllowMethods
+	if 0 == len(allowMethods) {
+		allowMethods = DefaultCORSAllowMethods
+	}
+	for _, allowed := range allowMethods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func setAllowOrigin(ctx *fasthttp.RequestCtx, cors config.CORS, origin string) {
+	// the credentials are not allowed with the wildcard origin
+	if !cors.AllowCredentials && 1 == len(cors.AllowOrigins) && "*" == cors.AllowOrigins[0] {
+		ctx.Response.Header.Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	ctx.Response.Header.Set("Access-Control-Allow-Origin", origin)
+	ctx.Response.Header.Add("Vary", "Origin")
+	if cors.AllowCredentials {
+		ctx.Response.Header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+// handlePreflight replies the preflight request without calling the handler
+func handlePreflight(ctx *fasthttp.RequestCtx, cors config.CORS, origin string) {
+	method := requestHeader(ctx, "Access-Control-Request-Method")
+	if !isAllowedOrigin(cors, origin) || !isAllowedMethod(cors, method) {
+		ctx.SetStatusCode(http.StatusForbidden)
+		return
+	}
+
+	setAllowOrigin(ctx, cors, origin)
+	allowMethods := cors.AllowMethods
+	if 0 == len(allowMethods) {
+		allowMethods = DefaultCORSAllowMethods
+	}
+	ctx.Response.Header.Set("Access-Control-Allow-Methods", strings.Join(allowMethods, ", "))
+	if len(cors.AllowHeaders) > 0 {
+		ctx.Response.Header.Set("Access-Control-Allow-Headers", strings.Join(cors.AllowHeaders, ", "))
+	} else if requestHeaders := requestHeader(ctx, "Access-Control-Request-Headers"); "" != requestHeaders {
+		ctx.Response.Header.Set("Access-Control-Allow-Headers", requestHeaders)
+	}
+	if cors.MaxAgeSeconds > 0 {
+		ctx.Response.Header.Set("Access-Control-Max-Age", strconv.Itoa(cors.MaxAgeSeconds))
+	}
+	ctx.SetStatusCode(http.StatusNoContent)
+}
@@ -0,0 +1,232 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/shutdown"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/handler/config"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+)
+
+func newRequestCtx(method, origin string, headers map[string]string) *fasthttp.RequestCtx {
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.Header.SetMethod(method)
+	ctx.Request.SetRequestURI("/v1/accounts")
+	if "" != origin {
+		ctx.Request.Header.Set("Origin", origin)
+	}
+	for k, v := range headers {
+		ctx.Request.Header.Set(k, v)
+	}
+
+	return ctx
+}
+
+func TestCORSPreflight(t *testing.T) {
+	called := false
+	handler := withHTTPPolicies(func(ctx *fasthttp.RequestCtx) {
+		called = true
+	}, config.HTTPServer{
+		CORS: config.CORS{
+			Enable:           true,
+			AllowOrigins:     []string{"https://app.example.com", "https://*.example.org"},
+			AllowHeaders:     []string{"Content-Type", "Authorization"},
+			AllowCredentials: true,
+			MaxAgeSeconds:    600,
+		},
+	})
+
+	ctx := newRequestCtx(http.MethodOptions, "https://app.example.com", map[string]string{"Access-Control-Request-Method": http.MethodPost})
+	handler(ctx)
+	assert.False(t, called)
+	assert.Equal(t, http.StatusNoContent, ctx.Response.StatusCode())
+	assert.Equal(t, "https://app.example.com", string(ctx.Response.Header.Peek("Access-Control-Allow-Origin")))
+	assert.Equal(t, "true", string(ctx.Response.Header.Peek("Access-Control-Allow-Credentials")))
+	assert.Equal(t, "Content-Type, Authorization", string(ctx.Response.Header.Peek("Access-Control-Allow-Headers")))
+	assert.Equal(t, "600", string(ctx.Response.Header.Peek("Access-Control-Max-Age")))
+	assert.True(t, strings.Contains(string(ctx.Response.Header.Peek("Access-Control-Allow-Methods")), http.MethodPost))
+
+	// the wildcard subdomain
+	ctx = newRequestCtx(http.MethodOptions, "https://api.example.org", map[string]string{"access-control-request-method": http.MethodGet})
+	handler(ctx)
+	assert.Equal(t, http.StatusNoContent, ctx.Response.StatusCode())
+	assert.Equal(t, "https://api.example.org", string(ctx.Response.Header.Peek("Access-Control-Allow-Origin")))
+
+	// the origin not allowed
+	ctx = newRequestCtx(http.MethodOptions, "https://evil.com", map[string]string{"Access-Control-Request-Method": http.MethodPost})
+	handler(ctx)
+	assert.Equal(t, http.StatusForbidden, ctx.Response.StatusCode())
+	assert.Equal(t, 0, len(ctx.Response.Header.Peek("Access-Control-Allow-Origin")))
+	assert.False(t, called)
+}
+
+func TestCORSActualRequest(t *testing.T) {
+	handler := withHTTPPolicies(func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(http.StatusOK)
+	}, config.HTTPServer{
+		CORS: config.CORS{
+			Enable:        true,
+			AllowOrigins:  []string{"*"},
+			ExposeHeaders: []string{constant.ReturnErrorCode},
+		},
+	})
+
+	ctx := newRequestCtx(http.MethodGet, "https://any.com", nil)
+	handler(ctx)
+	assert.Equal(t, "*", string(ctx.Response.Header.Peek("Access-Control-Allow-Origin")))
+	assert.Equal(t, constant.ReturnErrorCode, string(ctx.Response.Header.Peek("Access-Control-Expose-Headers")))
+
+	// not a cross-origin request
+	ctx = newRequestCtx(http.MethodGet, "", nil)
+	handler(ctx)
+	assert.Equal(t, http.StatusOK, ctx.Response.StatusCode())
+	assert.Equal(t, 0, len(ctx.Response.Header.Peek("Access-Control-Allow-Origin")))
+}
+
+func TestCORSWildcardOriginWithCredentials(t *testing.T) {
+	handler := withHTTPPolicies(func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(http.StatusOK)
+	}, config.HTTPServer{
+		CORS: config.CORS{
+			Enable:           true,
+			AllowOrigins:     []string{"*", "https://app.example.com"},
+			AllowCredentials: true,
+		},
+	})
+
+	// the wildcard origin doesn't allow any origin with the credentials
+	ctx := newRequestCtx(http.MethodGet, "https://evil.com", nil)
+	handler(ctx)
+	assert.Equal(t, 0, len(ctx.Response.Header.Peek("Access-Control-Allow-Origin")))
+	assert.Equal(t, 0, len(ctx.Response.Header.Peek("Access-Control-Allow-Credentials")))
+
+	ctx = newRequestCtx(http.MethodOptions, "https://evil.com", map[string]string{"Access-Control-Request-Method": http.MethodPost})
+	handler(ctx)
+	assert.Equal(t, http.StatusForbidden, ctx.Response.StatusCode())
+
+	ctx = newRequestCtx(http.MethodGet, "https://app.example.com", nil)
+	handler(ctx)
+	assert.Equal(t, "https://app.example.com", string(ctx.Response.Header.Peek("Access-Control-Allow-Origin")))
+	assert.Equal(t, "true", string(ctx.Response.Header.Peek("Access-Control-Allow-Credentials")))
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	handler := withHTTPPolicies(func(ctx *fasthttp.RequestCtx) {
+		ctx.Response.Header.Set("X-Frame-Options", "SAMEORIGIN")
+	}, config.HTTPServer{
+		TLS: config.TLS{CertFile: "cert.pem", KeyFile: "key.pem"},
+		SecurityHeaders: config.SecurityHeaders{
+			Enable:  true,
+			Headers: map[string]string{"Referrer-Policy": "", "Permissions-Policy": "camera=()"},
+		},
+	})
+
+	ctx := newRequestCtx(http.MethodGet, "", nil)
+	handler(ctx)
+	assert.Equal(t, "nosniff", string(ctx.Response.Header.Peek("X-Content-Type-Options")))
+	assert.Equal(t, "SAMEORIGIN", string(ctx.Response.Header.Peek("X-Frame-Options")))
+	assert.Equal(t, "camera=()", string(ctx.Response.Header.Peek("Permissions-Policy")))
+	assert.Equal(t, 0, len(ctx.Response.Header.Peek("Referrer-Policy")))
+	assert.True(t, len(ctx.Response.Header.Peek("Strict-Transport-Security")) > 0)
+
+	// the policies are disabled by default
+	ctx = newRequestCtx(http.MethodGet, "https://app.example.com", nil)
+	withHTTPPolicies(func(ctx *fasthttp.RequestCtx) {}, config.HTTPServer{})(ctx)
+	assert.Equal(t, 0, len(ctx.Response.Header.Peek("X-Content-Type-Options")))
+	assert.Equal(t, 0, len(ctx.Response.Header.Peek("Access-Control-Allow-Origin")))
+}
+
+func TestRequestBodyLimit(t *testing.T) {
+	serverConfig := config.HTTPServer{
+		MaxRequestBodySize:      8,
+		RouteMaxRequestBodySize: map[string]int{"/v1/files/:id": 2048, "/v1/uploads/*name": 4096},
+	}
+	limits := newRouteBodyLimits(serverConfig, []string{"/v1/accounts", "/v1/files/:id", "/v1/Uploads/*name"})
+	assert.Equal(t, 8, limits.limitOf("/v1/accounts"))
+	assert.Equal(t, 2048, limits.limitOf("/v1/files/001?version=1"))
+	assert.Equal(t, 8, limits.limitOf("/v1/files/001/content"))
+	// the URL paths lowercased by the config loader are matched as well
+	assert.Equal(t, 4096, limits.limitOf("/v1/Uploads/a/b"))
+	assert.Equal(t, DefaultMaxRequestBodySize, newRouteBodyLimits(config.HTTPServer{}, []string{"/v1/files/:id"}).limitOf("/v1/files/001"))
+
+	// the bodies are rejected before being read
+	called := 0
+	server := newHTTPServer(func(ctx *fasthttp.RequestCtx) {
+		called++
+	}, serverConfig, []string{"/v1/accounts", "/v1/files/:id"})
+	ln := fasthttputil.NewInmemoryListener()
+	defer ln.Close()
+	go server.Serve(ln)
+	client := &fasthttp.Client{Dial: func(addr string) (net.Conn, error) {
+		return ln.Dial()
+	}}
+
+	for _, c := range []struct {
+		uri    string
+		status int
+	}{
+		{"http://localhost/v1/accounts", http.StatusRequestEntityTooLarge},
+		{"http://localhost/v1/files/001", http.StatusOK},
+	} {
+		request := fasthttp.AcquireRequest()
+		response := fasthttp.AcquireResponse()
+		request.Header.SetMethod(http.MethodPost)
+		request.SetRequestURI(c.uri)
+		request.SetBodyString("0123456789")
+		assert.True(t, nil == client.Do(request, response))
+		assert.Equal(t, c.status, response.StatusCode())
+		if http.StatusRequestEntityTooLarge == c.status {
+			assert.Equal(t, constant.RequestEntityTooLargeError, string(response.Header.Peek(constant.ReturnErrorCode)))
+			assert.Equal(t, "The request body is larger than 8 bytes", string(response.Header.Peek(constant.ReturnErrorMsg)))
+		}
+		fasthttp.ReleaseRequest(request)
+		fasthttp.ReleaseResponse(response)
+	}
+	assert.Equal(t, 1, called)
+}
+
+func TestShutdownHTTPEndpoint(t *testing.T) {
+	coordinator := shutdown.NewCoordinator()
+	release := make(chan struct{})
+	handler := withShutdown(func(ctx *fasthttp.RequestCtx) {
+		<-release
+		ctx.SetStatusCode(http.StatusOK)
+	}, coordinator)
+
+	// the in-flight request is drained before the shutdown finishes
+	ctx := newRequestCtx(http.MethodGet, "", nil)
+	served := make(chan struct{})
+	go func() {
+		handler(ctx)
+		close(served)
+	}()
+	for 0 == coordinator.Progress().InFlight {
+		time.Sleep(time.Millisecond)
+	}
+	shutdownResult := make(chan *errors.Error)
+	go func() {
+		shutdownResult <- coordinator.Shutdown(5 * time.Second)
+	}()
+	for !coordinator.IsShuttingDown() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the new requests are rejected
+	rejected := newRequestCtx(http.MethodGet, "", nil)
+	handler(rejected)
+	assert.Equal(t, http.StatusServiceUnavailable, rejected.Response.StatusCode())
+	assert.Equal(t, constant.ShuttingDownError, string(rejected.Response.Header.Peek(constant.ReturnErrorCode)))
+
+	close(release)
+	<-served
+	assert.True(t, nil == <-shutdownResult)
+	assert.Equal(t, http.StatusOK, ctx.Response.StatusCode())
+}
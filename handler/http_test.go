@@ -0,0 +1,169 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"git.multiverse.io/eventkit/kit/codec"
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/msg"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/handler/base"
+	"git.multiverse.io/eventkit/kit/handler/config"
+	"git.multiverse.io/eventkit/kit/handler/router"
+)
+
+type GetAccountRequest struct {
+	ID     string   `path:"id"`
+	Fields []string `query:"fields"`
+}
+
+type Account struct {
+	ID     string
+	Fields []string
+}
+
+type AccountHandler struct {
+	base.Handler
+}
+
+func (h *AccountHandler) Get(request *GetAccountRequest) (*Account, *errors.Error) {
+	if "0" == request.ID {
+		return nil, errors.New("ACCOUNT_NOT_FOUND", "account not found")
+	}
+
+	return &Account{ID: request.ID, Fields: request.Fields}, nil
+}
+
+func TestNegotiateCodecs(t *testing.T) {
+	assert.Equal(t, 0, len(negotiateCodecs("")))
+	assert.Equal(t, 0, len(negotiateCodecs("*/*")))
+
+	codecs := negotiateCodecs("text/plain;q=0.5, application/xml, */*;q=0.1")
+	assert.Equal(t, 2, len(codecs))
+	assert.Equal(t, "application/xml", codecs[0].mediaType)
+	assert.Equal(t, "text/plain", codecs[1].mediaType)
+
+	codecs = negotiateCodecs("application/*;q=0.9, application/json;q=0")
+	assert.Equal(t, 4, len(codecs))
+	assert.Equal(t, constant.DefaultContentTypeJSON, codecs[0].mediaType)
+	assert.Equal(t, constant.ContentTypeProtobuf, codecs[2].mediaType)
+}
+
+func TestAcceptsMediaType(t *testing.T) {
+	assert.True(t, acceptsMediaType("", constant.DefaultContentTypeJSON))
+	assert.True(t, acceptsMediaType("application/json", constant.DefaultContentTypeJSON))
+	assert.True(t, acceptsMediaType("text/html, */*;q=0.8", constant.DefaultContentTypeJSON))
+	assert.True(t, acceptsMediaType("application/*", constant.DefaultContentTypeJSON))
+	assert.False(t, acceptsMediaType("application/xml", constant.DefaultContentTypeJSON))
+	// the most specific media range takes effect
+	assert.False(t, acceptsMediaType("application/*;q=0.9, application/json;q=0", constant.DefaultContentTypeJSON))
+	assert.False(t, acceptsMediaType("*/*, application/*;q=0", constant.DefaultContentTypeJSON))
+}
+
+type fixedEncoder struct{}
+
+func (f *fixedEncoder) Encode(v interface{}) ([]byte, error) {
+	return []byte(`{"fixed":true}`), nil
+}
+
+func TestEncodeResponseWithHandlerCodec(t *testing.T) {
+	callbackExecutor := NewCallbackExecutor()
+	routerRegister := &router.HandlerRouter{}
+	routerRegister.Router("GetAccount", &AccountHandler{}, router.Method("Get"), router.HandleGet("/v1/accounts/:id"),
+		router.WithCodec(codec.BuildCustomCodec(&fixedEncoder{}, codec.BuildJSONCodec().Decoder())))
+	callbackExecutor.SetRouter(routerRegister)
+	callbackExecutor.serviceConfig = &config.Service{ServiceID: "test"}
+
+	// the codec of the handler is used if the `Accept` header accepts JSON
+	for _, accept := range []string{"", "application/json", "*/*"} {
+		request := &msg.Message{RequestURL: "/v1/accounts/10001"}
+		request.SetAppProperty("Accept", accept)
+		response, err := callbackExecutor.Handle(context.Background(), request)
+		assert.True(t, nil == err)
+		assert.True(t, strings.Contains(string(response.Body), `{"fixed":true}`))
+	}
+
+	request := &msg.Message{RequestURL: "/v1/accounts/10001"}
+	request.SetAppProperty("Accept", "application/xml")
+	response, err := callbackExecutor.Handle(context.Background(), request)
+	assert.True(t, nil == err)
+	assert.Equal(t, "<Account><ID>10001</ID></Account>", string(response.Body))
+}
+
+func TestHandleURLPathWithParameters(t *testing.T) {
+	callbackExecutor := NewCallbackExecutor()
+	routerRegister := &router.HandlerRouter{}
+	routerRegister.Router("GetAccount", &AccountHandler{}, router.Method("Get"), router.HandleGet("/v1/accounts/:id"))
+	callbackExecutor.SetRouter(routerRegister)
+	callbackExecutor.serviceConfig = &config.Service{
+		ServiceID:         "test",
+		HTTPStatusMapping: map[string]int{"ACCOUNT_NOT_FOUND": http.StatusNotFound},
+	}
+
+	// the path parameters and query arguments are bound into the request
+	request := &msg.Message{
+		RequestURL:  "/v1/accounts/10001",
+		QueryParams: map[string][]string{"fields": {"name", "balance"}},
+	}
+	response, err := callbackExecutor.Handle(context.Background(), request)
+	assert.True(t, nil == err)
+	assert.True(t, strings.Contains(string(response.Body), `{"ID":"10001","Fields":["name","balance"]}`))
+	assert.Equal(t, http.StatusOK, callbackExecutor.httpStatusOf(response))
+
+	// the response is encoded by the media type of `Accept`
+	request = &msg.Message{RequestURL: "/v1/accounts/10001"}
+	request.SetAppProperty("accept", "application/xml")
+	response, err = callbackExecutor.Handle(context.Background(), request)
+	assert.True(t, nil == err)
+	assert.Equal(t, "<Account><ID>10001</ID></Account>", string(response.Body))
+	assert.Equal(t, "application/xml", response.GetAppPropertySilence(constant.HTTPContentTypeKey))
+
+	// the error codes are mapped to the HTTP status
+	response, _ = callbackExecutor.Handle(context.Background(), &msg.Message{RequestURL: "/v1/accounts/0"})
+	assert.Equal(t, http.StatusNotFound, callbackExecutor.httpStatusOf(response))
+	response, _ = callbackExecutor.Handle(context.Background(), &msg.Message{RequestURL: "/v1/unknown"})
+	assert.Equal(t, constant.CannotFoundHandlerWithURLError, response.GetAppPropertySilence(constant.ReturnErrorCode))
+	assert.Equal(t, http.StatusNotFound, callbackExecutor.httpStatusOf(response))
+
+	// the error codes lowercased by the config loader are mapped as well
+	callbackExecutor.serviceConfig.HTTPStatusMapping = map[string]int{"account_not_found": http.StatusNotFound}
+	response, _ = callbackExecutor.Handle(context.Background(), &msg.Message{RequestURL: "/v1/accounts/0"})
+	assert.Equal(t, http.StatusNotFound, callbackExecutor.httpStatusOf(response))
+
+	// the error codes without mapping are sent with the default error HTTP status
+	callbackExecutor.serviceConfig.HTTPStatusMapping = nil
+	response, _ = callbackExecutor.Handle(context.Background(), &msg.Message{RequestURL: "/v1/accounts/0"})
+	assert.Equal(t, http.StatusOK, callbackExecutor.httpStatusOf(response))
+	callbackExecutor.serviceConfig.DefaultErrorHTTPStatus = http.StatusInternalServerError
+	assert.Equal(t, http.StatusInternalServerError, callbackExecutor.httpStatusOf(response))
+	assert.True(t, strings.Contains(response.GetAppPropertySilence(constant.ReturnErrorMsg), "account not found"))
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	callbackExecutor := NewCallbackExecutor()
+	routerRegister := &router.HandlerRouter{}
+	routerRegister.Router("GetAccount", &AccountHandler{}, router.Method("Get"), router.HandleGet("/v1/accounts/:id"))
+	callbackExecutor.SetRouter(routerRegister)
+	callbackExecutor.serviceConfig = &config.Service{
+		ServiceID:              "account",
+		HTTPStatusMapping:      map[string]int{"ACCOUNT_NOT_FOUND": http.StatusNotFound, "CONFLICT": http.StatusConflict},
+		DefaultErrorHTTPStatus: http.StatusInternalServerError,
+		OpenAPI:                config.OpenAPI{Path: "/openapi.json", Version: "1.2.0"},
+	}
+	assert.Equal(t, "/openapi.json", callbackExecutor.openAPIPath())
+	assert.Equal(t, []int{http.StatusBadRequest, http.StatusNotFound, http.StatusConflict, http.StatusRequestEntityTooLarge, http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusGatewayTimeout},
+		callbackExecutor.errorHTTPStatuses())
+
+	document := callbackExecutor.OpenAPIDocument()
+	assert.Equal(t, "account", document.Info.Title)
+	assert.Equal(t, "1.2.0", document.Info.Version)
+	get := document.Paths["/v1/accounts/{id}"].Get
+	assert.NotNil(t, get)
+	assert.NotNil(t, get.Responses["409"])
+	assert.NotNil(t, get.Responses["500"])
+	assert.NotNil(t, document.AsyncAPI.Channels["GetAccount"])
+}
@@ -6,6 +6,7 @@ import (
 	"git.multiverse.io/eventkit/kit/cache/v1/repository"
 	"git.multiverse.io/eventkit/kit/common/apm"
 	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/shutdown"
 	"git.multiverse.io/eventkit/kit/common/util"
 	"git.multiverse.io/eventkit/kit/constant"
 	"git.multiverse.io/eventkit/kit/handler/config"
@@ -199,6 +200,10 @@ func Start(routerFn func(router *router.HandlerRouter) error, options ...callbac
 			return err
 		}
 
+		for _, conflict := range router.CheckRoutes() {
+			log.Warnsf("Event route conflict detected: %s", conflict)
+		}
+
 		callbackExecutor.SetRouter(router)
 	}
 
@@ -229,6 +234,7 @@ func Start(routerFn func(router *router.HandlerRouter) error, options ...callbac
 		}
 
 		apmWrapper.ApmLogger = apmLog
+		shutdown.RegisterCloser("APM logger", apmLog.Flush)
 	}
 
 	return nil
@@ -0,0 +1,470 @@
+// Package openapi generates the OpenAPI 3 document from the handlers registered into the router.
+// The URL path handlers are documented as the paths, the schemas are derived from the request and response structs
+// and the `validate` rules are mapped to the schema constraints.
+// The event ID handlers are documented as an AsyncAPI style section(`x-asyncapi`) of the document.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"git.multiverse.io/eventkit/kit/codec"
+	"git.multiverse.io/eventkit/kit/codec/registry"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/handler/binding"
+	"git.multiverse.io/eventkit/kit/handler/router"
+)
+
+// The following elements represent the versions of the specifications.
+const (
+	Version         = "3.0.3"
+	AsyncAPIVersion = "2.6.0"
+)
+
+// The following elements represent the match type of the event channels.
+const (
+	MatchExact      = "exact"
+	MatchPrefix     = "prefix"
+	MatchSuffix     = "suffix"
+	MatchExpression = "expression"
+)
+
+// Document is the OpenAPI document
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components *Components          `json:"components,omitempty"`
+	AsyncAPI   *AsyncAPI            `json:"x-asyncapi,omitempty"`
+}
+
+// Info is the metadata of the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// PathItem is the operations of a URL path
+type PathItem struct {
+	Get     *Operation `json:"get,omitempty"`
+	Put     *Operation `json:"put,omitempty"`
+	Post    *Operation `json:"post,omitempty"`
+	Delete  *Operation `json:"delete,omitempty"`
+	Options *Operation `json:"options,omitempty"`
+	Head    *Operation `json:"head,omitempty"`
+	Patch   *Operation `json:"patch,omitempty"`
+}
+
+// Operation is an API operation on a URL path
+type Operation struct {
+	Tags        []string             `json:"tags,omitempty"`
+	OperationID string               `json:"operationId"`
+	Parameters  []*Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*Response `json:"responses"`
+}
+
+// Parameter is a path parameter or a query argument of the operation
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+// RequestBody is the request body of the operation
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+// Response is a response of the operation
+type Response struct {
+	Description string                `json:"description"`
+	Headers     map[string]*Header    `json:"headers,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+// Header is a header of the response
+type Header struct {
+	Description string  `json:"description,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// MediaType is the schema of the body with the media type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Components stores the schemas referenced by the document
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// AsyncAPI documents the event ID handlers in the AsyncAPI style
+type AsyncAPI struct {
+	AsyncAPI string              `json:"asyncapi"`
+	Channels map[string]*Channel `json:"channels"`
+}
+
+// Channel is an event ID, prefix, suffix or matcher expression of event ID handled by the service
+type Channel struct {
+	Description string          `json:"description,omitempty"`
+	MatchType   string          `json:"x-match-type"`
+	Subscribe   *EventOperation `json:"subscribe"`
+}
+
+// EventOperation is the handling of the events of a channel
+type EventOperation struct {
+	OperationID string        `json:"operationId"`
+	Tags        []*Tag        `json:"tags,omitempty"`
+	Message     *EventMessage `json:"message"`
+	Reply       *EventMessage `json:"x-reply,omitempty"`
+}
+
+// Tag is the tag of an event operation
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// EventMessage is the payload of the events
+type EventMessage struct {
+	Name        string  `json:"name,omitempty"`
+	ContentType string  `json:"contentType,omitempty"`
+	Payload     *Schema `json:"payload,omitempty"`
+}
+
+// Options is the options of the document generation
+type Options struct {
+	Title             string
+	Description       string
+	Version           string
+	ErrorHTTPStatuses []int
+}
+
+// Option is used to modify the options of the document generation
+type Option func(*Options)
+
+// WithTitle sets the title of the document
+func WithTitle(title string) Option {
+	return func(options *Options) {
+		options.Title = title
+	}
+}
+
+// WithDescription sets the description of the document
+func WithDescription(description string) Option {
+	return func(options *Options) {
+		options.Description = description
+	}
+}
+
+// WithVersion sets the version of the API
+func WithVersion(version string) Option {
+	return func(options *Options) {
+		options.Version = version
+	}
+}
+
+// WithErrorHTTPStatuses sets the HTTP statuses of the error responses,
+// the error responses are documented as `default` if not set
+func WithErrorHTTPStatuses(statuses ...int) Option {
+	return func(options *Options) {
+		options.ErrorHTTPStatuses = statuses
+	}
+}
+
+var matchTypes = map[int]string{
+	router.EventID:         MatchExact,
+	router.EventPrefix:     MatchPrefix,
+	router.EventSuffix:     MatchSuffix,
+	router.EventExpression: MatchExpression,
+}
+
+// Generate generates the OpenAPI document from the handlers registered into the router
+func Generate(handlerRouter *router.HandlerRouter, opts ...Option) *Document {
+	options := &Options{
+		Title:   "API",
+		Version: "1.0.0",
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	document := &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       options.Title,
+			Description: options.Description,
+			Version:     options.Version,
+		},
+		Paths: make(map[string]*PathItem),
+	}
+	if nil == handlerRouter {
+		return document
+	}
+
+	handlerRouter.RLock()
+	defer handlerRouter.RUnlock()
+
+	g := newSchemaGenerator()
+	operationIDs := make(map[string]int)
+	for _, urlPath := range sortedKeys(handlerRouter.URLPathHandlers) {
+		handlerOptions := handlerRouter.URLPathHandlers[urlPath]
+		path, pathParams := convertURLPath(urlPath)
+		pathItem, ok := document.Paths[path]
+		if !ok {
+			pathItem = &PathItem{}
+			document.Paths[path] = pathItem
+		}
+		pathItem.set(handlerOptions.HandlerOptions.HTTPMethod, g.operation(handlerOptions, pathParams, operationIDs, options))
+	}
+
+	if len(handlerRouter.DefiniteEventHandlers) > 0 || len(handlerRouter.ExpressionEventHandlers) > 0 {
+		document.AsyncAPI = &AsyncAPI{
+			AsyncAPI: AsyncAPIVersion,
+			Channels: make(map[string]*Channel),
+		}
+		for _, handlers := range []map[string]*router.Options{handlerRouter.DefiniteEventHandlers, handlerRouter.ExpressionEventHandlers} {
+			for _, expression := range sortedKeys(handlers) {
+				handlerOptions := handlers[expression]
+				channel := g.channel(handlerOptions, operationIDs)
+				name := expression
+				// the same literal may be registered as both the prefix and the suffix
+				if _, ok := document.AsyncAPI.Channels[name]; ok {
+					name = fmt.Sprintf("%s (%s)", expression, channel.MatchType)
+				}
+				document.AsyncAPI.Channels[name] = channel
+			}
+		}
+	}
+
+	if len(g.schemas) > 0 {
+		document.Components = &Components{Schemas: g.schemas}
+	}
+
+	return document
+}
+
+func sortedKeys(m map[string]*router.Options) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// convertURLPath converts the URL path parameters(e.g. `/accounts/:id` and `/files/*filepath`) to the OpenAPI style,
+// returns the names of the path parameters
+func convertURLPath(urlPath string) (string, []string) {
+	segments := strings.Split(urlPath, "/")
+	params := make([]string, 0)
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+
+	return strings.Join(segments, "/"), params
+}
+
+func (p *PathItem) set(httpMethod int, operation *Operation) {
+	switch httpMethod {
+	case constant.HTTPMethodPost:
+		p.Post = operation
+	case constant.HTTPMethodPut:
+		p.Put = operation
+	case constant.HTTPMethodPatch:
+		p.Patch = operation
+	case constant.HTTPMethodDelete:
+		p.Delete = operation
+	case constant.HTTPMethodOptions:
+		p.Options = operation
+	case constant.HTTPMethodHead:
+		p.Head = operation
+	default:
+		p.Get = operation
+	}
+}
+
+// handlerName returns the name of the handler, default the name of the handler type
+func handlerName(handlerOptions *router.Options) string {
+	if "" != handlerOptions.HandlerOptions.HandlerName {
+		return handlerOptions.HandlerOptions.HandlerName
+	}
+	if nil != handlerOptions.HandlerOptions.HandlerReflectType {
+		return typeName(handlerOptions.HandlerOptions.HandlerReflectType)
+	}
+
+	return ""
+}
+
+// operationID returns the unique operation ID of the handler method
+func operationID(handlerOptions *router.Options, operationIDs map[string]int) string {
+	id := handlerName(handlerOptions) + "." + handlerOptions.HandlerOptions.HandlerMethodName
+	// the name of the handler function contains the package name, e.g. account.getAccount
+	if nil != handlerOptions.HandlerOptions.Func && "" == handlerOptions.HandlerOptions.HandlerName {
+		id = handlerOptions.HandlerOptions.Func.Name()
+	}
+	operationIDs[id]++
+	if n := operationIDs[id]; n > 1 {
+		id = id + strconv.Itoa(n)
+	}
+
+	return id
+}
+
+func requestType(handlerOptions *router.Options) reflect.Type {
+	if len(handlerOptions.HandlerOptions.HandlerMethodInParams) > 0 {
+		return handlerOptions.HandlerOptions.HandlerMethodInParams[0]
+	}
+
+	return nil
+}
+
+func responseType(handlerOptions *router.Options) reflect.Type {
+	// the last out parameter is the error
+	if len(handlerOptions.HandlerOptions.HandlerMethodOutParams) > 1 {
+		return handlerOptions.HandlerOptions.HandlerMethodOutParams[0]
+	}
+
+	return nil
+}
+
+// mediaTypes returns the media types of the request and response bodies of the handler, the content type declared by
+// the codec of the handler comes first(the codecs without the declared content type are regarded as JSON),
+// followed by the content types accepted by the handler(see router.WithContentTypes).
+func mediaTypes(handlerOptions *router.Options) []string {
+	primary := codec.ContentTypeOf(handlerOptions.HandlerOptions.Codec)
+	if "" == primary {
+		primary = constant.DefaultContentTypeJSON
+	}
+	result := []string{registry.MediaType(primary)}
+	for _, contentType := range handlerOptions.HandlerOptions.ContentTypes {
+		mediaType := registry.MediaType(contentType)
+		exists := false
+		for _, m := range result {
+			if m == mediaType {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			result = append(result, mediaType)
+		}
+	}
+
+	return result
+}
+
+// contentOf returns the content of the body with the schema for each media type
+func contentOf(mediaTypes []string, schema *Schema) map[string]*MediaType {
+	content := make(map[string]*MediaType, len(mediaTypes))
+	for _, mediaType := range mediaTypes {
+		content[mediaType] = &MediaType{Schema: schema}
+	}
+
+	return content
+}
+
+func hasRequestBody(httpMethod int) bool {
+	return constant.HTTPMethodPost == httpMethod || constant.HTTPMethodPut == httpMethod || constant.HTTPMethodPatch == httpMethod
+}
+
+func (g *schemaGenerator) operation(handlerOptions *router.Options, pathParams []string, operationIDs map[string]int, options *Options) *Operation {
+	operation := &Operation{
+		Tags:        []string{handlerName(handlerOptions)},
+		OperationID: operationID(handlerOptions, operationIDs),
+		Responses:   make(map[string]*Response),
+	}
+
+	bodyMediaTypes := mediaTypes(handlerOptions)
+	in := requestType(handlerOptions)
+	if nil != in {
+		operation.Parameters = g.parameters(in)
+		if hasRequestBody(handlerOptions.HandlerOptions.HTTPMethod) {
+			operation.RequestBody = &RequestBody{
+				Required: true,
+				Content:  contentOf(bodyMediaTypes, g.schemaOf(in)),
+			}
+		}
+	}
+	// the path parameters must be documented even if they are not bound into the request
+	for _, name := range pathParams {
+		if !hasParameter(operation.Parameters, name, binding.PathTag) {
+			operation.Parameters = append(operation.Parameters, &Parameter{Name: name, In: binding.PathTag, Required: true, Schema: &Schema{Type: "string"}})
+		}
+	}
+
+	success := &Response{Description: http.StatusText(http.StatusOK)}
+	if out := responseType(handlerOptions); nil != out {
+		success.Content = contentOf(bodyMediaTypes, g.schemaOf(out))
+	}
+	operation.Responses[strconv.Itoa(http.StatusOK)] = success
+
+	// the errors are returned with the error code and error message in the response headers
+	if 0 == len(options.ErrorHTTPStatuses) {
+		operation.Responses["default"] = errorResponse("Error")
+	}
+	for _, status := range options.ErrorHTTPStatuses {
+		operation.Responses[strconv.Itoa(status)] = errorResponse(http.StatusText(status))
+	}
+
+	return operation
+}
+
+func errorResponse(description string) *Response {
+	return &Response{
+		Description: description,
+		Headers: map[string]*Header{
+			constant.ReturnErrorCode: {Description: "The error code", Schema: &Schema{Type: "string"}},
+			constant.ReturnErrorMsg:  {Description: "The error message", Schema: &Schema{Type: "string"}},
+		},
+	}
+}
+
+func hasParameter(parameters []*Parameter, name, in string) bool {
+	for _, parameter := range parameters {
+		if parameter.Name == name && parameter.In == in {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (g *schemaGenerator) channel(handlerOptions *router.Options, operationIDs map[string]int) *Channel {
+	contentType := mediaTypes(handlerOptions)[0]
+	channel := &Channel{
+		MatchType: matchTypes[handlerOptions.HandlerOptions.RegisterType],
+		Subscribe: &EventOperation{
+			OperationID: operationID(handlerOptions, operationIDs),
+			Tags:        []*Tag{{Name: handlerName(handlerOptions)}},
+			Message:     &EventMessage{ContentType: contentType},
+		},
+	}
+	if nil != handlerOptions.Compensable {
+		channel.Description = "Compensable transaction handler"
+	}
+	if in := requestType(handlerOptions); nil != in {
+		channel.Subscribe.Message.Name = typeName(in)
+		channel.Subscribe.Message.Payload = g.schemaOf(in)
+	}
+	if out := responseType(handlerOptions); nil != out {
+		channel.Subscribe.Reply = &EventMessage{
+			Name:        typeName(out),
+			ContentType: contentType,
+			Payload:     g.schemaOf(out),
+		}
+	}
+
+	return channel
+}
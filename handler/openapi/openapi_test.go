@@ -0,0 +1,167 @@
+package openapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"git.multiverse.io/eventkit/kit/codec"
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/handler/base"
+	"git.multiverse.io/eventkit/kit/handler/router"
+)
+
+type GetAccountRequest struct {
+	ID     string   `path:"id"`
+	Fields []string `query:"fields" validate:"max=5"`
+}
+
+type CreateAccountRequest struct {
+	Name    string   `json:"name" validate:"required,min=1,max=64"`
+	Email   string   `json:"email" validate:"omitempty,email"`
+	Type    string   `json:"type" validate:"oneof=PERSONAL CORPORATE"`
+	Balance float64  `json:"balance" validate:"gte=0"`
+	Tags    []string `json:"tags" validate:"dive,alphanum"`
+}
+
+type Account struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Parent  *Account
+	private string
+}
+
+type AccountHandler struct {
+	base.Handler
+}
+
+func (h *AccountHandler) Get(request *GetAccountRequest) (*Account, *errors.Error) {
+	return &Account{ID: request.ID}, nil
+}
+
+func (h *AccountHandler) Create(request *CreateAccountRequest) (*Account, *errors.Error) {
+	return &Account{Name: request.Name}, nil
+}
+
+func (h *AccountHandler) Delete() *errors.Error {
+	return nil
+}
+
+func TestConvertURLPath(t *testing.T) {
+	path, params := convertURLPath("/v1/accounts/:id/files/*filepath")
+	assert.Equal(t, "/v1/accounts/{id}/files/{filepath}", path)
+	assert.Equal(t, []string{"id", "filepath"}, params)
+
+	path, params = convertURLPath("/v1/accounts")
+	assert.Equal(t, "/v1/accounts", path)
+	assert.Equal(t, 0, len(params))
+}
+
+func TestGenerate(t *testing.T) {
+	handlerRouter := &router.HandlerRouter{}
+	handlerRouter.Router("GetAccount", &AccountHandler{}, router.Method("Get"), router.HandleGet("/v1/accounts/:id"))
+	handlerRouter.Router("CreateAccount", &AccountHandler{}, router.Method("Create"), router.HandlePost("/v1/accounts"))
+	handlerRouter.Router("DeleteAccount", &AccountHandler{}, router.Method("Delete"), router.HandleDelete("/v1/closed-accounts/:id"))
+	handlerRouter.RouterPrefix("ACCOUNT_", &AccountHandler{}, router.Method("Create"))
+
+	document := Generate(handlerRouter, WithTitle("account"), WithVersion("2.0.0"), WithErrorHTTPStatuses(http.StatusBadRequest, http.StatusNotFound))
+	assert.Equal(t, Version, document.OpenAPI)
+	assert.Equal(t, "account", document.Info.Title)
+	assert.Equal(t, "2.0.0", document.Info.Version)
+	assert.Equal(t, 3, len(document.Paths))
+
+	// the path parameters and the query arguments
+	get := document.Paths["/v1/accounts/{id}"].Get
+	assert.NotNil(t, get)
+	assert.Equal(t, "AccountHandler.Get", get.OperationID)
+	assert.True(t, nil == get.RequestBody)
+	assert.Equal(t, 2, len(get.Parameters))
+	assert.Equal(t, "id", get.Parameters[0].Name)
+	assert.Equal(t, "path", get.Parameters[0].In)
+	assert.True(t, get.Parameters[0].Required)
+	assert.Equal(t, "fields", get.Parameters[1].Name)
+	assert.Equal(t, "query", get.Parameters[1].In)
+	assert.False(t, get.Parameters[1].Required)
+	assert.Equal(t, "array", get.Parameters[1].Schema.Type)
+	assert.Equal(t, 5, *get.Parameters[1].Schema.MaxItems)
+	assert.Equal(t, "#/components/schemas/Account", get.Responses["200"].Content[constant.DefaultContentTypeJSON].Schema.Ref)
+	assert.NotNil(t, get.Responses["400"])
+	assert.NotNil(t, get.Responses["404"])
+	assert.NotNil(t, get.Responses["404"].Headers[constant.ReturnErrorCode])
+	assert.True(t, nil == get.Responses["default"])
+
+	// the request body
+	post := document.Paths["/v1/accounts"].Post
+	assert.NotNil(t, post)
+	assert.Equal(t, "#/components/schemas/CreateAccountRequest", post.RequestBody.Content[constant.DefaultContentTypeJSON].Schema.Ref)
+
+	// the path parameters not bound into the request and the handler without response
+	del := document.Paths["/v1/closed-accounts/{id}"].Delete
+	assert.NotNil(t, del)
+	assert.Equal(t, 1, len(del.Parameters))
+	assert.Equal(t, "id", del.Parameters[0].Name)
+	assert.True(t, nil == del.Responses["200"].Content)
+
+	// the fields bound from the URL path are not the part of the body
+	assert.True(t, nil == document.Components.Schemas["GetAccountRequest"].Properties)
+	account := document.Components.Schemas["Account"]
+	assert.Equal(t, 3, len(account.Properties))
+	assert.Equal(t, "#/components/schemas/Account", account.Properties["Parent"].Ref)
+
+	// the event handlers
+	assert.Equal(t, AsyncAPIVersion, document.AsyncAPI.AsyncAPI)
+	assert.Equal(t, 4, len(document.AsyncAPI.Channels))
+	assert.Equal(t, MatchExact, document.AsyncAPI.Channels["CreateAccount"].MatchType)
+	assert.Equal(t, MatchPrefix, document.AsyncAPI.Channels["ACCOUNT_"].MatchType)
+	assert.Equal(t, "AccountHandler.Create3", document.AsyncAPI.Channels["ACCOUNT_"].Subscribe.OperationID)
+	assert.Equal(t, "#/components/schemas/CreateAccountRequest", document.AsyncAPI.Channels["ACCOUNT_"].Subscribe.Message.Payload.Ref)
+	assert.Equal(t, "#/components/schemas/Account", document.AsyncAPI.Channels["ACCOUNT_"].Subscribe.Reply.Payload.Ref)
+
+	_, err := json.Marshal(document)
+	assert.True(t, nil == err)
+}
+
+func TestGenerateWithoutRouter(t *testing.T) {
+	document := Generate(nil)
+	assert.Equal(t, "API", document.Info.Title)
+	assert.Equal(t, 0, len(document.Paths))
+	assert.True(t, nil == document.AsyncAPI)
+	assert.True(t, nil == document.Components)
+}
+
+func getAccount(ctx context.Context, request *GetAccountRequest) (*Account, error) {
+	return &Account{ID: request.ID}, nil
+}
+
+func TestGenerateWithHandlerFunction(t *testing.T) {
+	handlerRouter := &router.HandlerRouter{}
+	handlerRouter.Router("GetAccount", router.Func(getAccount), router.HandleGet("/v1/accounts/:id"))
+
+	get := Generate(handlerRouter).Paths["/v1/accounts/{id}"].Get
+	assert.NotNil(t, get)
+	assert.Equal(t, "openapi.getAccount", get.OperationID)
+	assert.Equal(t, 2, len(get.Parameters))
+	assert.Equal(t, "#/components/schemas/Account", get.Responses["200"].Content[constant.DefaultContentTypeJSON].Schema.Ref)
+}
+
+func TestGenerateWithCodecMediaTypes(t *testing.T) {
+	handlerRouter := &router.HandlerRouter{}
+	handlerRouter.Router("CreateAccount", &AccountHandler{}, router.Method("Create"), router.HandlePost("/v1/accounts"),
+		router.WithCodec(codec.BuildProtobufCodec()), router.WithContentTypes(constant.DefaultContentTypeJSON))
+	handlerRouter.Router("CreateAccountEvent", &AccountHandler{}, router.Method("Create"),
+		router.WithCodec(codec.BuildProtobufCodec()))
+
+	document := Generate(handlerRouter)
+	post := document.Paths["/v1/accounts"].Post
+	assert.Equal(t, 2, len(post.RequestBody.Content))
+	assert.Equal(t, "#/components/schemas/CreateAccountRequest", post.RequestBody.Content[constant.ContentTypeProtobuf].Schema.Ref)
+	assert.Equal(t, "#/components/schemas/CreateAccountRequest", post.RequestBody.Content[constant.DefaultContentTypeJSON].Schema.Ref)
+	assert.Equal(t, "#/components/schemas/Account", post.Responses["200"].Content[constant.ContentTypeProtobuf].Schema.Ref)
+
+	channel := document.AsyncAPI.Channels["CreateAccountEvent"]
+	assert.Equal(t, constant.ContentTypeProtobuf, channel.Subscribe.Message.ContentType)
+	assert.Equal(t, constant.ContentTypeProtobuf, channel.Subscribe.Reply.ContentType)
+}
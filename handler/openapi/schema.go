@@ -0,0 +1,381 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/handler/binding"
+)
+
+// ValidateTag is the tag of the validation rules
+const ValidateTag = "validate"
+
+// Schema is the schema of the data types
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     bool               `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     bool               `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+}
+
+var (
+	timeType           = reflect.TypeOf(time.Time{})
+	durationType       = reflect.TypeOf(time.Duration(0))
+	rawMessageType     = reflect.TypeOf(json.RawMessage{})
+	invalidSchemaChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+)
+
+// formatRules maps the validation rules to the formats of the strings
+var formatRules = map[string]string{
+	"email":    "email",
+	"url":      "uri",
+	"uri":      "uri",
+	"uuid":     "uuid",
+	"uuid4":    "uuid",
+	"ipv4":     "ipv4",
+	"ipv6":     "ipv6",
+	"hostname": "hostname",
+	"datetime": "date-time",
+}
+
+// patternRules maps the validation rules to the patterns of the strings
+var patternRules = map[string]string{
+	"alpha":    "^[a-zA-Z]+$",
+	"alphanum": "^[a-zA-Z0-9]+$",
+	"numeric":  "^[-+]?[0-9]+(?:\\.[0-9]+)?$",
+	"number":   "^[0-9]+$",
+}
+
+// schemaGenerator generates the schemas of the types, the named structs are stored as the components
+type schemaGenerator struct {
+	schemas map[string]*Schema
+	names   map[reflect.Type]string
+}
+
+func newSchemaGenerator() *schemaGenerator {
+	return &schemaGenerator{
+		schemas: make(map[string]*Schema),
+		names:   make(map[reflect.Type]string),
+	}
+}
+
+func typeName(t reflect.Type) string {
+	for reflect.Ptr == t.Kind() {
+		t = t.Elem()
+	}
+
+	return t.Name()
+}
+
+// componentName returns the unique component name of the named struct,
+// the name is qualified with the package if the same name is used by another type
+func (g *schemaGenerator) componentName(t reflect.Type) string {
+	if name, ok := g.names[t]; ok {
+		return name
+	}
+	name := t.Name()
+	for _, existing := range g.names {
+		if existing == name {
+			name = invalidSchemaChars.ReplaceAllString(t.PkgPath()+"."+t.Name(), "_")
+			break
+		}
+	}
+	g.names[t] = name
+
+	return name
+}
+
+func (g *schemaGenerator) schemaOf(t reflect.Type) *Schema {
+	for reflect.Ptr == t.Kind() {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case durationType:
+		return &Schema{Type: "integer", Format: "int64"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int8, reflect.Int16, reflect.Int32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int, reflect.Int64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32", Minimum: float64Of(0)}
+	case reflect.Uint, reflect.Uint64, reflect.Uintptr:
+		return &Schema{Type: "integer", Format: "int64", Minimum: float64Of(0)}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if reflect.Uint8 == t.Elem().Kind() {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if "" == t.Name() {
+			return g.structSchema(t)
+		}
+		name := g.componentName(t)
+		if _, ok := g.schemas[name]; !ok {
+			// stores a placeholder first to stop the recursion of the self-referencing types
+			g.schemas[name] = &Schema{}
+			*g.schemas[name] = *g.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		return &Schema{}
+	}
+}
+
+// jsonName returns the JSON name of the field, false if the field isn't encoded
+func jsonName(field reflect.StructField) (string, bool) {
+	tag, ok := field.Tag.Lookup("json")
+	if "-" == tag {
+		return "", false
+	}
+	name := strings.Split(tag, ",")[0]
+	// the fields bound from the path parameters or the query arguments are not the part of the body
+	if !ok && ("" != field.Tag.Get(binding.PathTag) || "" != field.Tag.Get(binding.QueryTag)) {
+		return "", false
+	}
+	if "" == name {
+		name = field.Name
+	}
+
+	return name, true
+}
+
+func (g *schemaGenerator) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	g.addFields(schema, t)
+	if 0 == len(schema.Properties) {
+		schema.Properties = nil
+	}
+
+	return schema
+}
+
+func (g *schemaGenerator) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		fieldType := field.Type
+		for reflect.Ptr == fieldType.Kind() {
+			fieldType = fieldType.Elem()
+		}
+		// the fields of the embedded structs without the JSON name are promoted
+		if field.Anonymous && reflect.Struct == fieldType.Kind() && "" == strings.Split(field.Tag.Get("json"), ",")[0] {
+			g.addFields(schema, fieldType)
+			continue
+		}
+		if "" != field.PkgPath {
+			continue
+		}
+		name, ok := jsonName(field)
+		if !ok {
+			continue
+		}
+		fieldSchema := g.schemaOf(field.Type)
+		if applyValidateRules(fieldSchema, field.Type, field.Tag.Get(ValidateTag)) {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = fieldSchema
+	}
+}
+
+// parameters returns the path parameters and the query arguments bound into the request struct
+func (g *schemaGenerator) parameters(t reflect.Type) []*Parameter {
+	for reflect.Ptr == t.Kind() {
+		t = t.Elem()
+	}
+	if reflect.Struct != t.Kind() {
+		return nil
+	}
+
+	parameters := make([]*Parameter, 0)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && reflect.Struct == field.Type.Kind() {
+			parameters = append(parameters, g.parameters(field.Type)...)
+			continue
+		}
+		for _, in := range []string{binding.PathTag, binding.QueryTag} {
+			name := field.Tag.Get(in)
+			if "" == name {
+				continue
+			}
+			parameter := &Parameter{Name: name, In: in, Schema: g.schemaOf(field.Type)}
+			required := applyValidateRules(parameter.Schema, field.Type, field.Tag.Get(ValidateTag))
+			parameter.Required = binding.PathTag == in || required
+			parameters = append(parameters, parameter)
+		}
+	}
+	if 0 == len(parameters) {
+		return nil
+	}
+
+	return parameters
+}
+
+func float64Of(f float64) *float64 {
+	return &f
+}
+
+func intOf(i int) *int {
+	return &i
+}
+
+// applyValidateRules maps the validation rules to the constraints of the schema, returns true if the field is required.
+// The rules after `dive` are applied to the items of the slices.
+func applyValidateRules(schema *Schema, t reflect.Type, rules string) bool {
+	if "" == rules || "-" == rules {
+		return false
+	}
+	for reflect.Ptr == t.Kind() {
+		t = t.Elem()
+	}
+
+	required := false
+	parts := strings.Split(rules, ",")
+	for i, rule := range parts {
+		if "dive" == rule {
+			if nil != schema.Items && (reflect.Slice == t.Kind() || reflect.Array == t.Kind()) {
+				applyValidateRules(schema.Items, t.Elem(), strings.Join(parts[i+1:], ","))
+			}
+			break
+		}
+		// the or-ed rules can not be mapped
+		if strings.Contains(rule, "|") {
+			continue
+		}
+		name, param := rule, ""
+		if idx := strings.Index(rule, "="); idx >= 0 {
+			name, param = rule[:idx], rule[idx+1:]
+		}
+		if "required" == name {
+			required = true
+			continue
+		}
+		// the constraints can not be the siblings of the reference
+		if "" != schema.Ref {
+			continue
+		}
+		applyValidateRule(schema, t, name, param)
+	}
+
+	return required
+}
+
+func applyValidateRule(schema *Schema, t reflect.Type, name, param string) {
+	if format, ok := formatRules[name]; ok {
+		schema.Format = format
+		return
+	}
+	if pattern, ok := patternRules[name]; ok {
+		schema.Pattern = pattern
+		return
+	}
+
+	switch name {
+	case "oneof":
+		for _, v := range strings.Fields(param) {
+			schema.Enum = append(schema.Enum, enumValue(schema, v))
+		}
+	case "eq":
+		schema.Enum = []interface{}{enumValue(schema, param)}
+	case "startswith":
+		schema.Pattern = "^" + regexp.QuoteMeta(param)
+	case "endswith":
+		schema.Pattern = regexp.QuoteMeta(param) + "$"
+	case "len", "min", "max", "gt", "gte", "lt", "lte":
+		applyRangeRule(schema, t, name, param)
+	}
+}
+
+func enumValue(schema *Schema, v string) interface{} {
+	switch schema.Type {
+	case "integer":
+		if i, err := strconv.ParseInt(v, 10, 64); nil == err {
+			return i
+		}
+	case "number":
+		if f, err := strconv.ParseFloat(v, 64); nil == err {
+			return f
+		}
+	}
+
+	return v
+}
+
+// applyRangeRule maps the range rules to the value range of the numbers, the length of the strings or the size of the arrays
+func applyRangeRule(schema *Schema, t reflect.Type, name, param string) {
+	f, err := strconv.ParseFloat(param, 64)
+	if nil != err {
+		return
+	}
+
+	switch schema.Type {
+	case "integer", "number":
+		switch name {
+		case "len":
+			schema.Minimum, schema.Maximum = float64Of(f), float64Of(f)
+		case "min", "gte":
+			schema.Minimum = float64Of(f)
+		case "max", "lte":
+			schema.Maximum = float64Of(f)
+		case "gt":
+			schema.Minimum, schema.ExclusiveMinimum = float64Of(f), true
+		case "lt":
+			schema.Maximum, schema.ExclusiveMaximum = float64Of(f), true
+		}
+	case "string", "array":
+		if reflect.Map == t.Kind() {
+			return
+		}
+		n := int(f)
+		minimum, maximum := &schema.MinLength, &schema.MaxLength
+		if "array" == schema.Type {
+			minimum, maximum = &schema.MinItems, &schema.MaxItems
+		}
+		switch name {
+		case "len":
+			*minimum, *maximum = intOf(n), intOf(n)
+		case "min", "gte":
+			*minimum = intOf(n)
+		case "max", "lte":
+			*maximum = intOf(n)
+		case "gt":
+			*minimum = intOf(n + 1)
+		case "lt":
+			*maximum = intOf(n - 1)
+		}
+	}
+}
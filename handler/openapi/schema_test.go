@@ -0,0 +1,83 @@
+package openapi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+)
+
+type Embedded struct {
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type Transfer struct {
+	Embedded
+	From     string            `json:"from" validate:"required,len=10,numeric"`
+	Amount   int64             `json:"amount" validate:"required,gt=0,lte=100000"`
+	Currency string            `json:"currency" validate:"oneof=CNY USD"`
+	Priority int               `json:"priority" validate:"oneof=1 2 3"`
+	Memo     *string           `json:"memo,omitempty" validate:"omitempty,max=128"`
+	Labels   map[string]string `json:"labels"`
+	Data     []byte            `json:"data"`
+	Timeout  time.Duration     `json:"timeout"`
+	Ignored  string            `json:"-"`
+	Query    string            `query:"q"`
+}
+
+func TestSchemaOf(t *testing.T) {
+	g := newSchemaGenerator()
+	schema := g.schemaOf(reflect.TypeOf(&Transfer{}))
+	assert.Equal(t, "#/components/schemas/Transfer", schema.Ref)
+
+	transfer := g.schemas["Transfer"]
+	assert.Equal(t, "object", transfer.Type)
+	assert.Equal(t, []string{"from", "amount"}, transfer.Required)
+	assert.Equal(t, 9, len(transfer.Properties))
+
+	assert.Equal(t, "date-time", transfer.Properties["createdAt"].Format)
+	assert.Equal(t, 10, *transfer.Properties["from"].MinLength)
+	assert.Equal(t, 10, *transfer.Properties["from"].MaxLength)
+	assert.Equal(t, patternRules["numeric"], transfer.Properties["from"].Pattern)
+	assert.Equal(t, float64(0), *transfer.Properties["amount"].Minimum)
+	assert.True(t, transfer.Properties["amount"].ExclusiveMinimum)
+	assert.Equal(t, float64(100000), *transfer.Properties["amount"].Maximum)
+	assert.Equal(t, []interface{}{"CNY", "USD"}, transfer.Properties["currency"].Enum)
+	assert.Equal(t, []interface{}{int64(1), int64(2), int64(3)}, transfer.Properties["priority"].Enum)
+	assert.Equal(t, 128, *transfer.Properties["memo"].MaxLength)
+	assert.Equal(t, "string", transfer.Properties["labels"].AdditionalProperties.Type)
+	assert.Equal(t, "byte", transfer.Properties["data"].Format)
+	assert.Equal(t, "integer", transfer.Properties["timeout"].Type)
+	_, ok := transfer.Properties["Ignored"]
+	assert.False(t, ok)
+	_, ok = transfer.Properties["Query"]
+	assert.False(t, ok)
+}
+
+func TestApplyValidateRulesWithDive(t *testing.T) {
+	g := newSchemaGenerator()
+	tags := []string{}
+	schema := g.schemaOf(reflect.TypeOf(tags))
+	required := applyValidateRules(schema, reflect.TypeOf(tags), "required,min=1,dive,email,max=64")
+	assert.True(t, required)
+	assert.Equal(t, 1, *schema.MinItems)
+	assert.Equal(t, "email", schema.Items.Format)
+	assert.Equal(t, 64, *schema.Items.MaxLength)
+
+	// the or-ed rules are ignored
+	schema = g.schemaOf(reflect.TypeOf(""))
+	assert.False(t, applyValidateRules(schema, reflect.TypeOf(""), "email|url"))
+	assert.Equal(t, "", schema.Format)
+}
+
+func TestComponentNameConflict(t *testing.T) {
+	type Transfer struct {
+		ID string
+	}
+	g := newSchemaGenerator()
+	assert.Equal(t, "#/components/schemas/Transfer", g.schemaOf(reflect.TypeOf(Transfer{})).Ref)
+	ref := g.schemaOf(reflect.TypeOf(Embedded{})).Ref
+	assert.Equal(t, "#/components/schemas/Embedded", ref)
+	assert.Equal(t, 2, len(g.schemas))
+}
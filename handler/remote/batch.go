@@ -0,0 +1,111 @@
+package remote
+
+import (
+	"context"
+
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/constant"
+)
+
+// SUBatch is a sub-batch of the items that routed to the same SU
+type SUBatch[T any] struct {
+	SU string
+	// Indexes are the indexes of the items in the original batch
+	Indexes []int
+	Items   []T
+}
+
+// SplitBySU splits the items into per-SU sub-batches according to the SU IDs(in the same order of the items,
+// e.g. addressing.BatchLookupResult.SuIDs), returns the indexes of the items whose SU ID is empty as not found.
+// The sub-batches are in the order of the first occurrence of each SU.
+func SplitBySU[T any](items []T, suIDs []string) ([]*SUBatch[T], []int, *errors.Error) {
+	if len(items) != len(suIDs) {
+		return nil, nil, errors.Errorf(constant.SystemInternalError,
+			"the number of items[%d] is not equal to the number of SU IDs[%d]", len(items), len(suIDs))
+	}
+
+	batches := make([]*SUBatch[T], 0)
+	batchIndexOfSU := make(map[string]int)
+	notFound := make([]int, 0)
+	for i, su := range suIDs {
+		if "" == su {
+			notFound = append(notFound, i)
+			continue
+		}
+		idx, ok := batchIndexOfSU[su]
+		if !ok {
+			idx = len(batches)
+			batchIndexOfSU[su] = idx
+			batches = append(batches, &SUBatch[T]{SU: su})
+		}
+		batches[idx].Indexes = append(batches[idx].Indexes, i)
+		batches[idx].Items = append(batches[idx].Items, items[i])
+	}
+
+	return batches, notFound, nil
+}
+
+// SubBatchCall defines the call of a sub-batch, the replies must be in the same order of the items
+type SubBatchCall[T any, R any] func(ctx context.Context, su string, items []T) ([]R, *errors.Error)
+
+// ItemReply is the merged reply of an item in the original batch
+type ItemReply[R any] struct {
+	SU    string
+	Reply R
+	Error *errors.Error
+}
+
+// CallBySU splits the items into per-SU sub-batches, calls the sub-batches concurrently(see ScatterGather)
+// and merges the replies into the original order of the items.
+// The items whose SU ID is empty and the items of the failed sub-batches are replied with an error,
+// the returned error is the error of ScatterGather decided by the policy.
+func CallBySU[T any, R any](ctx context.Context, items []T, suIDs []string, call SubBatchCall[T, R], opts ...ScatterOption) ([]*ItemReply[R], *errors.Error) {
+	batches, notFound, err := SplitBySU(items, suIDs)
+	if nil != err {
+		return nil, err
+	}
+
+	replies := make([]*ItemReply[R], len(items))
+	for _, i := range notFound {
+		replies[i] = &ItemReply[R]{
+			Error: errors.Errorf(constant.RecordsNotFound, "cannot found the SU of item with index:[%d]", i),
+		}
+	}
+
+	branches := make([]Branch, 0, len(batches))
+	for _, batch := range batches {
+		batch := batch
+		branches = append(branches, Branch{
+			Name: batch.SU,
+			Fn: func(ctx context.Context) (interface{}, *errors.Error) {
+				subReplies, err := call(ctx, batch.SU, batch.Items)
+				if nil != err {
+					return nil, err
+				}
+				if len(subReplies) != len(batch.Items) {
+					return nil, errors.Errorf(constant.SystemInternalError,
+						"the number of replies[%d] from SU[%s] is not equal to the number of items[%d]",
+						len(subReplies), batch.SU, len(batch.Items))
+				}
+				return subReplies, nil
+			},
+		})
+	}
+
+	results, scatterErr := ScatterGather(ctx, branches, opts...)
+	for bi, result := range results {
+		batch := batches[bi]
+		subReplies, _ := result.Value.([]R)
+		for j, i := range batch.Indexes {
+			reply := &ItemReply[R]{SU: batch.SU}
+			if result.IsSuccess() {
+				reply.Reply = subReplies[j]
+			} else {
+				reply.Error = result.Error
+			}
+			replies[i] = reply
+		}
+	}
+
+	return replies, scatterErr
+}
@@ -0,0 +1,47 @@
+package remote
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/constant"
+)
+
+func TestSplitBySU(t *testing.T) {
+	batches, notFound, err := SplitBySU([]string{"a", "b", "c", "d"}, []string{"su1", "su2", "", "su1"})
+	assert.True(t, nil == err)
+	assert.Equal(t, 2, len(batches))
+	assert.Equal(t, "su1", batches[0].SU)
+	assert.Equal(t, []string{"a", "d"}, batches[0].Items)
+	assert.Equal(t, []int{0, 3}, batches[0].Indexes)
+	assert.Equal(t, []int{2}, notFound)
+
+	_, _, err = SplitBySU([]string{"a"}, []string{})
+	assert.NotNil(t, err)
+}
+
+func TestCallBySU(t *testing.T) {
+	items := []string{"a", "b", "c", "d", "e"}
+	suIDs := []string{"su1", "su2", "", "su1", "su3"}
+	replies, err := CallBySU(context.Background(), items, suIDs,
+		func(ctx context.Context, su string, items []string) ([]string, *errors.Error) {
+			if "su3" == su {
+				return nil, errors.Errorf(constant.SystemInternalError, "su3 is unavailable")
+			}
+			subReplies := make([]string, 0, len(items))
+			for _, item := range items {
+				subReplies = append(subReplies, strings.ToUpper(item)+"@"+su)
+			}
+			return subReplies, nil
+		})
+	assert.True(t, nil == err)
+	assert.Equal(t, "A@su1", replies[0].Reply)
+	assert.Equal(t, "B@su2", replies[1].Reply)
+	assert.Equal(t, constant.RecordsNotFound, replies[2].Error.ErrorCode)
+	assert.Equal(t, "D@su1", replies[3].Reply)
+	assert.Equal(t, "su3", replies[4].SU)
+	assert.Equal(t, constant.SystemInternalError, replies[4].Error.ErrorCode)
+}
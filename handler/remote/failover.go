@@ -0,0 +1,20 @@
+package remote
+
+import (
+	"context"
+
+	"git.multiverse.io/eventkit/kit/client/mesh"
+	"git.multiverse.io/eventkit/kit/client/mesh/wrapper/addressing"
+	"git.multiverse.io/eventkit/kit/log"
+)
+
+// NewFailoverAlerter creates an alerter that publishes the failover events of addressing to the alert topic with event ID,
+// it can be set by addressing.SetFailoverAlerter.
+func NewFailoverAlerter(callInc CallInc, alertEventID string) addressing.FailoverAlerter {
+	return func(ctx context.Context, event *addressing.FailoverEvent) {
+		request := mesh.NewMeshRequest(event, mesh.WithTopicTypeAlert(), mesh.WithEventID(alertEventID))
+		if err := callInc.AsyncCalls(ctx, request); nil != err {
+			log.Errorf(ctx, "Failed to publish the failover alert[%++v], error:%s", event, err.Error())
+		}
+	}
+}
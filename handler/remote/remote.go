@@ -137,6 +137,10 @@ func (h *defaultRemoteCallImpl) SyncCalls(ctx context.Context, request client.Re
 		request.WithOptions(mesh.MarkLocalCall())
 	}
 
+	if derr := withDeadlineIfNecessary(finalCallCtx, request); nil != derr {
+		return nil, derr
+	}
+
 	if nil != response {
 		vi := reflect.ValueOf(response)
 		if vi.Kind() != reflect.Ptr {
@@ -205,6 +209,10 @@ func (h *defaultRemoteCallImpl) SyncCalls(ctx context.Context, request client.Re
 }
 
 func (h *defaultRemoteCallImpl) AsyncCalls(ctx context.Context, request client.Request, opts ...client.CallOption) *errors.Error {
+	if derr := withDeadlineIfNecessary(ctx, request); nil != derr {
+		return derr
+	}
+
 	e := h.Client.AsyncCall(ctx, request, opts...)
 	if e != nil {
 		var err *errors.Error
@@ -223,6 +231,36 @@ func (h *defaultRemoteCallImpl) AsyncCalls(ctx context.Context, request client.R
 	return nil
 }
 
+// withDeadlineIfNecessary caps the timeout and the max waiting time of the request by the deadline of the context,
+// the remaining budget of the handler is propagated to the downstream by the timeout of the request
+func withDeadlineIfNecessary(ctx context.Context, request client.Request) *errors.Error {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return nil
+	}
+	remaining := time.Until(deadline)
+	if remaining <= 0 {
+		return errors.Errorf(constant.SystemRemoteCallTimeout, "The deadline of the request is exceeded before calling the event[%s]", request.RequestOptions().EventID)
+	}
+
+	options := request.RequestOptions()
+	if options.Timeout <= 0 || options.Timeout > remaining {
+		request.WithOptions(mesh.WithTimeout(remaining))
+	}
+	if options.MaxWaitingTime <= 0 || options.MaxWaitingTime > remaining {
+		request.WithOptions(mesh.WithMaxWaitingTime(remaining))
+	}
+
+	return nil
+}
+
+// withCompressionIfNecessary sets the payload compression of the request with the downstream service config
+func withCompressionIfNecessary(request client.Request, downstreamConfigs *config.Downstream) {
+	if nil != downstreamConfigs && "" != downstreamConfigs.Compression.Algorithm {
+		request.WithOptions(mesh.WithCompression(downstreamConfigs.Compression.Algorithm, downstreamConfigs.Compression.Threshold))
+	}
+}
+
 func getCommunicateConfigs(downstreamConfigs *config.Downstream) (timeoutMilliseconds int, retryWaitingMilliseconds int,
 	maxWaitingTimeMilliseconds int, maxRetryTimes int, deleteTransactionPropagationInfo bool, protoType string,
 	httpAddress string, httpMethod string, httpContextType string) {
@@ -360,6 +398,8 @@ func (h *defaultRemoteCallImpl) SyncCall(ctx context.Context, dstSU, serviceKey
 		request.WithOptions(mesh.WithRetryWaitingMilliseconds(time.Duration(retryWaitingMilliseconds) * time.Millisecond))
 	}
 
+	withCompressionIfNecessary(request, serviceConfig)
+
 	return h.SyncCalls(callCtx, request, response, opts...)
 }
 
@@ -406,6 +446,8 @@ func (h *defaultRemoteCallImpl) AsyncCall(ctx context.Context, dstSU, serviceKey
 		)
 	}
 
+	withCompressionIfNecessary(request, serviceConfig)
+
 	return h.AsyncCalls(callCtx, request, opts...)
 }
 
@@ -488,6 +530,8 @@ func (h *defaultRemoteCallImpl) SyncCallw(ctx context.Context, elementType, elem
 		request.WithOptions(mesh.WithRetryWaitingMilliseconds(time.Duration(retryWaitingMilliseconds) * time.Millisecond))
 	}
 
+	withCompressionIfNecessary(request, serviceConfig)
+
 	return h.SyncCalls(callCtx, request, response, opts...)
 }
 
@@ -535,5 +579,7 @@ func (h *defaultRemoteCallImpl) AsyncCallw(ctx context.Context, elementType, ele
 		)
 	}
 
+	withCompressionIfNecessary(request, serviceConfig)
+
 	return h.AsyncCalls(callCtx, request, opts...)
 }
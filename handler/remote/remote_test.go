@@ -1 +1,50 @@
 package remote
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/client/mesh"
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/constant"
+)
+
+func TestWithDeadlineIfNecessary(t *testing.T) {
+	// the context without the deadline
+	request := mesh.NewMeshRequest(nil, mesh.WithTimeout(time.Minute))
+	assert.True(t, nil == withDeadlineIfNecessary(context.Background(), request))
+	assert.Equal(t, time.Minute, request.RequestOptions().Timeout)
+
+	// the remaining budget caps the timeout of the request
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	assert.True(t, nil == withDeadlineIfNecessary(ctx, request))
+	assert.True(t, request.RequestOptions().Timeout <= time.Second)
+	assert.True(t, request.RequestOptions().MaxWaitingTime <= time.Second)
+	assert.True(t, request.RequestOptions().Timeout > 0)
+
+	// the shorter timeout of the request is kept
+	request = mesh.NewMeshRequest(nil, mesh.WithTimeout(time.Millisecond), mesh.WithMaxWaitingTime(time.Millisecond))
+	assert.True(t, nil == withDeadlineIfNecessary(ctx, request))
+	assert.Equal(t, time.Millisecond, request.RequestOptions().Timeout)
+	assert.Equal(t, time.Millisecond, request.RequestOptions().MaxWaitingTime)
+
+	// the deadline is exceeded
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+	err := withDeadlineIfNecessary(expired, mesh.NewMeshRequest(nil, mesh.WithEventID("QueryCustomer")))
+	assert.NotNil(t, err)
+	assert.Equal(t, constant.SystemRemoteCallTimeout, err.ErrorCode)
+}
+
+func TestAsyncCallsWithDeadline(t *testing.T) {
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+
+	// the exceeded deadline fails the async call before the client is used
+	h := &defaultRemoteCallImpl{}
+	err := h.AsyncCalls(expired, mesh.NewMeshRequest(nil, mesh.WithEventID("NotifyCustomer")))
+	assert.NotNil(t, err)
+	assert.Equal(t, constant.SystemRemoteCallTimeout, err.ErrorCode)
+}
@@ -0,0 +1,378 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/util"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/contexts"
+	"git.multiverse.io/eventkit/kit/log"
+)
+
+// GatherPolicy defines when the scatter-gather call finishes gathering the results
+type GatherPolicy int
+
+const (
+	// WaitAll waits for all the branches finished(or the shared deadline exceeded)
+	WaitAll GatherPolicy = iota
+	// FailFast stops gathering and cancels the remaining branches once any branch failed
+	FailFast
+	// Quorum stops gathering once the minimum number of successful branches reached
+	Quorum
+)
+
+func (p GatherPolicy) String() string {
+	switch p {
+	case FailFast:
+		return "FailFast"
+	case Quorum:
+		return "Quorum"
+	default:
+		return "WaitAll"
+	}
+}
+
+// BranchFunc defines the function executed by a branch of the scatter-gather call,
+// the context passed in contains a copy of HandlerContexts and the shared deadline.
+type BranchFunc func(ctx context.Context) (interface{}, *errors.Error)
+
+// Branch defines a single call of the scatter-gather call
+type Branch struct {
+	Name string
+	Fn   BranchFunc
+}
+
+// NewBranch creates a branch that executes a typed sync call(see Call)
+func NewBranch[Req any, Resp any](name string, callInc CallInc, target Target, req Req, opts ...TypedCallOption) Branch {
+	return Branch{
+		Name: name,
+		Fn: func(ctx context.Context) (interface{}, *errors.Error) {
+			resp, _, err := Call[Req, Resp](ctx, callInc, target, req, opts...)
+			if nil != err {
+				return nil, err
+			}
+			return resp, nil
+		},
+	}
+}
+
+// BranchResult is the result of a single branch
+type BranchResult struct {
+	Name  string
+	Index int
+	Value interface{}
+	Error *errors.Error
+	Cost  time.Duration
+	Done  bool
+}
+
+// IsSuccess returns true if the branch is finished without error
+func (r *BranchResult) IsSuccess() bool {
+	return r.Done && nil == r.Error
+}
+
+func (r *BranchResult) String() string {
+	errStr := ""
+	if nil != r.Error {
+		errStr = r.Error.Error()
+	}
+	return fmt.Sprintf("BranchResult{Name:%s, Index:%d, Done:%v, Cost:%s, Error:%s}",
+		r.Name, r.Index, r.Done, r.Cost, errStr)
+}
+
+// ResultValue returns the typed value of the branch result
+func ResultValue[Resp any](result *BranchResult) (Resp, bool) {
+	var zero Resp
+	if nil == result || !result.IsSuccess() {
+		return zero, false
+	}
+	v, ok := result.Value.(Resp)
+	return v, ok
+}
+
+// ScatterOptions defines the options of the scatter-gather call
+type ScatterOptions struct {
+	Policy         GatherPolicy
+	MinSuccesses   int
+	Timeout        time.Duration
+	MaxConcurrency int
+}
+
+// ScatterOption sets the options of the scatter-gather call
+type ScatterOption func(*ScatterOptions)
+
+// WithWaitAll waits for all the branches finished
+func WithWaitAll() ScatterOption {
+	return func(o *ScatterOptions) {
+		o.Policy = WaitAll
+	}
+}
+
+// WithFailFast cancels the remaining branches once any branch failed
+func WithFailFast() ScatterOption {
+	return func(o *ScatterOptions) {
+		o.Policy = FailFast
+	}
+}
+
+// WithQuorum stops gathering once the number of successful branches reached minSuccesses
+func WithQuorum(minSuccesses int) ScatterOption {
+	return func(o *ScatterOptions) {
+		o.Policy = Quorum
+		o.MinSuccesses = minSuccesses
+	}
+}
+
+// WithScatterTimeout sets the shared deadline of all the branches,
+// the remaining budget of the current request will be used if it's shorter.
+func WithScatterTimeout(timeout time.Duration) ScatterOption {
+	return func(o *ScatterOptions) {
+		o.Timeout = timeout
+	}
+}
+
+// WithMaxConcurrency limits the number of branches running at the same time, 0 means unlimited
+func WithMaxConcurrency(maxConcurrency int) ScatterOption {
+	return func(o *ScatterOptions) {
+		o.MaxConcurrency = maxConcurrency
+	}
+}
+
+// remainingBudget returns the remaining time budget of the current request that calculated from the start time and TO3
+func remainingBudget(ctx context.Context) (time.Duration, bool) {
+	st := ctx.Value(constant.KeyST)
+	to3 := ctx.Value(constant.To3)
+	if nil == st || nil == to3 {
+		return 0, false
+	}
+	startTime, ok := st.(time.Time)
+	if !ok {
+		return 0, false
+	}
+	to3Time, ok := to3.(int)
+	if !ok || to3Time <= 0 {
+		return 0, false
+	}
+
+	return time.Duration(to3Time)*time.Millisecond - time.Since(startTime), true
+}
+
+// buildBranchContext creates the context for a branch, each branch owns a copy of HandlerContexts with a new child span
+// of the caller's span, and the start time and TO3 are reset so that the downstream calls share the same deadline.
+func buildBranchContext(ctx context.Context, deadline time.Time, hasDeadline bool) context.Context {
+	branchCtx := ctx
+	if handlerContexts := contexts.HandlerContextsFromContext(ctx); nil != handlerContexts {
+		copied := handlerContexts.Copy()
+		copied.StartHandleTime = handlerContexts.StartHandleTime
+		copied.DownstreamConfigs = handlerContexts.DownstreamConfigs
+		var parentSpanID string
+		if nil != handlerContexts.SpanContexts {
+			parentSpanID = handlerContexts.SpanContexts.SpanID
+		}
+		spanID := util.GenerateSerialNo(
+			handlerContexts.Org,
+			handlerContexts.Wks,
+			handlerContexts.Env,
+			handlerContexts.Su,
+			handlerContexts.InstanceID,
+			constant.SpanIDType)
+		if len(parentSpanID) == 0 {
+			parentSpanID = spanID
+		}
+		copied.With(
+			contexts.WithSpanID(spanID),
+			contexts.WithParentSpanID(parentSpanID),
+		)
+		branchCtx = contexts.BuildContextFromParentWithHandlerContexts(ctx, copied)
+	}
+
+	if hasDeadline {
+		now := time.Now()
+		branchCtx = context.WithValue(branchCtx, constant.KeyST, now)
+		branchCtx = context.WithValue(branchCtx, constant.To3, int(deadline.Sub(now).Milliseconds()))
+	}
+
+	return branchCtx
+}
+
+func runBranch(ctx context.Context, index int, branch Branch) (result *BranchResult) {
+	startTime := time.Now()
+	result = &BranchResult{
+		Name:  branch.Name,
+		Index: index,
+	}
+	defer func() {
+		if r := recover(); nil != r {
+			result.Value = nil
+			result.Error = errors.Errorf(constant.SystemInternalError, "scatter-gather branch[%s] panic:%v", branch.Name, r)
+		}
+		result.Cost = time.Since(startTime)
+		result.Done = true
+	}()
+
+	if nil == branch.Fn {
+		result.Error = errors.Errorf(constant.SystemInternalError, "scatter-gather branch[%s] has no function", branch.Name)
+		return
+	}
+	result.Value, result.Error = branch.Fn(ctx)
+	return
+}
+
+// ScatterGather runs the branches concurrently and gathers the results according to the policy,
+// the results are returned in the same order of the branches, the branches that not finished
+// before the deadline or canceled by the policy are marked with an error.
+// The returned error is:
+//   - WaitAll: always nil, please check the error of each result
+//   - FailFast: the first error of branches
+//   - Quorum: an error with code ScatterGatherQuorumNotReachedError if the quorum cannot be reached
+func ScatterGather(ctx context.Context, branches []Branch, opts ...ScatterOption) ([]*BranchResult, *errors.Error) {
+	options := &ScatterOptions{
+		Policy: WaitAll,
+	}
+	for _, o := range opts {
+		o(options)
+	}
+
+	total := len(branches)
+	results := make([]*BranchResult, total)
+	for i, branch := range branches {
+		results[i] = &BranchResult{Name: branch.Name, Index: i}
+	}
+	if 0 == total {
+		return results, nil
+	}
+
+	if Quorum == options.Policy && (options.MinSuccesses <= 0 || options.MinSuccesses > total) {
+		return results, errors.Errorf(constant.SystemInternalError,
+			"invalid quorum, min successes:[%d], total branches:[%d]", options.MinSuccesses, total)
+	}
+
+	var deadline time.Time
+	hasDeadline := false
+	if options.Timeout > 0 {
+		deadline = time.Now().Add(options.Timeout)
+		hasDeadline = true
+	}
+	if budget, ok := remainingBudget(ctx); ok {
+		budgetDeadline := time.Now().Add(budget)
+		if !hasDeadline || budgetDeadline.Before(deadline) {
+			deadline = budgetDeadline
+			hasDeadline = true
+		}
+	}
+	if parentDeadline, ok := ctx.Deadline(); ok && (!hasDeadline || parentDeadline.Before(deadline)) {
+		deadline = parentDeadline
+		hasDeadline = true
+	}
+
+	var gatherCtx context.Context
+	var cancel context.CancelFunc
+	if hasDeadline {
+		gatherCtx, cancel = context.WithDeadline(ctx, deadline)
+	} else {
+		gatherCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	var semaphore chan struct{}
+	if options.MaxConcurrency > 0 {
+		semaphore = make(chan struct{}, options.MaxConcurrency)
+	}
+
+	// buffered so that the branches finished after gathering won't be blocked
+	resultCh := make(chan *BranchResult, total)
+	for i, branch := range branches {
+		go func(index int, branch Branch) {
+			if nil != semaphore {
+				select {
+				case semaphore <- struct{}{}:
+					defer func() { <-semaphore }()
+				case <-gatherCtx.Done():
+					return
+				}
+			}
+			if nil != gatherCtx.Err() {
+				return
+			}
+			resultCh <- runBranch(buildBranchContext(gatherCtx, deadline, hasDeadline), index, branch)
+		}(i, branch)
+	}
+
+	successes, failures, finished := 0, 0, 0
+	var firstError *errors.Error
+	var resultErr *errors.Error
+	stopped := false
+
+	for !stopped && finished < total {
+		select {
+		case result := <-resultCh:
+			results[result.Index] = result
+			finished++
+			if nil == result.Error {
+				successes++
+			} else {
+				failures++
+				if nil == firstError {
+					firstError = result.Error
+				}
+			}
+
+			switch options.Policy {
+			case FailFast:
+				if nil != result.Error {
+					resultErr = result.Error
+					stopped = true
+				}
+			case Quorum:
+				if successes >= options.MinSuccesses {
+					stopped = true
+				} else if total-failures < options.MinSuccesses {
+					resultErr = errors.Errorf(constant.ScatterGatherQuorumNotReachedError,
+						"quorum cannot be reached, min successes:[%d], successes:[%d], failures:[%d], total:[%d], first error:[%s]",
+						options.MinSuccesses, successes, failures, total, firstError.Error())
+					stopped = true
+				}
+			}
+		case <-gatherCtx.Done():
+			stopped = true
+		}
+	}
+
+	timeoutExceeded := nil != gatherCtx.Err() && finished < total && !(FailFast == options.Policy && nil != resultErr)
+	cancel()
+
+	for _, result := range results {
+		if result.Done {
+			continue
+		}
+		if timeoutExceeded {
+			result.Error = errors.Errorf(constant.SystemRemoteCallTimeout,
+				"scatter-gather branch[%s] timeout, deadline:[%s]", result.Name, deadline.Format(time.RFC3339Nano))
+		} else {
+			result.Error = errors.Errorf(constant.ScatterGatherCanceledError,
+				"scatter-gather branch[%s] canceled by policy[%s]", result.Name, options.Policy)
+		}
+	}
+
+	if timeoutExceeded {
+		switch options.Policy {
+		case FailFast:
+			resultErr = errors.Errorf(constant.SystemRemoteCallTimeout,
+				"scatter-gather timeout, finished:[%d], total:[%d]", finished, total)
+		case Quorum:
+			if successes < options.MinSuccesses {
+				resultErr = errors.Errorf(constant.ScatterGatherQuorumNotReachedError,
+					"quorum is not reached before deadline, min successes:[%d], successes:[%d], total:[%d]",
+					options.MinSuccesses, successes, total)
+			}
+		}
+	}
+
+	log.Debugf(ctx, "scatter-gather finished, policy:[%s], total:[%d], finished:[%d], successes:[%d], failures:[%d], timeout:[%v]",
+		options.Policy, total, finished, successes, failures, timeoutExceeded)
+
+	return results, resultErr
+}
@@ -0,0 +1,157 @@
+package remote
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/contexts"
+)
+
+func successBranch(name string, delay time.Duration) Branch {
+	return Branch{
+		Name: name,
+		Fn: func(ctx context.Context) (interface{}, *errors.Error) {
+			time.Sleep(delay)
+			return name, nil
+		},
+	}
+}
+
+func failedBranch(name string, delay time.Duration) Branch {
+	return Branch{
+		Name: name,
+		Fn: func(ctx context.Context) (interface{}, *errors.Error) {
+			time.Sleep(delay)
+			return nil, errors.Errorf(constant.SystemInternalError, "branch %s failed", name)
+		},
+	}
+}
+
+func TestScatterGatherWaitAll(t *testing.T) {
+	ctx, handlerContexts := contexts.BuildContextFromParent(context.Background(), contexts.SU("su1"))
+	var branchHandlerContexts *contexts.HandlerContexts
+	branches := []Branch{
+		successBranch("a", 10*time.Millisecond),
+		failedBranch("b", 0),
+		{
+			Name: "c",
+			Fn: func(ctx context.Context) (interface{}, *errors.Error) {
+				branchHandlerContexts = contexts.HandlerContextsFromContext(ctx)
+				return 3, nil
+			},
+		},
+	}
+	results, err := ScatterGather(ctx, branches, WithWaitAll())
+	assert.True(t, nil == err)
+	assert.Equal(t, 3, len(results))
+	assert.True(t, results[0].IsSuccess())
+	assert.False(t, results[1].IsSuccess())
+	assert.True(t, results[2].IsSuccess())
+
+	v, ok := ResultValue[int](results[2])
+	assert.True(t, ok)
+	assert.Equal(t, 3, v)
+
+	// each branch owns a copy of the handler contexts
+	assert.NotNil(t, branchHandlerContexts)
+	assert.Equal(t, "su1", branchHandlerContexts.Su)
+	assert.True(t, handlerContexts != branchHandlerContexts)
+}
+
+func TestScatterGatherBranchSpans(t *testing.T) {
+	ctx, handlerContexts := contexts.BuildContextFromParent(context.Background(), contexts.SU("su1"),
+		contexts.WithTraceID("trace1"), contexts.WithSpanID("span1"), contexts.WithParentSpanID("span0"))
+	spans := make([]*contexts.SpanContexts, 2)
+	branches := make([]Branch, 0, len(spans))
+	for i := range spans {
+		i := i
+		branches = append(branches, Branch{
+			Name: "branch",
+			Fn: func(ctx context.Context) (interface{}, *errors.Error) {
+				spans[i] = contexts.HandlerContextsFromContext(ctx).SpanContexts
+				return nil, nil
+			},
+		})
+	}
+	_, err := ScatterGather(ctx, branches, WithWaitAll())
+	assert.True(t, nil == err)
+
+	// each branch owns a new child span of the caller's span
+	for _, span := range spans {
+		assert.Equal(t, "trace1", span.TraceID)
+		assert.Equal(t, "span1", span.ParentSpanID)
+		assert.True(t, "" != span.SpanID && "span1" != span.SpanID)
+	}
+	assert.True(t, spans[0].SpanID != spans[1].SpanID)
+	assert.Equal(t, "span1", handlerContexts.SpanContexts.SpanID)
+	assert.Equal(t, "span0", handlerContexts.SpanContexts.ParentSpanID)
+}
+
+func TestScatterGatherFailFast(t *testing.T) {
+	branches := []Branch{
+		successBranch("slow", time.Second),
+		failedBranch("failed", 0),
+	}
+	startTime := time.Now()
+	results, err := ScatterGather(context.Background(), branches, WithFailFast())
+	assert.NotNil(t, err)
+	assert.True(t, time.Since(startTime) < time.Second)
+	assert.Equal(t, constant.ScatterGatherCanceledError, results[0].Error.ErrorCode)
+	assert.Equal(t, constant.SystemInternalError, results[1].Error.ErrorCode)
+}
+
+func TestScatterGatherQuorum(t *testing.T) {
+	branches := []Branch{
+		successBranch("a", 0),
+		successBranch("b", 5*time.Millisecond),
+		successBranch("c", time.Second),
+	}
+	startTime := time.Now()
+	results, err := ScatterGather(context.Background(), branches, WithQuorum(2))
+	assert.True(t, nil == err)
+	assert.True(t, time.Since(startTime) < time.Second)
+	assert.False(t, results[2].IsSuccess())
+
+	branches = []Branch{
+		successBranch("a", 0),
+		failedBranch("b", 0),
+		failedBranch("c", 0),
+	}
+	_, err = ScatterGather(context.Background(), branches, WithQuorum(2))
+	assert.NotNil(t, err)
+	assert.Equal(t, constant.ScatterGatherQuorumNotReachedError, err.ErrorCode)
+
+	_, err = ScatterGather(context.Background(), branches, WithQuorum(4))
+	assert.NotNil(t, err)
+}
+
+func TestScatterGatherSharedDeadline(t *testing.T) {
+	var to3 interface{}
+	branches := []Branch{
+		successBranch("fast", 0),
+		successBranch("slow", time.Second),
+		{
+			Name: "budget",
+			Fn: func(ctx context.Context) (interface{}, *errors.Error) {
+				to3 = ctx.Value(constant.To3)
+				return nil, nil
+			},
+		},
+	}
+	ctx := context.WithValue(context.Background(), constant.KeyST, time.Now())
+	ctx = context.WithValue(ctx, constant.To3, 50)
+
+	startTime := time.Now()
+	results, err := ScatterGather(ctx, branches, WithScatterTimeout(time.Minute), WithMaxConcurrency(2))
+	assert.True(t, nil == err)
+	assert.True(t, time.Since(startTime) < time.Second)
+	assert.True(t, results[0].IsSuccess())
+	assert.Equal(t, constant.SystemRemoteCallTimeout, results[1].Error.ErrorCode)
+	if nil != to3 {
+		assert.True(t, to3.(int) <= 50)
+	}
+}
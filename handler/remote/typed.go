@@ -0,0 +1,192 @@
+package remote
+
+import (
+	"context"
+	"reflect"
+
+	"git.multiverse.io/eventkit/kit/client"
+	"git.multiverse.io/eventkit/kit/client/mesh"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/constant"
+)
+
+type targetType int
+
+const (
+	targetTypeRequest targetType = iota
+	targetTypeElement
+	targetTypeSU
+)
+
+// Target defines the routing target of a typed call
+type Target struct {
+	targetType  targetType
+	elementType string
+	elementID   string
+	dstSU       string
+	serviceKey  string
+}
+
+// ToRequest creates a target that routes the call using the options of the request itself (event id, su, topic type...)
+func ToRequest() Target {
+	return Target{targetType: targetTypeRequest}
+}
+
+// ToElement creates a target that routes the call by the element type and element id
+func ToElement(elementType, elementID, serviceKey string) Target {
+	return Target{
+		targetType:  targetTypeElement,
+		elementType: elementType,
+		elementID:   elementID,
+		serviceKey:  serviceKey,
+	}
+}
+
+// ToSU creates a target that routes the call to the specified destination SU
+func ToSU(dstSU, serviceKey string) Target {
+	return Target{
+		targetType: targetTypeSU,
+		dstSU:      dstSU,
+		serviceKey: serviceKey,
+	}
+}
+
+// ToServiceKey creates a target that routes the call only by the downstream service config of the service key
+func ToServiceKey(serviceKey string) Target {
+	return ToSU("", serviceKey)
+}
+
+// String returns the readable description of the target
+func (t Target) String() string {
+	switch t.targetType {
+	case targetTypeElement:
+		return "element[type=" + t.elementType + ", id=" + t.elementID + ", serviceKey=" + t.serviceKey + "]"
+	case targetTypeSU:
+		return "su[su=" + t.dstSU + ", serviceKey=" + t.serviceKey + "]"
+	default:
+		return "request"
+	}
+}
+
+// TypedCallOptions defines the options of the typed call
+type TypedCallOptions struct {
+	RequestOptions []client.RequestOption
+	CallOptions    []client.CallOption
+}
+
+// TypedCallOption sets the options of the typed call
+type TypedCallOption func(*TypedCallOptions)
+
+// WithRequestOptions appends the request options which will be used to build the mesh request
+func WithRequestOptions(opts ...client.RequestOption) TypedCallOption {
+	return func(o *TypedCallOptions) {
+		o.RequestOptions = append(o.RequestOptions, opts...)
+	}
+}
+
+// WithCallOptions appends the call options which will be passed to the remote call
+func WithCallOptions(opts ...client.CallOption) TypedCallOption {
+	return func(o *TypedCallOptions) {
+		o.CallOptions = append(o.CallOptions, opts...)
+	}
+}
+
+func newTypedCallOptions(opts []TypedCallOption) *TypedCallOptions {
+	options := &TypedCallOptions{}
+	for _, o := range opts {
+		o(options)
+	}
+
+	return options
+}
+
+// newResponse creates the holder that the response will be decoded into,
+// if Resp is a pointer type, the element of the pointer will be allocated.
+func newResponse[Resp any]() (holder *Resp, decodeInto interface{}) {
+	holder = new(Resp)
+	rt := reflect.TypeOf(holder).Elem()
+	if rt.Kind() == reflect.Ptr {
+		*holder = reflect.New(rt.Elem()).Interface().(Resp)
+		return holder, *holder
+	}
+
+	return holder, holder
+}
+
+// Call executes a sync call with the typed request and returns the typed response,
+// the response body is decoded with the codec of the request(see mesh.WithCodec).
+func Call[Req any, Resp any](ctx context.Context, callInc CallInc, target Target, req Req, opts ...TypedCallOption) (Resp, client.ResponseMeta, *errors.Error) {
+	var zero Resp
+	if nil == callInc {
+		return zero, nil, errors.New(constant.SystemInternalError, "The remote call instance is nil!")
+	}
+
+	options := newTypedCallOptions(opts)
+	request := mesh.NewMeshRequest(req, options.RequestOptions...)
+	resp, decodeInto := newResponse[Resp]()
+
+	var responseMeta client.ResponseMeta
+	var err *errors.Error
+	switch target.targetType {
+	case targetTypeElement:
+		responseMeta, err = callInc.SyncCallw(ctx, target.elementType, target.elementID, target.serviceKey, request, decodeInto, options.CallOptions...)
+	case targetTypeSU:
+		responseMeta, err = callInc.SyncCall(ctx, target.dstSU, target.serviceKey, request, decodeInto, options.CallOptions...)
+	default:
+		responseMeta, err = callInc.SyncCalls(ctx, request, decodeInto, options.CallOptions...)
+	}
+
+	if nil != err {
+		return zero, responseMeta, err
+	}
+
+	return *resp, responseMeta, nil
+}
+
+// SemiSyncCall executes a semi-sync call with the typed request and returns the typed response
+func SemiSyncCall[Req any, Resp any](ctx context.Context, callInc CallInc, target Target, req Req, opts ...TypedCallOption) (Resp, client.ResponseMeta, *errors.Error) {
+	var zero Resp
+	if nil == callInc {
+		return zero, nil, errors.New(constant.SystemInternalError, "The remote call instance is nil!")
+	}
+
+	options := newTypedCallOptions(opts)
+	request := mesh.NewMeshRequest(req, options.RequestOptions...)
+	resp, decodeInto := newResponse[Resp]()
+
+	var responseMeta client.ResponseMeta
+	var err *errors.Error
+	switch target.targetType {
+	case targetTypeElement:
+		responseMeta, err = callInc.SemiSyncCallw(ctx, target.elementType, target.elementID, target.serviceKey, request, decodeInto, options.CallOptions...)
+	case targetTypeSU:
+		responseMeta, err = callInc.SemiSyncCall(ctx, target.dstSU, target.serviceKey, request, decodeInto, options.CallOptions...)
+	default:
+		responseMeta, err = callInc.SemiSyncCalls(ctx, request, decodeInto, options.CallOptions...)
+	}
+
+	if nil != err {
+		return zero, responseMeta, err
+	}
+
+	return *resp, responseMeta, nil
+}
+
+// AsyncCall executes an async call with the typed request
+func AsyncCall[Req any](ctx context.Context, callInc CallInc, target Target, req Req, opts ...TypedCallOption) *errors.Error {
+	if nil == callInc {
+		return errors.New(constant.SystemInternalError, "The remote call instance is nil!")
+	}
+
+	options := newTypedCallOptions(opts)
+	request := mesh.NewMeshRequest(req, options.RequestOptions...)
+
+	switch target.targetType {
+	case targetTypeElement:
+		return callInc.AsyncCallw(ctx, target.elementType, target.elementID, target.serviceKey, request, options.CallOptions...)
+	case targetTypeSU:
+		return callInc.AsyncCall(ctx, target.dstSU, target.serviceKey, request, options.CallOptions...)
+	default:
+		return callInc.AsyncCalls(ctx, request, options.CallOptions...)
+	}
+}
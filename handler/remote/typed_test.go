@@ -0,0 +1,77 @@
+package remote
+
+import (
+	"context"
+	"testing"
+
+	"git.multiverse.io/eventkit/kit/client"
+	"git.multiverse.io/eventkit/kit/client/mesh"
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/constant"
+	remoteMock "git.multiverse.io/eventkit/kit/mocks/remote"
+	"github.com/golang/mock/gomock"
+)
+
+type typedRequest struct {
+	Name string `json:"name"`
+}
+
+type typedResponse struct {
+	Greeting string `json:"greeting"`
+}
+
+func decodeWithRequestCodec(request client.Request, response interface{}) (client.ResponseMeta, *errors.Error) {
+	body := []byte(`{"greeting":"hello, ` + request.Body().(*typedRequest).Name + `"}`)
+	if err := request.Codec().Decoder().Decode(body, response); nil != err {
+		return nil, errors.Wrap(constant.SystemInternalError, err, 0)
+	}
+	return mesh.NewMeshResponseMeta(body, map[string]string{}), nil
+}
+
+func TestTypedCall(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	callInc := remoteMock.NewMockCallInc(mockCtrl)
+
+	callInc.EXPECT().SyncCallw(gomock.Any(), "customer", "c001", "queryCustomer", gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, elementType, elementID, serviceKey string, request client.Request, response interface{}, opts ...client.CallOption) (client.ResponseMeta, *errors.Error) {
+			return decodeWithRequestCodec(request, response)
+		})
+	resp, meta, err := Call[*typedRequest, *typedResponse](context.Background(), callInc, ToElement("customer", "c001", "queryCustomer"), &typedRequest{Name: "element"})
+	assert.True(t, nil == err)
+	assert.NotNil(t, meta)
+	assert.Equal(t, "hello, element", resp.Greeting)
+
+	callInc.EXPECT().SyncCall(gomock.Any(), "su1", "queryCustomer", gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, dstSU, serviceKey string, request client.Request, response interface{}, opts ...client.CallOption) (client.ResponseMeta, *errors.Error) {
+			return decodeWithRequestCodec(request, response)
+		})
+	valueResp, _, err := Call[*typedRequest, typedResponse](context.Background(), callInc, ToSU("su1", "queryCustomer"), &typedRequest{Name: "su"})
+	assert.True(t, nil == err)
+	assert.Equal(t, "hello, su", valueResp.Greeting)
+
+	callInc.EXPECT().SyncCalls(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(ctx context.Context, request client.Request, response interface{}, opts ...client.CallOption) (client.ResponseMeta, *errors.Error) {
+			assert.Equal(t, "QueryCustomer", request.RequestOptions().EventID)
+			return nil, errors.New(constant.SystemRemoteCallTimeout, "timeout")
+		})
+	resp, _, err = Call[*typedRequest, *typedResponse](context.Background(), callInc, ToRequest(), &typedRequest{Name: "request"},
+		WithRequestOptions(mesh.WithEventID("QueryCustomer")))
+	assert.NotNil(t, err)
+	assert.Equal(t, constant.SystemRemoteCallTimeout, err.ErrorCode)
+	assert.True(t, nil == resp)
+
+	_, _, err = Call[*typedRequest, *typedResponse](context.Background(), nil, ToRequest(), &typedRequest{})
+	assert.NotNil(t, err)
+}
+
+func TestTypedAsyncCall(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	callInc := remoteMock.NewMockCallInc(mockCtrl)
+
+	callInc.EXPECT().AsyncCall(gomock.Any(), "", "notifyCustomer", gomock.Any()).Return(nil)
+	err := AsyncCall(context.Background(), callInc, ToServiceKey("notifyCustomer"), &typedRequest{Name: "async"})
+	assert.True(t, nil == err)
+}
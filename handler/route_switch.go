@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+
+	"git.multiverse.io/eventkit/kit/handler/config"
+	"git.multiverse.io/eventkit/kit/log"
+)
+
+func routeModeOf(s config.RouteSwitch) string {
+	if s.IsEnabled() {
+		return config.RouteModeEnabled
+	}
+
+	return s.Mode
+}
+
+// applyRouteSwitches applies the route switches of the service config into the router, the changes are logged and audited
+func (e *EventCallback) applyRouteSwitches(switches map[string]config.RouteSwitch) {
+	if nil == e.handlerRouter {
+		return
+	}
+
+	serviceID := ""
+	if nil != e.serviceConfig {
+		serviceID = e.serviceConfig.ServiceID
+	}
+	changes, rejected := e.handlerRouter.SetRouteSwitches(switches)
+	for _, r := range rejected {
+		log.Errorsf("Service ID: %s - the switch of route[%s] is rejected, unknown mode[%s], the mode %s is kept", serviceID, r.Route, r.New.Mode, routeModeOf(r.Old))
+	}
+	for _, change := range changes {
+		log.Warnsf("Service ID: %s - the switch of route[%s] is changed: %s => %s", serviceID, change.Route, routeModeOf(change.Old), routeModeOf(change.New))
+		previous, _ := json.Marshal(change.Old)
+		current, _ := json.Marshal(change.New)
+		log.AuditInfo(context.Background(), serviceID, "config", "system", current, nil,
+			log.Metadata("action", "routeSwitchChanged"),
+			log.Metadata("route", change.Route),
+			log.Metadata("previous", string(previous)),
+		)
+	}
+}
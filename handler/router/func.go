@@ -0,0 +1,123 @@
+package router
+
+import (
+	"context"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/log"
+)
+
+var errorReflectType = reflect.TypeOf((*error)(nil)).Elem()
+
+// FuncHandler is the adapter of the context-first handler function, it's invoked without reflection.
+// The request header, the topic attributes and the remote call are carried by the context(see base.FromContext).
+type FuncHandler interface {
+	// Name returns the name of the handler function
+	Name() string
+	// NewRequest returns a new request that the body of the message is decoded into
+	NewRequest() interface{}
+	// Invoke calls the handler function with the request returned by NewRequest
+	Invoke(ctx context.Context, request interface{}) (interface{}, error)
+	// RequestType returns the type of the request
+	RequestType() reflect.Type
+	// ResponseType returns the type of the response
+	ResponseType() reflect.Type
+}
+
+type funcHandler[T any, R any] struct {
+	name string
+	fn   func(ctx context.Context, request *T) (*R, error)
+}
+
+// Func adapts the context-first handler function to the handler that can be registered into the router, e.g.
+//
+//	handlerRouter.Router("GetAccount", router.Func(getAccount), router.HandleGet("/v1/accounts/:id"))
+func Func[T any, R any](fn func(ctx context.Context, request *T) (*R, error)) FuncHandler {
+	if nil == fn {
+		panic(errors.Errorf(constant.SystemInternalError, "The handler function cannot be nil"))
+	}
+
+	return &funcHandler[T, R]{name: funcName(fn), fn: fn}
+}
+
+// funcName returns the name of the function without the package path, e.g. account.getAccount
+func funcName(fn interface{}) string {
+	name := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	if idx := strings.LastIndex(name, "/"); idx >= 0 {
+		name = name[idx+1:]
+	}
+
+	return name
+}
+
+func (f *funcHandler[T, R]) Name() string {
+	return f.name
+}
+
+func (f *funcHandler[T, R]) NewRequest() interface{} {
+	return new(T)
+}
+
+func (f *funcHandler[T, R]) Invoke(ctx context.Context, request interface{}) (interface{}, error) {
+	req, ok := request.(*T)
+	if !ok {
+		return nil, errors.Errorf(constant.SystemInternalError, "Invalid request type[%T] of handler function[%s]", request, f.name)
+	}
+	response, err := f.fn(ctx, req)
+	if nil != err {
+		// the nil *errors.Error returned as the error
+		if e, ok := err.(*errors.Error); ok && nil == e {
+			return response, nil
+		}
+		return nil, err
+	}
+
+	return response, nil
+}
+
+func (f *funcHandler[T, R]) RequestType() reflect.Type {
+	return reflect.TypeOf((*T)(nil))
+}
+
+func (f *funcHandler[T, R]) ResponseType() reflect.Type {
+	return reflect.TypeOf((*R)(nil))
+}
+
+// generateFuncHandlerProperties fills the properties of the handler function,
+// the types of the request and the response are kept for the validation and the API document.
+func (e *HandlerRouter) generateFuncHandlerProperties(fn FuncHandler, registerOptions *Options) *Options {
+	if "" == registerOptions.EventExpression {
+		panic(errors.Errorf(constant.SystemInternalError, "%s is empty,please check, handler function[%s]",
+			registerTypeNameMapping[registerOptions.HandlerOptions.RegisterType], fn.Name()))
+	}
+	if nil != registerOptions.Compensable {
+		panic(errors.Errorf(constant.SystemInternalError, "failed to register: %s[%s] - handler function[%s], the compensable transaction isn't supported by the handler function",
+			registerTypeNameMapping[registerOptions.HandlerOptions.RegisterType], registerOptions.EventExpression, fn.Name()))
+	}
+
+	registerOptions.HandlerOptions.Func = fn
+	registerOptions.HandlerOptions.HandlerMethodName = fn.Name()
+	registerOptions.HandlerOptions.HandlerMethodInParams = []reflect.Type{fn.RequestType()}
+	registerOptions.HandlerOptions.HandlerMethodOutParams = []reflect.Type{fn.ResponseType(), errorReflectType}
+
+	var combineErrors bool
+	if nil != registerOptions.HandlerOptions.CustomValidationOptions {
+		combineErrors = registerOptions.HandlerOptions.CustomValidationOptions.CombineErrors
+	}
+	log.Infosf("Event handler register: %s[%s] - Handler function[%s] - Interceptors:%++v - Enabled validation:[%v - (combine errors:%v)]",
+		registerTypeNameMapping[registerOptions.HandlerOptions.RegisterType],
+		registerOptions.EventExpression,
+		fn.Name(),
+		registerOptions.HandlerOptions.Interceptors,
+		registerOptions.HandlerOptions.EnableValidation,
+		combineErrors,
+	)
+
+	e.registerURLPath(registerOptions)
+
+	return registerOptions
+}
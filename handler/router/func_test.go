@@ -0,0 +1,71 @@
+package router
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/compensable"
+)
+
+type funcRequest struct {
+	ID string `path:"id"`
+}
+
+type funcResponse struct {
+	ID string
+}
+
+func getByID(ctx context.Context, request *funcRequest) (*funcResponse, error) {
+	if "" == request.ID {
+		var err *errors.Error
+		return nil, err
+	}
+
+	return &funcResponse{ID: request.ID}, nil
+}
+
+func TestFunc(t *testing.T) {
+	fn := Func(getByID)
+	assert.Equal(t, "router.getByID", fn.Name())
+	assert.Equal(t, reflect.TypeOf(&funcRequest{}), fn.RequestType())
+	assert.Equal(t, reflect.TypeOf(&funcResponse{}), fn.ResponseType())
+
+	request := fn.NewRequest()
+	request.(*funcRequest).ID = "1"
+	response, err := fn.Invoke(context.Background(), request)
+	assert.True(t, nil == err)
+	assert.Equal(t, "1", response.(*funcResponse).ID)
+
+	// the nil *errors.Error returned as the error
+	response, err = fn.Invoke(context.Background(), fn.NewRequest())
+	assert.True(t, nil == err)
+	assert.True(t, nil == response.(*funcResponse))
+
+	// the invalid request type
+	_, err = fn.Invoke(context.Background(), &funcResponse{})
+	assert.NotNil(t, err)
+}
+
+func TestHandlerRouter_RouterFunc(t *testing.T) {
+	router := &HandlerRouter{}
+	options := router.Router("GET_BY_ID", Func(getByID), HandleGet("/v1/items/:id"), EnableValidation(false))
+	assert.NotNil(t, options.HandlerOptions.Func)
+	assert.Equal(t, "router.getByID", options.HandlerOptions.HandlerMethodName)
+	assert.Equal(t, 1, len(options.HandlerOptions.HandlerMethodInParams))
+	assert.Equal(t, 2, len(options.HandlerOptions.HandlerMethodOutParams))
+	assert.Equal(t, options, router.MatchHandler("GET_BY_ID"))
+	matched, params := router.MatchURLPath("/v1/items/9")
+	assert.Equal(t, options, matched)
+	assert.Equal(t, "9", params["id"])
+
+	prefixOptions := router.RouterPrefix("ITEM_", Func(getByID))
+	assert.Equal(t, prefixOptions, router.MatchHandler("ITEM_GET"))
+
+	defer func() {
+		assert.NotNil(t, recover())
+	}()
+	router.Router("TCC_BY_ID", Func(getByID), Compensable(&compensable.Compensable{TryMethod: "Try"}))
+}
@@ -48,6 +48,15 @@ type HandlerRouter struct {
 	DefaultInterceptorsOption                      Option
 	DefaultEnableValidationOption                  Option
 	DefaultCustomValidationRegisterFunctionsOption Option
+
+	// matcher is the matching engine of the prefixes, suffixes and matcher expressions
+	matcher *eventIDMatcher
+	// urlPathRoutes stores the URL paths with parameters
+	urlPathRoutes []*urlPathRoute
+	// versions stores the versions of the handlers keyed by event ID
+	versions map[string]*versionedHandlers
+	// routeSwitches stores the hot reloaded switches of the routes
+	routeSwitches map[string]config.RouteSwitch
 }
 
 var defaultCodec = auto.BuildAutoCodecWithJSONCodec()
@@ -75,6 +84,9 @@ func (h *HandlerOptions) IsMatchedEventID(eventID string, matcherExpression stri
 		}
 	case EventExpression:
 		{
+			if nil != h.expression && h.expression.String() == matcherExpression {
+				return h.expression.MatchString(eventID)
+			}
 			if isOk, _ := regexp.MatchString(matcherExpression, eventID); isOk {
 				return true
 			}
@@ -106,6 +118,9 @@ func (e *HandlerRouter) DefaultEnableValidation(combineErrors bool, defaultCusto
 }
 
 func (e *HandlerRouter) generateHandlerProperties(instance interface{}, registerOptions *Options) *Options {
+	if fn, ok := instance.(FuncHandler); ok {
+		return e.generateFuncHandlerProperties(fn, registerOptions)
+	}
 	handlerReflectType := reflect.TypeOf(instance)
 	handlerName := ""
 	if _, ok := handlerReflectType.MethodByName(constant.FunctionForGetHandlerName); ok {
@@ -176,14 +191,21 @@ func (e *HandlerRouter) generateHandlerProperties(instance interface{}, register
 		}
 	}
 
-	// register URL path with handler
+	e.registerURLPath(registerOptions)
+	return registerOptions
+}
+
+// registerURLPath registers the URL path with the handler
+func (e *HandlerRouter) registerURLPath(registerOptions *Options) {
 	if "" != registerOptions.HandlerOptions.URLPath {
 		if nil == e.URLPathHandlers {
 			e.URLPathHandlers = make(map[string]*Options)
 		}
 		e.URLPathHandlers[registerOptions.HandlerOptions.URLPath] = registerOptions
+		if isURLPathPattern(registerOptions.HandlerOptions.URLPath) {
+			e.addURLPathRoute(registerOptions.HandlerOptions.URLPath, registerOptions)
+		}
 	}
-	return registerOptions
 }
 
 func newRegisterOptions(opts ...Option) *Options {
@@ -242,10 +264,23 @@ func (e *HandlerRouter) Router(eventID string, instance interface{}, opts ...Opt
 	if nil == e.DefiniteEventHandlers {
 		e.DefiniteEventHandlers = make(map[string]*Options)
 	}
-	if _, ok := e.DefiniteEventHandlers[eventID]; ok {
+	if existing, ok := e.DefiniteEventHandlers[eventID]; ok && ("" == existing.HandlerOptions.Version || "" == registerOptions.HandlerOptions.Version) {
 		panic(errors.Errorf(constant.SystemInternalError, "Duplicate event ID:%s", eventID))
 	}
-	e.DefiniteEventHandlers[eventID] = registerOptions
+	if "" == registerOptions.HandlerOptions.Version {
+		e.DefiniteEventHandlers[eventID] = registerOptions
+		return registerOptions
+	}
+
+	// the default version is used when the version cannot be selected by the request
+	defaultOptions := e.addVersion(eventID, registerOptions)
+	e.DefiniteEventHandlers[eventID] = defaultOptions
+	if "" != registerOptions.HandlerOptions.URLPath && registerOptions.HandlerOptions.URLPath == defaultOptions.HandlerOptions.URLPath {
+		e.URLPathHandlers[registerOptions.HandlerOptions.URLPath] = defaultOptions
+		if isURLPathPattern(registerOptions.HandlerOptions.URLPath) {
+			e.addURLPathRoute(registerOptions.HandlerOptions.URLPath, defaultOptions)
+		}
+	}
 
 	return registerOptions
 }
@@ -281,6 +316,7 @@ func (e *HandlerRouter) RouterPrefix(prefixOfEventID string, instance interface{
 	registerOptions.HandlerOptions.RegisterType = EventPrefix
 
 	e.generateHandlerProperties(instance, registerOptions)
+	e.eventIDMatcher().addPrefix(prefixOfEventID, registerOptions)
 	if nil == e.ExpressionEventHandlers {
 		e.ExpressionEventHandlers = make(map[string]*Options)
 	}
@@ -320,6 +356,7 @@ func (e *HandlerRouter) RouterSuffix(suffixOfEventID string, instance interface{
 	registerOptions.HandlerOptions.RegisterType = EventSuffix
 
 	e.generateHandlerProperties(instance, registerOptions)
+	e.eventIDMatcher().addSuffix(suffixOfEventID, registerOptions)
 	if nil == e.ExpressionEventHandlers {
 		e.ExpressionEventHandlers = make(map[string]*Options)
 	}
@@ -359,6 +396,7 @@ func (e *HandlerRouter) RouterExpression(matcherExpressionOfEventID string, inst
 	registerOptions.HandlerOptions.RegisterType = EventExpression
 
 	e.generateHandlerProperties(instance, registerOptions)
+	e.eventIDMatcher().addExpression(matcherExpressionOfEventID, registerOptions)
 	if nil == e.ExpressionEventHandlers {
 		e.ExpressionEventHandlers = make(map[string]*Options)
 	}
@@ -368,6 +406,7 @@ func (e *HandlerRouter) RouterExpression(matcherExpressionOfEventID string, inst
 }
 
 // MatchHandler finds the handler by eventID, return nil if the eventID cannot match any router.
+// The precedence is: exact event ID > longest prefix > longest suffix > matcher expression by registration order.
 func (e *HandlerRouter) MatchHandler(eventID string) *Options {
 	e.RLock()
 	defer e.RUnlock()
@@ -377,20 +416,38 @@ func (e *HandlerRouter) MatchHandler(eventID string) *Options {
 		return hp
 	}
 
-	// 2. ExpressionEventHandlers
-	for k, v := range e.ExpressionEventHandlers {
-		if v.HandlerOptions.IsMatchedEventID(eventID, k) {
-			return v
-		}
+	// 2. the longest prefix, the longest suffix, then the matcher expressions by registration order
+	if nil != e.matcher {
+		return e.matcher.match(eventID)
 	}
 
 	return nil
 }
 
-// MatchHandlerWithURLPath finds the handler by URL path, return nil if the eventID cannot match any router.
-func (e *HandlerRouter) MatchHandlerWithURLPath(urlPath string) *Options {
+func (e *HandlerRouter) eventIDMatcher() *eventIDMatcher {
+	if nil == e.matcher {
+		e.matcher = &eventIDMatcher{}
+	}
+
+	return e.matcher
+}
+
+// CheckRoutes detects the duplicate routes, the ambiguous routes and the routes shadowed by the routes with higher precedence,
+// it should be called after all the handlers are registered.
+func (e *HandlerRouter) CheckRoutes() []RouteConflict {
 	e.RLock()
 	defer e.RUnlock()
 
-	return e.URLPathHandlers[urlPath]
+	if nil == e.matcher {
+		return nil
+	}
+
+	return e.matcher.checkRoutes(e.DefiniteEventHandlers)
+}
+
+// MatchHandlerWithURLPath finds the handler by URL path, return nil if the eventID cannot match any router.
+func (e *HandlerRouter) MatchHandlerWithURLPath(urlPath string) *Options {
+	options, _ := e.MatchURLPath(urlPath)
+
+	return options
 }
@@ -0,0 +1,349 @@
+package router
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/constant"
+)
+
+// The following elements represent the type of the route conflict.
+const (
+	// ConflictAmbiguous means the routes overlap, and the handler is decided by the precedence
+	ConflictAmbiguous = "AMBIGUOUS"
+	// ConflictShadowed means the route can never be matched because of a route with the higher precedence
+	ConflictShadowed = "SHADOWED"
+	// ConflictDuplicate means the route is registered again, the later registration replaces the earlier one
+	ConflictDuplicate = "DUPLICATE"
+)
+
+// RouteConflict is a conflict of the event routes detected by HandlerRouter.CheckRoutes
+type RouteConflict struct {
+	Type   string
+	Route  string
+	By     string
+	Reason string
+}
+
+func (c RouteConflict) String() string {
+	return fmt.Sprintf("%s: %s by %s, %s", c.Type, c.Route, c.By, c.Reason)
+}
+
+// trieNode is the node of the prefix trie and the suffix trie(stores the reversed suffixes)
+type trieNode struct {
+	children map[byte]*trieNode
+	options  *Options
+}
+
+// insert inserts the key into the trie, returns the replaced options of the key if the key has been inserted
+func (n *trieNode) insert(key string, reversed bool, options *Options) *Options {
+	node := n
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if reversed {
+			c = key[len(key)-1-i]
+		}
+		if nil == node.children {
+			node.children = make(map[byte]*trieNode)
+		}
+		child, ok := node.children[c]
+		if !ok {
+			child = &trieNode{}
+			node.children[c] = child
+		}
+		node = child
+	}
+	existing := node.options
+	node.options = options
+
+	return existing
+}
+
+// longestMatch returns the options of the longest key that is the prefix(or suffix if reversed) of the event ID
+func (n *trieNode) longestMatch(eventID string, reversed bool) *Options {
+	var matched *Options
+	node := n
+	for i := 0; i < len(eventID); i++ {
+		c := eventID[i]
+		if reversed {
+			c = eventID[len(eventID)-1-i]
+		}
+		child, ok := node.children[c]
+		if !ok {
+			break
+		}
+		node = child
+		if nil != node.options {
+			matched = node.options
+		}
+	}
+
+	return matched
+}
+
+type expressionRoute struct {
+	expression string
+	regexp     *regexp.Regexp
+	options    *Options
+}
+
+// eventIDMatcher is the matching engine of the event IDs registered with prefix, suffix and matcher expression.
+// The precedence is: exact event ID > longest prefix > longest suffix > matcher expression by registration order.
+type eventIDMatcher struct {
+	prefixes    trieNode
+	suffixes    trieNode
+	expressions []*expressionRoute
+	// routes stores all the prefixes, suffixes and expressions in registration order
+	routes []*Options
+	// duplicates stores the routes replaced by the later registrations
+	duplicates []RouteConflict
+}
+
+// replace replaces the existing route with the route registered later and records the duplicate
+func (m *eventIDMatcher) replace(existing, options *Options) {
+	for i, route := range m.routes {
+		if route == existing {
+			m.routes[i] = options
+			break
+		}
+	}
+	m.duplicates = append(m.duplicates, RouteConflict{
+		Type:   ConflictDuplicate,
+		Route:  routeName(existing),
+		By:     routeName(options),
+		Reason: "the route is registered again, the later registration replaces the earlier one",
+	})
+}
+
+func (m *eventIDMatcher) addPrefix(prefix string, options *Options) {
+	if existing := m.prefixes.insert(prefix, false, options); nil != existing {
+		m.replace(existing, options)
+		return
+	}
+	m.routes = append(m.routes, options)
+}
+
+func (m *eventIDMatcher) addSuffix(suffix string, options *Options) {
+	if existing := m.suffixes.insert(suffix, true, options); nil != existing {
+		m.replace(existing, options)
+		return
+	}
+	m.routes = append(m.routes, options)
+}
+
+func (m *eventIDMatcher) addExpression(expression string, options *Options) {
+	re, err := regexp.Compile(expression)
+	if nil != err {
+		panic(errors.Errorf(constant.SystemInternalError, "Invalid matcher expression of event ID[%s], error=%v", expression, err))
+	}
+	options.HandlerOptions.expression = re
+	for _, route := range m.expressions {
+		if route.expression == expression {
+			m.replace(route.options, options)
+			route.options = options
+			return
+		}
+	}
+	m.expressions = append(m.expressions, &expressionRoute{
+		expression: expression,
+		regexp:     re,
+		options:    options,
+	})
+	m.routes = append(m.routes, options)
+}
+
+func (m *eventIDMatcher) match(eventID string) *Options {
+	if options := m.prefixes.longestMatch(eventID, false); nil != options {
+		return options
+	}
+	if options := m.suffixes.longestMatch(eventID, true); nil != options {
+		return options
+	}
+	for _, route := range m.expressions {
+		if route.regexp.MatchString(eventID) {
+			return route.options
+		}
+	}
+
+	return nil
+}
+
+func routeName(options *Options) string {
+	return fmt.Sprintf("%s[%s]", registerTypeNameMapping[options.HandlerOptions.RegisterType], options.EventExpression)
+}
+
+// literalShape describes the literal parts that all the event IDs matched by an expression contain
+type literalShape struct {
+	// matchAll is true if the expression matches any event ID
+	matchAll bool
+	// prefix is the literal that all the matched event IDs start with
+	prefix string
+	// suffix is the literal that all the matched event IDs end with
+	suffix string
+	// exact is true if the expression only matches the prefix
+	exact bool
+}
+
+func isAnyChars(re *syntax.Regexp) bool {
+	return syntax.OpStar == re.Op && (syntax.OpAnyChar == re.Sub[0].Op || syntax.OpAnyCharNotNL == re.Sub[0].Op)
+}
+
+func hasAssertion(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpBeginText, syntax.OpEndText, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return true
+	}
+	for _, sub := range re.Sub {
+		if hasAssertion(sub) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func literalOf(re *syntax.Regexp) (string, bool) {
+	if syntax.OpLiteral != re.Op || 0 != re.Flags&syntax.FoldCase {
+		return "", false
+	}
+
+	return string(re.Rune), true
+}
+
+// analyzeExpression returns the literal shape of the expression, the event IDs never contain line breaks
+func analyzeExpression(expression string) literalShape {
+	re, err := syntax.Parse(expression, syntax.Perl)
+	if nil != err {
+		return literalShape{}
+	}
+	re = re.Simplify()
+	// the unanchored expression that matches the empty string matches any event ID
+	if !hasAssertion(re) && regexp.MustCompile(expression).MatchString("") {
+		return literalShape{matchAll: true}
+	}
+
+	subs := []*syntax.Regexp{re}
+	if syntax.OpConcat == re.Op {
+		subs = re.Sub
+	}
+	beginAnchored := len(subs) > 0 && (syntax.OpBeginText == subs[0].Op || syntax.OpBeginLine == subs[0].Op)
+	if beginAnchored {
+		subs = subs[1:]
+	}
+	endAnchored := len(subs) > 0 && (syntax.OpEndText == subs[len(subs)-1].Op || syntax.OpEndLine == subs[len(subs)-1].Op)
+	if endAnchored {
+		subs = subs[:len(subs)-1]
+	}
+
+	shape := literalShape{}
+	if 0 == len(subs) || (1 == len(subs) && (syntax.OpEmptyMatch == subs[0].Op || isAnyChars(subs[0]))) {
+		shape.matchAll = !(beginAnchored && endAnchored && (0 == len(subs) || syntax.OpEmptyMatch == subs[0].Op))
+		return shape
+	}
+	if beginAnchored {
+		if literal, ok := literalOf(subs[0]); ok {
+			shape.prefix = literal
+			shape.exact = endAnchored && 1 == len(subs)
+		}
+	}
+	if endAnchored {
+		if literal, ok := literalOf(subs[len(subs)-1]); ok {
+			shape.suffix = literal
+		}
+	}
+
+	return shape
+}
+
+// checkRoutes detects the duplicate, ambiguous and shadowed routes
+func (m *eventIDMatcher) checkRoutes(definiteEventHandlers map[string]*Options) []RouteConflict {
+	conflicts := append(make([]RouteConflict, 0), m.duplicates...)
+	prefixes := make([]*Options, 0)
+	suffixes := make([]*Options, 0)
+	for _, options := range m.routes {
+		switch options.HandlerOptions.RegisterType {
+		case EventPrefix:
+			prefixes = append(prefixes, options)
+		case EventSuffix:
+			suffixes = append(suffixes, options)
+		}
+	}
+
+	// any prefix P and suffix S overlap on the event IDs of the form P...S, which are routed to the prefix
+	for _, prefix := range prefixes {
+		for _, suffix := range suffixes {
+			reason := "the event IDs starting with the prefix and ending with the suffix are routed by the prefix"
+			if prefix.EventExpression == suffix.EventExpression {
+				reason = "the event IDs starting and ending with the same literal are routed by the prefix"
+			}
+			conflicts = append(conflicts, RouteConflict{
+				Type:   ConflictAmbiguous,
+				Route:  routeName(suffix),
+				By:     routeName(prefix),
+				Reason: reason,
+			})
+		}
+	}
+
+	var matchAll *expressionRoute
+	for _, route := range m.expressions {
+		if nil != matchAll {
+			conflicts = append(conflicts, RouteConflict{
+				Type:   ConflictShadowed,
+				Route:  routeName(route.options),
+				By:     routeName(matchAll.options),
+				Reason: "the earlier expression matches all the event IDs",
+			})
+			continue
+		}
+		shape := analyzeExpression(route.expression)
+		if shape.matchAll {
+			matchAll = route
+			continue
+		}
+		if shadowedBy := shadowedByLiteral(shape, prefixes, suffixes, definiteEventHandlers); "" != shadowedBy {
+			conflicts = append(conflicts, RouteConflict{
+				Type:   ConflictShadowed,
+				Route:  routeName(route.options),
+				By:     shadowedBy,
+				Reason: "all the event IDs matched by the expression are routed by the route with higher precedence",
+			})
+		}
+	}
+
+	return conflicts
+}
+
+func shadowedByLiteral(shape literalShape, prefixes, suffixes []*Options, definiteEventHandlers map[string]*Options) string {
+	if shape.exact {
+		if options, ok := definiteEventHandlers[shape.prefix]; ok {
+			return routeName(options)
+		}
+	}
+	if "" != shape.prefix {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(shape.prefix, prefix.EventExpression) {
+				return routeName(prefix)
+			}
+		}
+	}
+	if shape.exact {
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(shape.prefix, suffix.EventExpression) {
+				return routeName(suffix)
+			}
+		}
+	}
+	if "" != shape.suffix {
+		for _, suffix := range suffixes {
+			if strings.HasSuffix(shape.suffix, suffix.EventExpression) {
+				return routeName(suffix)
+			}
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,159 @@
+package router
+
+import (
+	"fmt"
+	"testing"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+)
+
+func TestHandlerRouter_MatchHandlerPrecedence(t *testing.T) {
+	router := &HandlerRouter{}
+	router.RouterExpression("^ORDER.*", &MyHandler{}, Method("Method1"))
+	router.RouterExpression(".*CREATED$", &MyHandler{}, Method("Method2"))
+	router.RouterSuffix("_CREATED", &MyHandler{}, Method("Method3"))
+	router.RouterSuffix("ORDER_CREATED", &MyHandler{}, Method("Method1"))
+	router.RouterPrefix("ORDER", &MyHandler{}, Method("Method2"))
+	router.RouterPrefix("ORDER_PAY", &MyHandler{}, Method("Method3"))
+	router.Router("ORDER_PAY_CREATED", &MyHandler{}, Method("Method1"))
+
+	expectations := map[string]string{
+		// exact > prefix
+		"ORDER_PAY_CREATED": "ORDER_PAY_CREATED",
+		// longest prefix
+		"ORDER_PAY_UPDATED": "ORDER_PAY",
+		"ORDER_CREATED":     "ORDER",
+		// longest suffix
+		"NEW_ORDER_CREATED": "ORDER_CREATED",
+		"USER_CREATED":      "_CREATED",
+		// expression by registration order
+		"USERCREATED": ".*CREATED$",
+		"UNKNOWN":     "",
+	}
+	// the result is the same on every run
+	for i := 0; i < 100; i++ {
+		for eventID, expected := range expectations {
+			o := router.MatchHandler(eventID)
+			if "" == expected {
+				assert.True(t, nil == o)
+				continue
+			}
+			assert.True(t, nil != o)
+			assert.Equal(t, expected, o.EventExpression)
+		}
+	}
+
+	router = &HandlerRouter{}
+	router.RouterExpression("EVENT", &MyHandler{}, Method("Method1"))
+	router.RouterExpression("EVENT_A", &MyHandler{}, Method("Method2"))
+	assert.Equal(t, "EVENT", router.MatchHandler("EVENT_A").EventExpression)
+	assert.True(t, router.MatchHandler("EVENT_A").HandlerOptions.IsMatchedEventID("EVENT_A", "EVENT"))
+}
+
+func TestHandlerRouter_DuplicateRoutes(t *testing.T) {
+	registers := []func(router *HandlerRouter, method string) *Options{
+		func(router *HandlerRouter, method string) *Options {
+			return router.RouterPrefix("ORDER", &MyHandler{}, Method(method))
+		},
+		func(router *HandlerRouter, method string) *Options {
+			return router.RouterSuffix("ORDER", &MyHandler{}, Method(method))
+		},
+		func(router *HandlerRouter, method string) *Options {
+			return router.RouterExpression("ORDER.*", &MyHandler{}, Method(method))
+		},
+	}
+	for _, register := range registers {
+		router := &HandlerRouter{}
+		register(router, "Method1")
+		// the later registration replaces the earlier one and is reported as a conflict
+		replaced := register(router, "Method2")
+		assert.Equal(t, replaced, router.MatchHandler("ORDER"))
+		conflicts := router.CheckRoutes()
+		assert.Equal(t, 1, len(conflicts))
+		assert.Equal(t, ConflictDuplicate, conflicts[0].Type)
+		assert.Equal(t, 1, len(router.matcher.routes))
+	}
+
+	router := &HandlerRouter{}
+	func() {
+		defer func() {
+			assert.NotNil(t, recover())
+		}()
+		router.RouterExpression("ORDER(", &MyHandler{}, Method("Method1"))
+	}()
+}
+
+func TestHandlerRouter_CheckRoutes(t *testing.T) {
+	router := &HandlerRouter{}
+	assert.Equal(t, 0, len(router.CheckRoutes()))
+
+	router.Router("ORDER_CANCELLED", &MyHandler{}, Method("Method1"))
+	router.RouterPrefix("ORDER", &MyHandler{}, Method("Method1"))
+	router.RouterSuffix("ORDER", &MyHandler{}, Method("Method1"))
+	router.RouterSuffix("_PAID", &MyHandler{}, Method("Method1"))
+	router.RouterExpression("^ORDER_[0-9]+", &MyHandler{}, Method("Method1"))
+	router.RouterExpression("[a-z]+_PAID$", &MyHandler{}, Method("Method1"))
+	router.RouterExpression("^USER_CANCELLED$", &MyHandler{}, Method("Method1"))
+	router.RouterExpression("^USER_[0-9]+$", &MyHandler{}, Method("Method1"))
+	router.RouterExpression(".*", &MyHandler{}, Method("Method1"))
+	router.RouterExpression("^USER_.*", &MyHandler{}, Method("Method1"))
+
+	conflicts := router.CheckRoutes()
+	assert.Equal(t, 5, len(conflicts))
+	assert.Equal(t, RouteConflict{
+		Type:   ConflictAmbiguous,
+		Route:  "Suffix of Event ID[ORDER]",
+		By:     "Prefix of Event ID[ORDER]",
+		Reason: "the event IDs starting and ending with the same literal are routed by the prefix",
+	}, conflicts[0])
+	// the prefix and the suffix overlap on the event IDs like ORDER_PAID
+	assert.Equal(t, RouteConflict{
+		Type:   ConflictAmbiguous,
+		Route:  "Suffix of Event ID[_PAID]",
+		By:     "Prefix of Event ID[ORDER]",
+		Reason: "the event IDs starting with the prefix and ending with the suffix are routed by the prefix",
+	}, conflicts[1])
+	assert.Equal(t, ConflictShadowed, conflicts[2].Type)
+	assert.Equal(t, "Matcher expression of Event ID[^ORDER_[0-9]+]", conflicts[2].Route)
+	assert.Equal(t, "Prefix of Event ID[ORDER]", conflicts[2].By)
+	assert.Equal(t, "Matcher expression of Event ID[[a-z]+_PAID$]", conflicts[3].Route)
+	assert.Equal(t, "Suffix of Event ID[_PAID]", conflicts[3].By)
+	assert.Equal(t, "Matcher expression of Event ID[^USER_.*]", conflicts[4].Route)
+	assert.Equal(t, "Matcher expression of Event ID[.*]", conflicts[4].By)
+}
+
+func TestAnalyzeExpression(t *testing.T) {
+	assert.Equal(t, literalShape{matchAll: true}, analyzeExpression(".*"))
+	assert.Equal(t, literalShape{matchAll: true}, analyzeExpression("^.*$"))
+	assert.Equal(t, literalShape{matchAll: true}, analyzeExpression("A*"))
+	assert.Equal(t, literalShape{}, analyzeExpression("^$"))
+	assert.Equal(t, literalShape{prefix: "ORDER"}, analyzeExpression("^ORDER"))
+	assert.Equal(t, literalShape{suffix: "ORDER"}, analyzeExpression(".*ORDER$"))
+	assert.Equal(t, literalShape{prefix: "ORDER", suffix: "ORDER", exact: true}, analyzeExpression("^ORDER$"))
+	assert.Equal(t, literalShape{}, analyzeExpression("(?i)^ORDER"))
+}
+
+func newBenchmarkRouter() *HandlerRouter {
+	router := &HandlerRouter{}
+	for i := 0; i < 100; i++ {
+		router.Router(fmt.Sprintf("EVENT_%03d", i), &MyHandler{}, Method("Method1"))
+		router.RouterPrefix(fmt.Sprintf("PREFIX_%03d_", i), &MyHandler{}, Method("Method1"))
+		router.RouterSuffix(fmt.Sprintf("_SUFFIX_%03d", i), &MyHandler{}, Method("Method1"))
+	}
+	for i := 0; i < 20; i++ {
+		router.RouterExpression(fmt.Sprintf("^EXPR_%03d_[0-9]+$", i), &MyHandler{}, Method("Method1"))
+	}
+
+	return router
+}
+
+func BenchmarkHandlerRouter_MatchHandler(b *testing.B) {
+	router := newBenchmarkRouter()
+	eventIDs := []string{"EVENT_050", "PREFIX_050_ORDER", "ORDER_SUFFIX_050", "EXPR_019_12345", "NOT_MATCHED"}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router.MatchHandler(eventIDs[i%len(eventIDs)])
+	}
+}
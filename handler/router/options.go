@@ -6,11 +6,14 @@ import (
 	"git.multiverse.io/eventkit/kit/common/msg"
 	"git.multiverse.io/eventkit/kit/compensable"
 	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/handler/dedup"
 	"git.multiverse.io/eventkit/kit/interceptor"
 	"git.multiverse.io/eventkit/kit/log"
 	"git.multiverse.io/eventkit/kit/validation"
 	"github.com/go-playground/validator/v10"
 	"reflect"
+	"regexp"
+	"time"
 )
 
 // Options is a set of configuration parameters that contains HandlerOptions and Compensable and event expression.
@@ -35,9 +38,33 @@ type HandlerOptions struct {
 	InvokePreHandle                          bool
 	EnableValidation                         bool
 	CustomValidationOptions                  *CustomValidationOptions
+	Deduplication                            *dedup.Options
 	ResponseTemplate                         string
 	ResponseDataWhenErrorForResponseTemplate interface{}
 	CustomErrorWrapperFn                     msg.CustomErrorWrapperFn
+	// ContentTypes is the content types that the request is decoded and the response is encoded by the codec registered
+	// for the content type of the request(see registry.Register), other content types are handled by the codec of the handler
+	ContentTypes []string
+	// Version is the version of the handler, the handlers with the different versions can be registered under the same event ID
+	Version string
+	// IsDefaultVersion marks the handler as the default version of the event ID
+	IsDefaultVersion bool
+	// CanaryWeight is the percentage of the requests routed to the canary version
+	CanaryWeight int
+	// CanaryHeaders routes the requests with all the headers to the canary version
+	CanaryHeaders map[string]string
+	// Timeout is the timeout of the handler, it overrides the default timeout and caps the timeout propagated by the upstream
+	Timeout time.Duration
+	// TimeoutSafetyMargin is subtracted from the timeout propagated by the upstream, it overrides the margin of the service config
+	TimeoutSafetyMargin time.Duration
+	// DisableTimeout marks the handler is never canceled by the timeout
+	DisableTimeout bool
+	// Func is the adapter of the context-first handler function, the handler is invoked without reflection if it's set
+	Func FuncHandler
+	// ReadOnly marks the handler doesn't modify the data, it's still served when the route is read-only
+	ReadOnly bool
+	// expression is the precompiled matcher expression of the event ID
+	expression *regexp.Regexp
 }
 
 // CustomValidationOptions is a set of configuration parameters that perform the validation of the handler
@@ -249,6 +276,21 @@ func DisableValidation() Option {
 	}
 }
 
+// EnableDeduplication marks to skip the duplicate messages with the dedup store when execute the handler,
+// the message ID is committed into the dedup store only when the handler succeeds.
+func EnableDeduplication(store dedup.Store, dedupOptions ...dedup.Option) Option {
+	return func(options *Options) {
+		options.HandlerOptions.Deduplication = dedup.NewOptions(store, dedupOptions...)
+	}
+}
+
+// DisableDeduplication marks to disable the deduplication when execute the handler
+func DisableDeduplication() Option {
+	return func(options *Options) {
+		options.HandlerOptions.Deduplication = nil
+	}
+}
+
 // WithHandlerMethodInParams adds the method in parameter type into the router config, usually called by framework
 func WithHandlerMethodInParams(handlerMethodInParams []reflect.Type) Option {
 	return func(options *Options) {
@@ -318,3 +360,78 @@ func WithCodec(codec codec.Codec) Option {
 		options.HandlerOptions.Codec = codec
 	}
 }
+
+// WithContentTypes sets the content types that the handler accepts besides the codec of the handler,
+// the request with one of the content types is decoded and the response is encoded by the codec registered for the content type, e.g.
+//
+//	handlerRouter.Router("GetAccount", handler, router.WithContentTypes(constant.DefaultContentTypeJSON, constant.ContentTypeProtobuf))
+func WithContentTypes(contentTypes ...string) Option {
+	return func(options *Options) {
+		options.HandlerOptions.ContentTypes = contentTypes
+	}
+}
+
+// WithVersion sets the version of the handler, the handlers with the different versions can be registered under the same event ID,
+// the version is selected by the event version of the request.
+func WithVersion(version string) Option {
+	return func(options *Options) {
+		options.HandlerOptions.Version = version
+	}
+}
+
+// AsDefaultVersion marks the handler as the default version of the event ID,
+// the first registered non-canary version is the default version if not marked.
+func AsDefaultVersion() Option {
+	return func(options *Options) {
+		options.HandlerOptions.IsDefaultVersion = true
+	}
+}
+
+// WithCanaryWeight marks the handler as a canary version that receives the percentage of the requests without the event version
+func WithCanaryWeight(weight int) Option {
+	return func(options *Options) {
+		options.HandlerOptions.CanaryWeight = weight
+	}
+}
+
+// WithCanaryHeader marks the handler as a canary version that receives the requests with the header
+func WithCanaryHeader(key, value string) Option {
+	return func(options *Options) {
+		if nil == options.HandlerOptions.CanaryHeaders {
+			options.HandlerOptions.CanaryHeaders = make(map[string]string)
+		}
+		options.HandlerOptions.CanaryHeaders[key] = value
+	}
+}
+
+// ReadOnly marks the handler doesn't modify the data, the read-only handlers are still served when the route is read-only,
+// the handlers of the GET, HEAD and OPTIONS methods are read-only by default.
+func ReadOnly() Option {
+	return func(options *Options) {
+		options.HandlerOptions.ReadOnly = true
+	}
+}
+
+// WithTimeout enforces the timeout of the handler, the timeout overrides the default timeout
+// and caps the timeout propagated by the upstream.
+func WithTimeout(timeout time.Duration) Option {
+	return func(options *Options) {
+		options.HandlerOptions.Timeout = timeout
+		options.HandlerOptions.DisableTimeout = false
+	}
+}
+
+// WithTimeoutSafetyMargin sets the margin subtracted from the timeout propagated by the upstream
+func WithTimeoutSafetyMargin(margin time.Duration) Option {
+	return func(options *Options) {
+		options.HandlerOptions.TimeoutSafetyMargin = margin
+	}
+}
+
+// DisableTimeout marks the handler is never canceled by the timeout, e.g. the handler that cannot be interrupted
+func DisableTimeout() Option {
+	return func(options *Options) {
+		options.HandlerOptions.Timeout = 0
+		options.HandlerOptions.DisableTimeout = true
+	}
+}
@@ -0,0 +1,96 @@
+package router
+
+import (
+	"sort"
+	"strings"
+)
+
+// urlPathRoute is a URL path registered with the parameters, e.g. `/accounts/:id` or `/files/*filepath`
+type urlPathRoute struct {
+	pattern  string
+	segments []string
+	// literals is the number of the literal segments, the route with more literal segments is matched first
+	literals int
+	options  *Options
+}
+
+func isURLPathPattern(urlPath string) bool {
+	return strings.Contains(urlPath, "/:") || strings.Contains(urlPath, "/*")
+}
+
+func newURLPathRoute(pattern string, options *Options) *urlPathRoute {
+	route := &urlPathRoute{
+		pattern:  pattern,
+		segments: strings.Split(strings.Trim(pattern, "/"), "/"),
+		options:  options,
+	}
+	for _, segment := range route.segments {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			route.literals++
+		}
+	}
+
+	return route
+}
+
+// match returns the path parameters if the URL path matches the route
+func (r *urlPathRoute) match(urlPath string) (map[string]string, bool) {
+	segments := strings.Split(strings.Trim(urlPath, "/"), "/")
+	params := make(map[string]string)
+	for i, segment := range r.segments {
+		if strings.HasPrefix(segment, "*") {
+			// the catch-all parameter matches the rest of the URL path
+			params[segment[1:]] = "/" + strings.Join(segments[i:], "/")
+			return params, true
+		}
+		if i >= len(segments) {
+			return nil, false
+		}
+		if strings.HasPrefix(segment, ":") {
+			if "" == segments[i] {
+				return nil, false
+			}
+			params[segment[1:]] = segments[i]
+		} else if segment != segments[i] {
+			return nil, false
+		}
+	}
+	if len(segments) != len(r.segments) {
+		return nil, false
+	}
+
+	return params, true
+}
+
+// addURLPathRoute adds the URL path with the parameters, the routes are ordered by the number of the literal segments
+func (e *HandlerRouter) addURLPathRoute(pattern string, options *Options) {
+	for i, route := range e.urlPathRoutes {
+		if route.pattern == pattern {
+			e.urlPathRoutes[i] = newURLPathRoute(pattern, options)
+			return
+		}
+	}
+	e.urlPathRoutes = append(e.urlPathRoutes, newURLPathRoute(pattern, options))
+	sort.SliceStable(e.urlPathRoutes, func(i, j int) bool {
+		return e.urlPathRoutes[i].literals > e.urlPathRoutes[j].literals
+	})
+}
+
+// MatchURLPath finds the handler and the path parameters by URL path,
+// the exact URL path is matched first, then the URL paths with parameters(e.g. `/accounts/:id`).
+// returns nil if the URL path cannot match any router.
+func (e *HandlerRouter) MatchURLPath(urlPath string) (*Options, map[string]string) {
+	e.RLock()
+	defer e.RUnlock()
+
+	if options, ok := e.URLPathHandlers[urlPath]; ok && !isURLPathPattern(urlPath) {
+		return options, nil
+	}
+	for _, route := range e.urlPathRoutes {
+		if params, ok := route.match(urlPath); ok {
+			return route.options, params
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,38 @@
+package router
+
+import (
+	"testing"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+)
+
+func TestHandlerRouter_MatchURLPath(t *testing.T) {
+	router := &HandlerRouter{}
+	router.Router("GetAccount", &MyHandler{}, Method("Method1"), HandleGet("/v1/accounts/:id"))
+	router.Router("GetCurrentAccount", &MyHandler{}, Method("Method2"), HandleGet("/v1/accounts/current"))
+	router.Router("GetTransaction", &MyHandler{}, Method("Method3"), HandleGet("/v1/accounts/:id/transactions/:seq"))
+	router.Router("GetFile", &MyHandler{}, Method("Method1"), HandleGet("/v1/files/*filepath"))
+
+	o, params := router.MatchURLPath("/v1/accounts/current")
+	assert.Equal(t, "GetCurrentAccount", o.EventExpression)
+	assert.Equal(t, 0, len(params))
+
+	o, params = router.MatchURLPath("/v1/accounts/10001")
+	assert.Equal(t, "GetAccount", o.EventExpression)
+	assert.Equal(t, map[string]string{"id": "10001"}, params)
+
+	o, params = router.MatchURLPath("/v1/accounts/10001/transactions/3")
+	assert.Equal(t, "GetTransaction", o.EventExpression)
+	assert.Equal(t, map[string]string{"id": "10001", "seq": "3"}, params)
+
+	o, params = router.MatchURLPath("/v1/files/a/b.txt")
+	assert.Equal(t, "GetFile", o.EventExpression)
+	assert.Equal(t, map[string]string{"filepath": "/a/b.txt"}, params)
+
+	o, _ = router.MatchURLPath("/v1/accounts/10001/transactions")
+	assert.True(t, nil == o)
+	o, _ = router.MatchURLPath("/v1/accounts/:id")
+	assert.Equal(t, "GetAccount", o.EventExpression)
+	assert.True(t, nil == router.MatchHandlerWithURLPath("/v1/accounts"))
+	assert.Equal(t, "GetAccount", router.MatchHandlerWithURLPath("/v1/accounts/10002").EventExpression)
+}
@@ -0,0 +1,136 @@
+package router
+
+import (
+	"sort"
+	"strings"
+
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/handler/config"
+)
+
+// AllRoutes is the key of the route switch that applies to all the routes
+const AllRoutes = "*"
+
+// RouteSwitchChange is a change of the route switch
+type RouteSwitchChange struct {
+	Route string
+	Old   config.RouteSwitch
+	New   config.RouteSwitch
+}
+
+// routeKeyOf returns the lowercase key of the route switch, the config loader lowercases the keys
+func routeKeyOf(route string) string {
+	return strings.ToLower(route)
+}
+
+// SetRouteSwitches replaces the route switches, returns the changes and the switches rejected for the unknown mode
+// sorted by the route, the previous switches of the rejected routes are kept.
+func (e *HandlerRouter) SetRouteSwitches(switches map[string]config.RouteSwitch) (changes []RouteSwitchChange, rejected []RouteSwitchChange) {
+	e.Lock()
+	defer e.Unlock()
+
+	normalized := make(map[string]config.RouteSwitch, len(switches))
+	for route, s := range switches {
+		if "" != s.Route {
+			route = s.Route
+		}
+		route = routeKeyOf(route)
+		if !s.IsValid() {
+			rejected = append(rejected, RouteSwitchChange{Route: route, Old: e.routeSwitches[route], New: s})
+			if oldSwitch, ok := e.routeSwitches[route]; ok {
+				normalized[route] = oldSwitch
+			}
+			continue
+		}
+		s.Route = ""
+		s.Mode = strings.ToLower(s.Mode)
+		normalized[route] = s
+	}
+
+	changes = make([]RouteSwitchChange, 0)
+	for route, newSwitch := range normalized {
+		if oldSwitch := e.routeSwitches[route]; oldSwitch != newSwitch && !(oldSwitch.IsEnabled() && newSwitch.IsEnabled()) {
+			changes = append(changes, RouteSwitchChange{Route: route, Old: oldSwitch, New: newSwitch})
+		}
+	}
+	for route, oldSwitch := range e.routeSwitches {
+		if _, ok := normalized[route]; !ok && !oldSwitch.IsEnabled() {
+			changes = append(changes, RouteSwitchChange{Route: route, Old: oldSwitch})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Route < changes[j].Route
+	})
+	sort.Slice(rejected, func(i, j int) bool {
+		return rejected[i].Route < rejected[j].Route
+	})
+	e.routeSwitches = normalized
+
+	return changes, rejected
+}
+
+// RouteSwitchOf returns the switch of the handler serving the event ID, the switch of the event ID takes precedence over
+// the event expression(e.g. the prefix) and the URL path, then the switch of all the routes
+func (e *HandlerRouter) RouteSwitchOf(options *Options, eventID string) config.RouteSwitch {
+	e.RLock()
+	defer e.RUnlock()
+
+	if 0 == len(e.routeSwitches) || nil == options {
+		return config.RouteSwitch{}
+	}
+	for _, route := range []string{eventID, options.EventExpression, options.HandlerOptions.URLPath, AllRoutes} {
+		if "" == route {
+			continue
+		}
+		if s, ok := e.routeSwitches[routeKeyOf(route)]; ok {
+			return s
+		}
+	}
+
+	return config.RouteSwitch{}
+}
+
+func isReadOnlyHandler(options *Options) bool {
+	if options.HandlerOptions.ReadOnly {
+		return true
+	}
+	if "" == options.HandlerOptions.URLPath {
+		return false
+	}
+	switch options.HandlerOptions.HTTPMethod {
+	case constant.HTTPMethodGet, constant.HTTPMethodHead, constant.HTTPMethodOptions:
+		return true
+	}
+
+	return false
+}
+
+// CheckRouteSwitch returns the error if the handler cannot serve the event ID by the switch of the route,
+// the event ID is empty for the URL path requests
+func (e *HandlerRouter) CheckRouteSwitch(options *Options, eventID string) *errors.Error {
+	s := e.RouteSwitchOf(options, eventID)
+	route := eventID
+	if "" == route {
+		route = options.EventExpression
+	}
+	switch s.Mode {
+	case config.RouteModeDisabled:
+		return errors.Errorf(constant.RouteDisabledError, "The route[%s] is disabled", route)
+	case config.RouteModeMaintenance:
+		if "" != s.ErrorCode {
+			return errors.Errorf(s.ErrorCode, "%s", s.ErrorMessage)
+		}
+		message := s.ErrorMessage
+		if "" == message {
+			message = "The route[" + route + "] is under maintenance"
+		}
+		return errors.Errorf(constant.RouteUnderMaintenanceError, "%s", message)
+	case config.RouteModeReadOnly:
+		if !isReadOnlyHandler(options) {
+			return errors.Errorf(constant.RouteReadOnlyError, "The route[%s] is read-only", route)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package router
+
+import (
+	"strings"
+	"testing"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/handler/config"
+)
+
+func TestHandlerRouter_SetRouteSwitches(t *testing.T) {
+	router := &HandlerRouter{}
+	changes, rejected := router.SetRouteSwitches(map[string]config.RouteSwitch{
+		"ORDER": {Mode: config.RouteModeDisabled},
+		"PAY":   {Mode: config.RouteModeEnabled},
+	})
+	assert.Equal(t, 0, len(rejected))
+	assert.Equal(t, 1, len(changes))
+	assert.Equal(t, "order", changes[0].Route)
+	assert.True(t, changes[0].Old.IsEnabled())
+	assert.Equal(t, config.RouteModeDisabled, changes[0].New.Mode)
+
+	// the removed switches are changed to enabled
+	changes, _ = router.SetRouteSwitches(map[string]config.RouteSwitch{
+		AllRoutes: {Mode: config.RouteModeReadOnly},
+	})
+	assert.Equal(t, 2, len(changes))
+	assert.Equal(t, AllRoutes, changes[0].Route)
+	assert.Equal(t, "order", changes[1].Route)
+	assert.True(t, changes[1].New.IsEnabled())
+
+	changes, _ = router.SetRouteSwitches(map[string]config.RouteSwitch{AllRoutes: {Mode: config.RouteModeReadOnly}})
+	assert.Equal(t, 0, len(changes))
+
+	// the modes are case-insensitive, the unknown modes are rejected and the previous switches are kept
+	changes, rejected = router.SetRouteSwitches(map[string]config.RouteSwitch{
+		AllRoutes: {Mode: "Disabled"},
+		"ORDER":   {Mode: "paused"},
+	})
+	assert.Equal(t, 1, len(changes))
+	assert.Equal(t, config.RouteModeDisabled, changes[0].New.Mode)
+	assert.Equal(t, 1, len(rejected))
+	assert.Equal(t, "order", rejected[0].Route)
+	assert.Equal(t, "paused", rejected[0].New.Mode)
+	changes, rejected = router.SetRouteSwitches(map[string]config.RouteSwitch{
+		AllRoutes: {Mode: "Disabled"},
+		"ORDER":   {Mode: config.RouteModeMaintenance},
+	})
+	assert.Equal(t, 0, len(rejected))
+	assert.Equal(t, 1, len(changes))
+	changes, rejected = router.SetRouteSwitches(map[string]config.RouteSwitch{
+		AllRoutes: {Mode: "Disabled"},
+		"ORDER":   {Mode: "paused"},
+	})
+	assert.Equal(t, 0, len(changes))
+	assert.Equal(t, 1, len(rejected))
+	assert.Equal(t, config.RouteModeMaintenance, rejected[0].Old.Mode)
+}
+
+func TestHandlerRouter_CheckRouteSwitch(t *testing.T) {
+	router := &HandlerRouter{}
+	order := router.Router("ORDER", &MyHandler{}, Method("Method1"), HandlePost("/v1/orders"))
+	query := router.Router("QUERY_ORDER", &MyHandler{}, Method("Method2"), HandleGet("/v1/orders/:id"))
+	pay := router.Router("PAY", &MyHandler{}, Method("Method3"), ReadOnly())
+	prefix := router.RouterPrefix("REFUND_", &MyHandler{}, Method("Method1"))
+
+	assert.True(t, nil == router.CheckRouteSwitch(order, "ORDER"))
+
+	router.SetRouteSwitches(map[string]config.RouteSwitch{
+		"ORDER":          {Mode: config.RouteModeDisabled},
+		"/v1/orders/:id": {Mode: config.RouteModeMaintenance},
+		"REFUND_":        {Mode: config.RouteModeMaintenance, ErrorCode: "MAINTENANCE", ErrorMessage: "back at 10:00"},
+		AllRoutes:        {Mode: config.RouteModeReadOnly},
+	})
+	assert.Equal(t, constant.RouteDisabledError, router.CheckRouteSwitch(order, "ORDER").ErrorCode)
+	// the switch of the URL path
+	assert.Equal(t, constant.RouteUnderMaintenanceError, router.CheckRouteSwitch(query, "").ErrorCode)
+	// the custom error of the maintenance mode
+	err := router.CheckRouteSwitch(prefix, "REFUND_001")
+	assert.Equal(t, "MAINTENANCE", err.ErrorCode)
+	assert.True(t, strings.Contains(err.Error(), "back at 10:00"))
+	// the read-only handler is served in the read-only mode
+	assert.True(t, nil == router.CheckRouteSwitch(pay, "PAY"))
+
+	router.SetRouteSwitches(map[string]config.RouteSwitch{AllRoutes: {Mode: config.RouteModeReadOnly}})
+	assert.Equal(t, constant.RouteReadOnlyError, router.CheckRouteSwitch(order, "ORDER").ErrorCode)
+	assert.True(t, nil == router.CheckRouteSwitch(query, ""))
+
+	router.SetRouteSwitches(nil)
+	assert.True(t, nil == router.CheckRouteSwitch(order, "ORDER"))
+
+	// the switches of the event IDs served by the prefix route and the keys lowercased or split by the config loader
+	router.SetRouteSwitches(map[string]config.RouteSwitch{
+		"refund_002": {Mode: config.RouteModeDisabled},
+		"anything":   {Route: "REFUND_V1.003", Mode: config.RouteModeDisabled},
+	})
+	assert.True(t, nil == router.CheckRouteSwitch(prefix, "REFUND_001"))
+	err = router.CheckRouteSwitch(prefix, "REFUND_002")
+	assert.Equal(t, constant.RouteDisabledError, err.ErrorCode)
+	assert.True(t, strings.Contains(err.Error(), "REFUND_002"))
+	assert.Equal(t, constant.RouteDisabledError, router.CheckRouteSwitch(prefix, "REFUND_V1.003").ErrorCode)
+}
@@ -0,0 +1,162 @@
+package router
+
+import (
+	"math/rand"
+	"sort"
+
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/msg"
+	"git.multiverse.io/eventkit/kit/constant"
+)
+
+// canaryPercent returns a random percentage in [0, 100) used by the weighted canary selection
+var canaryPercent = func() int {
+	return rand.Intn(100)
+}
+
+func isCanary(options *Options) bool {
+	return options.HandlerOptions.CanaryWeight > 0 || len(options.HandlerOptions.CanaryHeaders) > 0
+}
+
+// versionedHandlers stores the versions of the handler registered under the same event ID
+type versionedHandlers struct {
+	versions map[string]*Options
+	// first is the first registered version, it's the default version if all the versions are canaries
+	first          *Options
+	defaultVersion *Options
+	canaries       []*Options
+}
+
+func (v *versionedHandlers) add(eventID string, options *Options) {
+	version := options.HandlerOptions.Version
+	if _, ok := v.versions[version]; ok {
+		panic(errors.Errorf(constant.SystemInternalError, "Duplicate version[%s] of event ID:%s", version, eventID))
+	}
+	if isCanary(options) {
+		if options.HandlerOptions.IsDefaultVersion {
+			panic(errors.Errorf(constant.SystemInternalError, "The canary version[%s] of event ID[%s] cannot be the default version", version, eventID))
+		}
+		weight := options.HandlerOptions.CanaryWeight
+		for _, canary := range v.canaries {
+			weight += canary.HandlerOptions.CanaryWeight
+		}
+		if options.HandlerOptions.CanaryWeight < 0 || weight > 100 {
+			panic(errors.Errorf(constant.SystemInternalError, "Invalid canary weight[%d] of version[%s] of event ID[%s], the sum of the canary weights cannot be great than 100",
+				options.HandlerOptions.CanaryWeight, version, eventID))
+		}
+		v.canaries = append(v.canaries, options)
+	} else if options.HandlerOptions.IsDefaultVersion {
+		if nil != v.defaultVersion && v.defaultVersion.HandlerOptions.IsDefaultVersion {
+			panic(errors.Errorf(constant.SystemInternalError, "Duplicate default version of event ID[%s]: %s and %s", eventID, v.defaultVersion.HandlerOptions.Version, version))
+		}
+		v.defaultVersion = options
+	} else if nil == v.defaultVersion {
+		v.defaultVersion = options
+	}
+	if nil == v.first {
+		v.first = options
+	}
+	v.versions[version] = options
+}
+
+func (v *versionedHandlers) defaultOptions() *Options {
+	if nil != v.defaultVersion {
+		return v.defaultVersion
+	}
+
+	return v.first
+}
+
+// selectVersion selects the version by the event version of the request, the canary headers, the canary weights,
+// then falls back to the default version
+func (v *versionedHandlers) selectVersion(request *msg.Message) *Options {
+	if nil == request {
+		return v.defaultOptions()
+	}
+	if options, ok := v.versions[request.GetMsgTopicVersion()]; ok {
+		return options
+	}
+
+	for _, canary := range v.canaries {
+		if len(canary.HandlerOptions.CanaryHeaders) > 0 && matchCanaryHeaders(canary.HandlerOptions.CanaryHeaders, request) {
+			return canary
+		}
+	}
+
+	if len(v.canaries) > 0 {
+		percent := canaryPercent()
+		for _, canary := range v.canaries {
+			if percent < canary.HandlerOptions.CanaryWeight {
+				return canary
+			}
+			percent -= canary.HandlerOptions.CanaryWeight
+		}
+	}
+
+	return v.defaultOptions()
+}
+
+func matchCanaryHeaders(headers map[string]string, request *msg.Message) bool {
+	for k, v := range headers {
+		if request.GetAppPropertyIgnoreCaseSilence(k) != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// addVersion adds the version of the handler registered under the event ID, returns the default version
+func (e *HandlerRouter) addVersion(eventID string, options *Options) *Options {
+	if nil == e.versions {
+		e.versions = make(map[string]*versionedHandlers)
+	}
+	versions, ok := e.versions[eventID]
+	if !ok {
+		versions = &versionedHandlers{versions: make(map[string]*Options)}
+		e.versions[eventID] = versions
+	}
+	versions.add(eventID, options)
+
+	return versions.defaultOptions()
+}
+
+// Versions returns the versions of the handler registered under the event ID
+func (e *HandlerRouter) Versions(eventID string) []string {
+	e.RLock()
+	defer e.RUnlock()
+
+	versions, ok := e.versions[eventID]
+	if !ok {
+		return nil
+	}
+	result := make([]string, 0, len(versions.versions))
+	for version := range versions.versions {
+		result = append(result, version)
+	}
+	sort.Strings(result)
+
+	return result
+}
+
+// MatchHandlerWithVersion finds the handler by the event ID and the request,
+// the version is selected by the event version of the request, the canary headers, the canary weights,
+// then falls back to the default version.
+func (e *HandlerRouter) MatchHandlerWithVersion(eventID string, request *msg.Message) *Options {
+	if options := e.matchVersion(eventID, request); nil != options {
+		return options
+	}
+
+	return e.MatchHandler(eventID)
+}
+
+func (e *HandlerRouter) matchVersion(eventID string, request *msg.Message) *Options {
+	e.RLock()
+	defer e.RUnlock()
+
+	if versions, ok := e.versions[eventID]; ok {
+		return versions.selectVersion(request)
+	}
+
+	return nil
+}
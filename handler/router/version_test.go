@@ -0,0 +1,104 @@
+package router
+
+import (
+	"testing"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/msg"
+	"git.multiverse.io/eventkit/kit/constant"
+)
+
+func versionRequest(version string, appProps map[string]string) *msg.Message {
+	request := &msg.Message{TopicAttribute: map[string]string{constant.TopicDestinationVersion: version}}
+	request.SetAppProps(appProps)
+
+	return request
+}
+
+func TestHandlerRouter_RouterWithVersion(t *testing.T) {
+	router := &HandlerRouter{}
+	router.Router("ORDER", &MyHandler{}, Method("Method1"), WithVersion("v1"))
+	router.Router("ORDER", &MyHandler{}, Method("Method2"), WithVersion("v2"), AsDefaultVersion())
+	router.Router("ORDER", &MyHandler{}, Method("Method3"), WithVersion("v3"), WithCanaryHeader("X-Canary", "true"))
+	assert.Equal(t, []string{"v1", "v2", "v3"}, router.Versions("ORDER"))
+	assert.Equal(t, 0, len(router.Versions("UNKNOWN")))
+
+	// the default version is used by MatchHandler
+	assert.Equal(t, "v2", router.MatchHandler("ORDER").HandlerOptions.Version)
+
+	// the version of the request
+	assert.Equal(t, "Method1", router.MatchHandlerWithVersion("ORDER", versionRequest("v1", nil)).HandlerOptions.HandlerMethodName)
+	assert.Equal(t, "Method3", router.MatchHandlerWithVersion("ORDER", versionRequest("v3", nil)).HandlerOptions.HandlerMethodName)
+	// the canary header
+	assert.Equal(t, "Method3", router.MatchHandlerWithVersion("ORDER", versionRequest("", map[string]string{"x-canary": "true"})).HandlerOptions.HandlerMethodName)
+	// falls back to the default version
+	assert.Equal(t, "Method2", router.MatchHandlerWithVersion("ORDER", versionRequest("v9", nil)).HandlerOptions.HandlerMethodName)
+	assert.Equal(t, "Method2", router.MatchHandlerWithVersion("ORDER", nil).HandlerOptions.HandlerMethodName)
+
+	// the event IDs without versions
+	router.Router("PAY", &MyHandler{}, Method("Method1"))
+	assert.Equal(t, "Method1", router.MatchHandlerWithVersion("PAY", versionRequest("v1", nil)).HandlerOptions.HandlerMethodName)
+	assert.True(t, nil == router.MatchHandlerWithVersion("UNKNOWN", nil))
+}
+
+func TestHandlerRouter_RouterWithCanaryWeight(t *testing.T) {
+	defer func(f func() int) {
+		canaryPercent = f
+	}(canaryPercent)
+
+	router := &HandlerRouter{}
+	router.Router("ORDER", &MyHandler{}, Method("Method2"), WithVersion("v2"), WithCanaryWeight(10))
+	router.Router("ORDER", &MyHandler{}, Method("Method3"), WithVersion("v3"), WithCanaryWeight(20))
+	router.Router("ORDER", &MyHandler{}, Method("Method1"), WithVersion("v1"))
+	// the first non-canary version is the default version
+	assert.Equal(t, "v1", router.MatchHandler("ORDER").HandlerOptions.Version)
+
+	expectations := map[int]string{0: "v2", 9: "v2", 10: "v3", 29: "v3", 30: "v1", 99: "v1"}
+	for percent, version := range expectations {
+		canaryPercent = func() int {
+			return percent
+		}
+		assert.Equal(t, version, router.MatchHandlerWithVersion("ORDER", versionRequest("", nil)).HandlerOptions.Version)
+	}
+}
+
+func TestHandlerRouter_RouterWithInvalidVersions(t *testing.T) {
+	expectPanic := func(f func()) {
+		defer func() {
+			assert.NotNil(t, recover())
+		}()
+		f()
+	}
+
+	router := &HandlerRouter{}
+	router.Router("ORDER", &MyHandler{}, Method("Method1"), WithVersion("v1"))
+	// duplicate version
+	expectPanic(func() {
+		router.Router("ORDER", &MyHandler{}, Method("Method2"), WithVersion("v1"))
+	})
+	// the handler without version under the versioned event ID
+	expectPanic(func() {
+		router.Router("ORDER", &MyHandler{}, Method("Method2"))
+	})
+	// the canary weights great than 100
+	router.Router("ORDER", &MyHandler{}, Method("Method2"), WithVersion("v2"), WithCanaryWeight(60))
+	expectPanic(func() {
+		router.Router("ORDER", &MyHandler{}, Method("Method3"), WithVersion("v3"), WithCanaryWeight(50))
+	})
+	// the canary version as the default version
+	expectPanic(func() {
+		router.Router("ORDER", &MyHandler{}, Method("Method3"), WithVersion("v4"), WithCanaryWeight(10), AsDefaultVersion())
+	})
+}
+
+func TestHandlerRouter_RouterWithVersionAndURLPath(t *testing.T) {
+	router := &HandlerRouter{}
+	router.Router("ORDER", &MyHandler{}, Method("Method1"), WithVersion("v1"), HandleGet("/v1/orders/:id"))
+	router.Router("ORDER", &MyHandler{}, Method("Method2"), WithVersion("v2"), WithCanaryWeight(10), HandleGet("/v1/orders/:id"))
+
+	// the URL path is routed to the default version
+	options, params := router.MatchURLPath("/v1/orders/100")
+	assert.Equal(t, "v1", options.HandlerOptions.Version)
+	assert.Equal(t, "100", params["id"])
+	assert.Equal(t, "v1", router.URLPathHandlers["/v1/orders/:id"].HandlerOptions.Version)
+}
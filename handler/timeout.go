@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"context"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/msg"
+	"git.multiverse.io/eventkit/kit/common/shutdown"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/handler/base"
+	"git.multiverse.io/eventkit/kit/handler/config"
+	"git.multiverse.io/eventkit/kit/handler/remote"
+	"git.multiverse.io/eventkit/kit/handler/router"
+	"git.multiverse.io/eventkit/kit/log"
+)
+
+// handlerInvocation is the result of the handler invoked with the deadline
+type handlerInvocation struct {
+	ins      base.HandlerInterface
+	response *msg.Message
+	err      error
+}
+
+// handlerDeadline returns the deadline of the handler, the deadline is derived from the timeout propagated by the upstream
+// minus the safety margin, the timeout of the route overrides the default timeout and caps the propagated timeout.
+// Returns false if the timeout of the handler isn't enforced.
+// The compensable handlers are never canceled, the try phase abandoned at the deadline would keep writing
+// while the root transaction rolls back, the timeout of the transaction applies to them instead.
+func (e *EventCallback) handlerDeadline(hp *router.Options, request *msg.Message, st time.Time) (time.Time, bool) {
+	if hp.HandlerOptions.DisableTimeout || nil != hp.Compensable {
+		return time.Time{}, false
+	}
+	var timeoutConfig config.HandlerTimeout
+	if nil != e.serviceConfig {
+		timeoutConfig = e.serviceConfig.HandlerTimeout
+	}
+	if !timeoutConfig.Enable && hp.HandlerOptions.Timeout <= 0 {
+		return time.Time{}, false
+	}
+
+	var propagated time.Duration
+	if nil != request {
+		if to3, err := strconv.Atoi(request.GetAppPropertySilence(constant.To3)); nil == err && to3 > 0 {
+			propagated = time.Duration(to3) * time.Millisecond
+		}
+	}
+
+	var timeout time.Duration
+	if propagated > 0 {
+		margin := hp.HandlerOptions.TimeoutSafetyMargin
+		if margin <= 0 && timeoutConfig.SafetyMarginMilliseconds > 0 {
+			margin = time.Duration(timeoutConfig.SafetyMarginMilliseconds) * time.Millisecond
+		}
+		if margin <= 0 {
+			margin = constant.DefaultTimeoutSafetyMarginMilliseconds * time.Millisecond
+		}
+		// the margin takes at most half of the propagated timeout, otherwise the small budget expires before handling
+		if margin > propagated/2 {
+			margin = propagated / 2
+		}
+		timeout = propagated - margin
+		if hp.HandlerOptions.Timeout > 0 && hp.HandlerOptions.Timeout < timeout {
+			timeout = hp.HandlerOptions.Timeout
+		}
+	} else if hp.HandlerOptions.Timeout > 0 {
+		timeout = hp.HandlerOptions.Timeout
+	} else {
+		timeout = constant.DefaultTimeoutMilliseconds * time.Millisecond
+	}
+
+	return st.Add(timeout), true
+}
+
+// invokeHandlerWithDeadline invokes the handler with the context canceled at the deadline,
+// the timeout error is returned at the deadline without waiting for the handler.
+// The handler instance is set only if the handler finished before the deadline,
+// otherwise the returned channel is closed when the abandoned handler returns.
+// The abandoned handler is tracked by the shutdown coordinator, so the graceful shutdown waits for it before closing the resources.
+func (e *EventCallback) invokeHandlerWithDeadline(ctx context.Context, deadline time.Time, hp *router.Options, request *msg.Message,
+	remoteCall remote.CallInc, lang string, ins *base.HandlerInterface) (*msg.Message, <-chan struct{}, error) {
+	timeoutErr := func() error {
+		return errors.Errorf(constant.HandlerTimeoutError, "The handler of event[%s] is timeout, deadline:%s", hp.EventExpression, deadline.Format(time.RFC3339Nano))
+	}
+	if !time.Now().Before(deadline) {
+		return nil, nil, timeoutErr()
+	}
+
+	handleCtx, cancel := context.WithDeadline(ctx, deadline)
+	defer cancel()
+
+	done := make(chan handlerInvocation, 1)
+	returned := make(chan struct{})
+	taskDone := shutdown.Track()
+	go func() {
+		defer taskDone()
+		defer close(returned)
+		defer func() {
+			if r := recover(); nil != r {
+				done <- handlerInvocation{err: errors.Errorf(constant.SystemInternalError, "panic, error=%++v, stack=%s", r, debug.Stack())}
+			}
+		}()
+		invocation := handlerInvocation{}
+		invocation.ins, invocation.response, invocation.err = e.invokeHandler(handleCtx, hp, request, remoteCall, lang)
+		done <- invocation
+	}()
+
+	finish := func(invocation handlerInvocation) (*msg.Message, <-chan struct{}, error) {
+		*ins = invocation.ins
+		// the handler failed because the deadline is exceeded, e.g. the handler returned the error of the context
+		if nil != invocation.err && context.DeadlineExceeded == handleCtx.Err() {
+			return nil, nil, timeoutErr()
+		}
+		return invocation.response, nil, invocation.err
+	}
+
+	select {
+	case invocation := <-done:
+		return finish(invocation)
+	case <-handleCtx.Done():
+		// the handler may finish at the deadline
+		select {
+		case invocation := <-done:
+			return finish(invocation)
+		default:
+		}
+		log.Warnf(ctx, "The handler of event[%s] is canceled at the deadline[%s]", hp.EventExpression, deadline.Format(time.RFC3339Nano))
+		return nil, returned, timeoutErr()
+	}
+}
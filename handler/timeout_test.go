@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/msg"
+	"git.multiverse.io/eventkit/kit/common/shutdown"
+	"git.multiverse.io/eventkit/kit/compensable"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/handler/config"
+	"git.multiverse.io/eventkit/kit/handler/dedup"
+	"git.multiverse.io/eventkit/kit/handler/router"
+)
+
+func TestHandlerDeadline(t *testing.T) {
+	callbackExecutor := NewCallbackExecutor()
+	st := time.Now()
+	request := &msg.Message{}
+	request.SetAppProperty(constant.To3, "1000")
+	hp := &router.Options{}
+
+	// the timeout isn't enforced by default
+	_, ok := callbackExecutor.handlerDeadline(hp, request, st)
+	assert.False(t, ok)
+
+	// the propagated timeout minus the default safety margin
+	callbackExecutor.serviceConfig = &config.Service{HandlerTimeout: config.HandlerTimeout{Enable: true}}
+	deadline, ok := callbackExecutor.handlerDeadline(hp, request, st)
+	assert.True(t, ok)
+	assert.Equal(t, st.Add(900*time.Millisecond), deadline)
+
+	callbackExecutor.serviceConfig.HandlerTimeout.SafetyMarginMilliseconds = 200
+	deadline, _ = callbackExecutor.handlerDeadline(hp, request, st)
+	assert.Equal(t, st.Add(800*time.Millisecond), deadline)
+
+	// the route options
+	hp.HandlerOptions.TimeoutSafetyMargin = 300 * time.Millisecond
+	deadline, _ = callbackExecutor.handlerDeadline(hp, request, st)
+	assert.Equal(t, st.Add(700*time.Millisecond), deadline)
+
+	hp.HandlerOptions.Timeout = 500 * time.Millisecond
+	deadline, _ = callbackExecutor.handlerDeadline(hp, request, st)
+	assert.Equal(t, st.Add(500*time.Millisecond), deadline)
+
+	// the route timeout overrides the default timeout
+	deadline, _ = callbackExecutor.handlerDeadline(hp, &msg.Message{}, st)
+	assert.Equal(t, st.Add(500*time.Millisecond), deadline)
+	hp.HandlerOptions.Timeout = 0
+	deadline, _ = callbackExecutor.handlerDeadline(hp, &msg.Message{}, st)
+	assert.Equal(t, st.Add(constant.DefaultTimeoutMilliseconds*time.Millisecond), deadline)
+
+	// the safety margin takes at most half of the small propagated timeout
+	hp.HandlerOptions.TimeoutSafetyMargin = 0
+	callbackExecutor.serviceConfig.HandlerTimeout.SafetyMarginMilliseconds = 0
+	smallRequest := &msg.Message{}
+	smallRequest.SetAppProperty(constant.To3, "50")
+	deadline, _ = callbackExecutor.handlerDeadline(hp, smallRequest, st)
+	assert.Equal(t, st.Add(25*time.Millisecond), deadline)
+
+	hp.HandlerOptions.DisableTimeout = true
+	_, ok = callbackExecutor.handlerDeadline(hp, request, st)
+	assert.False(t, ok)
+
+	// the compensable handlers are never canceled
+	hp.HandlerOptions.DisableTimeout = false
+	hp.HandlerOptions.Timeout = 500 * time.Millisecond
+	hp.Compensable = &compensable.Compensable{TryMethod: "Try"}
+	_, ok = callbackExecutor.handlerDeadline(hp, request, st)
+	assert.False(t, ok)
+}
+
+func slowFunc(ctx context.Context, request *Request) (*Response, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-time.After(time.Duration(len(request.A)) * 100 * time.Millisecond):
+	}
+
+	return &Response{B: request.A}, nil
+}
+
+func TestInvokeHandlerWithTimeout(t *testing.T) {
+	callbackExecutor := NewCallbackExecutor()
+	routerRegister := &router.HandlerRouter{}
+	routerRegister.Router("SLOW_TOPIC", router.Func(slowFunc), router.WithTimeout(150*time.Millisecond))
+	callbackExecutor.SetRouter(routerRegister)
+	callbackExecutor.serviceConfig = &config.Service{ServiceID: "test"}
+
+	topicAttribute, _ := BuildBussinessTopicAttributes("ORG001", "WKS1", "ENV1", "SU001", "V1", "SLOW_TOPIC")
+	handle := func(body string) (*msg.Message, time.Duration) {
+		st := time.Now()
+		response, err := callbackExecutor.Handle(context.Background(), &msg.Message{TopicAttribute: topicAttribute, Body: []byte(body)})
+		assert.True(t, nil == err)
+		return response, time.Since(st)
+	}
+
+	response, _ := handle(`{"A":"a"}`)
+	assert.Equal(t, "", response.GetAppPropertySilence(constant.ReturnErrorCode))
+
+	response, elapsed := handle(`{"A":"aaaaaaaaaa"}`)
+	assert.Equal(t, constant.HandlerTimeoutError, response.GetAppPropertySilence(constant.ReturnErrorCode))
+	assert.Equal(t, http.StatusGatewayTimeout, callbackExecutor.httpStatusOf(response))
+	assert.True(t, elapsed < time.Second)
+}
+
+func uncancelableFunc(ctx context.Context, request *Request) (*Response, error) {
+	time.Sleep(300 * time.Millisecond)
+	return &Response{B: request.A}, nil
+}
+
+func TestInvokeHandlerWithTimeoutAndDeduplication(t *testing.T) {
+	callbackExecutor := NewCallbackExecutor()
+	routerRegister := &router.HandlerRouter{}
+	routerRegister.Router("UNCANCELABLE_TOPIC", router.Func(uncancelableFunc), router.WithTimeout(100*time.Millisecond),
+		router.EnableDeduplication(dedup.NewMemoryStore()))
+	callbackExecutor.SetRouter(routerRegister)
+	callbackExecutor.serviceConfig = &config.Service{ServiceID: "test"}
+
+	topicAttribute, _ := BuildBussinessTopicAttributes("ORG001", "WKS1", "ENV1", "SU001", "V1", "UNCANCELABLE_TOPIC")
+	handle := func() *msg.Message {
+		response, err := callbackExecutor.Handle(context.Background(), &msg.Message{ID: 1, TopicAttribute: topicAttribute, Body: []byte(`{"A":"a"}`)})
+		assert.True(t, nil == err)
+		return response
+	}
+
+	response := handle()
+	assert.Equal(t, constant.HandlerTimeoutError, response.GetAppPropertySilence(constant.ReturnErrorCode))
+	// the abandoned handler is tracked until it returns
+	backgroundTasks := shutdown.CurrentProgress().BackgroundTasks
+	assert.True(t, backgroundTasks > 0)
+
+	// the message is reserved until the abandoned handler returns
+	response = handle()
+	assert.Equal(t, constant.DuplicateMessageInFlightError, response.GetAppPropertySilence(constant.ReturnErrorCode))
+
+	time.Sleep(400 * time.Millisecond)
+	assert.True(t, shutdown.CurrentProgress().BackgroundTasks < backgroundTasks)
+	response = handle()
+	assert.Equal(t, constant.HandlerTimeoutError, response.GetAppPropertySilence(constant.ReturnErrorCode))
+}
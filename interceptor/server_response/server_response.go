@@ -8,6 +8,7 @@ import (
 	"git.multiverse.io/eventkit/kit/constant"
 	"git.multiverse.io/eventkit/kit/contexts"
 	"git.multiverse.io/eventkit/kit/log"
+	"strings"
 	"text/template"
 )
 
@@ -26,6 +27,10 @@ func (l *Interceptor) PostHandle(ctx context.Context, request *msg.Message, resp
 		log.Debug(ctx, "The response is empty, skip the following logic!")
 		return nil
 	}
+	// the response encoded by the negotiated media type(e.g. XML or text) is sent as it is
+	if contentType := response.GetAppPropertyIgnoreCaseSilence(constant.HTTPContentTypeKey); "" != contentType && !strings.Contains(contentType, "json") {
+		return nil
+	}
 	if "" == response.GetAppPropertyIgnoreCaseSilence(constant.ReturnErrorCode) {
 		responseTemplate := constant.DefaultResponseTemplate
 		handlerContexts := contexts.HandlerContextsFromContext(ctx)
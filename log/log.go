@@ -149,6 +149,11 @@ func Init(conf ...Config) {
 	defer func() { _ = logger.Sync() }() // flushes buffer, if any
 }
 
+// Sync flushes the buffered logs
+func Sync() error {
+	return logger.Sync()
+}
+
 // RegisterHookFunc register hook function to add custom log field
 func RegisterHookFunc(fn HookFunc) {
 	hookFn = fn
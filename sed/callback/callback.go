@@ -8,12 +8,14 @@ import (
 	"git.multiverse.io/eventkit/kit/common/msg"
 	"git.multiverse.io/eventkit/kit/common/protocol"
 	"git.multiverse.io/eventkit/kit/common/serializer"
+	"git.multiverse.io/eventkit/kit/common/shutdown"
 	"git.multiverse.io/eventkit/kit/common/status"
 	"git.multiverse.io/eventkit/kit/constant"
 	"git.multiverse.io/eventkit/kit/log"
 	"github.com/buaazp/fasthttprouter"
 	jsoniter "github.com/json-iterator/go"
 	"github.com/valyala/fasthttp"
+	"net"
 	"runtime/debug"
 )
 
@@ -42,12 +44,24 @@ func RegisterCallbackExecutor(ce Executor) {
 	status.SetClientStatus(status.ClientStartingInit)
 	ce.Init()
 
+	if err := ValidateTransport(ce.CallbackOptions()); nil != err {
+		panic(fmt.Sprintf("validate transport failed, error=%++v", err))
+	}
+	SetTransport(ce.CallbackOptions().CommType)
+	SetBinaryFrame(ce.CallbackOptions().EnableBinaryFrame)
 	SetSedServerAddr(ce.CallbackOptions().ServerAddress)
 	InitSedClient()
+	if ce.CallbackOptions().MaxConcurrency > 0 || len(ce.CallbackOptions().ConcurrencyLimits) > 0 {
+		SetScheduler(NewScheduler(ce.CallbackOptions().MaxConcurrency, ce.CallbackOptions().ConcurrencyLimits))
+	}
 
 	callbackExecutor = ce
 
-	go StartCallbackServer(ce.CallbackOptions().CallbackPort)
+	go StartCallbackServerWithOptions(ce.CallbackOptions())
+	shutdown.RegisterCloser("callback listener", func() error {
+		ShutdownClientListen()
+		return nil
+	})
 
 	if ce.CallbackOptions().EnableClientSideStatusFSM {
 		if err := StartClientSideStatusFSM(); nil != err {
@@ -95,6 +109,14 @@ func callbackHandlerForFastHTTP(ctx *fasthttp.RequestCtx) {
 	}()
 
 	version = string(ctx.Request.Header.Peek("v"))
+
+	// reject the new callbacks when shutting down, the in-flight callbacks are drained by the shutdown coordinator
+	if !shutdown.Enter() {
+		err = errors.Errorf(constant.ShuttingDownError, "callbackHandlerForFastHTTP:the client is shutting down")
+		return
+	}
+	defer shutdown.Exit()
+
 	requestBody := ctx.PostBody()
 	var protoMsg protocol.ProtoMessage
 
@@ -110,6 +132,17 @@ func callbackHandlerForFastHTTP(ctx *fasthttp.RequestCtx) {
 	}
 	message := model.ProtocolMsgToMsg(&protoMsg)
 
+	// queue the callback by the concurrency limit of its topic type, the callback is shed if the queue is full
+	if nil != scheduler {
+		release, serr := scheduler.Acquire(message.GetMsgTopicType())
+		if nil != serr {
+			log.Warnsf("callbackHandlerForFastHTTP:shed the callback, topic ID=[%s], error=%s", message.GetMsgTopicId(), errors.ErrorToString(serr))
+			err = serr
+			return
+		}
+		defer release()
+	}
+
 	context := context.Background()
 	reply, err := callbackExecutor.Handle(context, message)
 	if nil != err {
@@ -139,6 +172,10 @@ func getClientStatus(ctx *fasthttp.RequestCtx) {
 		Version:       status.ClientVersion,
 		ProtocolLevel: status.ClientProtocolLevel,
 	}
+	if shutdown.IsShuttingDown() {
+		progress := shutdown.CurrentProgress()
+		res.Drain = &progress
+	}
 
 	resBytes, err := json.Marshal(res)
 	if nil != err {
@@ -160,7 +197,39 @@ func StartCallbackServer(port int) {
 	}
 
 	serverAddr := fmt.Sprintf("0.0.0.0:%d", port)
-	log.Infosf("Start callback endpoint, listen addr=%s", serverAddr)
+	listener, err := (&tcpTransport{}).Listen(serverAddr)
+	if nil != err {
+		log.Errors("startUserMsgCallbackServer: listen failed:", err.Error())
+		panic(err)
+	}
+	serveCallbackOrPanic(listener)
+}
+
+// StartCallbackServerWithOptions start callback service with the transport of the communicate type,
+// the callback endpoint listens on the callback port for TCP or the callback socket file for uds.
+func StartCallbackServerWithOptions(options *Options) {
+	transport, ok := GetTransport(options.CommType)
+	if !ok {
+		transport = currentTransport()
+	}
+	listener, err := transport.Listen(callbackListenAddress(options))
+	if nil != err {
+		log.Errors("startUserMsgCallbackServer: listen failed:", err.Error())
+		panic(err)
+	}
+	serveCallbackOrPanic(listener)
+}
+
+func serveCallbackOrPanic(listener net.Listener) {
+	if err := ServeCallback(listener); err != nil {
+		log.Errors("startUserMsgCallbackServer: start fasthttp failed:", err.Error())
+		panic(err)
+	}
+}
+
+// ServeCallback serves the callback endpoints on the listener until the server is shutdown
+func ServeCallback(listener net.Listener) error {
+	log.Infosf("Start callback endpoint, listen addr=%s", listener.Addr())
 	router := fasthttprouter.New()
 	router.POST("/v1/newmsg", callbackHandlerForFastHTTP)
 	router.GET("/v1/client/status", getClientStatus)
@@ -170,10 +239,8 @@ func StartCallbackServer(port int) {
 		MaxRequestBodySize:            1024 * 1024 * 1024,
 		DisableHeaderNamesNormalizing: true,
 	}
-	if err := server.ListenAndServe(serverAddr); err != nil {
-		log.Errors("startUserMsgCallbackServer: start fasthttp failed:", err.Error())
-		panic(err)
-	}
+
+	return server.Serve(listener)
 }
 
 // ShutdownClientListen shutdown the client listener
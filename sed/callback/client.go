@@ -15,6 +15,7 @@ import (
 	"os"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
@@ -33,8 +34,29 @@ var (
 	maxWriteTimeoutMilliseconds = defaultWriteTimeoutMilliseconds
 	once                        sync.Once
 	clientWithConnectTimeout    *fasthttp.Client
+	// binaryFrame is 1 if the raw binary frames(v2) are sent before the server protocol level is known
+	binaryFrame int32
 )
 
+// SetBinaryFrame opens or closes the raw binary frames(v2) before the server protocol level is known,
+// the client falls back to the base64 frames(v1) if the server doesn't reply the binary frame.
+func SetBinaryFrame(enable bool) {
+	if enable {
+		atomic.StoreInt32(&binaryFrame, 1)
+	} else {
+		atomic.StoreInt32(&binaryFrame, 0)
+	}
+}
+
+// frameVersion returns the version of the request frame
+func frameVersion() string {
+	if status.ServerProtocolLevel >= 2 || 1 == atomic.LoadInt32(&binaryFrame) {
+		return "2"
+	}
+
+	return "1"
+}
+
 func SetMaxReadAndWriteTimeoutMilliseconds(iMaxReadTimeoutMilliseconds, iMaxWriteTimeoutMilliseconds int64) {
 	log.Infosf("Setting read timeout(milliseconds)=[%d], write timeout(milliseconds)=[%d]", iMaxReadTimeoutMilliseconds, iMaxWriteTimeoutMilliseconds)
 	maxReadTimeoutMilliseconds = iMaxReadTimeoutMilliseconds
@@ -50,6 +72,8 @@ func createClientIfNecessary() {
 			//
 			// default max attempts is 5 times.
 			MaxIdemponentCallAttempts: 1,
+			// dial the sed server with the transport of the current communicate type
+			Dial: dialSedServer,
 		}
 		log.Infosf("Create http client with read timeout(milliseconds)=[%d], write timeout(milliseconds)=[%d]", maxReadTimeoutMilliseconds, maxWriteTimeoutMilliseconds)
 		clientWithConnectTimeout.ReadTimeout = time.Duration(maxReadTimeoutMilliseconds) * time.Millisecond
@@ -61,6 +85,21 @@ func createClientIfNecessary() {
 // and it's returns responses where from server endpoint
 // allow the caller to specify a timeout(millisecond)
 func post(protoMsg protocol.ProtoMessage, path string, isNeedDeserializerToMessage bool, timeout time.Duration) (protocol.ProtoMessage, error) {
+	version := frameVersion()
+	retProtoMsg, err := postWithVersion(protoMsg, path, isNeedDeserializerToMessage, timeout, version)
+	if errFrameVersionNotSupported == err {
+		log.Warnsf("The sed server doesn't support the binary frame, fall back to the base64 frame, url=[%s]", serverAddr+path)
+		SetBinaryFrame(false)
+		return postWithVersion(protoMsg, path, isNeedDeserializerToMessage, timeout, "1")
+	}
+
+	return retProtoMsg, err
+}
+
+// errFrameVersionNotSupported is returned when the binary frame is sent but the reply is not a binary frame
+var errFrameVersionNotSupported = errors.New(constant.SystemInternalError, "The binary frame is not supported by the sed server")
+
+func postWithVersion(protoMsg protocol.ProtoMessage, path string, isNeedDeserializerToMessage bool, timeout time.Duration, version string) (protocol.ProtoMessage, error) {
 	var retProtoMsg protocol.ProtoMessage
 	var err error
 	var fullURL string
@@ -70,14 +109,10 @@ func post(protoMsg protocol.ProtoMessage, path string, isNeedDeserializerToMessa
 	defer fasthttp.ReleaseRequest(req)
 
 	var requestBytes []byte
-	var version string
-
-	if status.ServerProtocolLevel >= 2 {
+	if "2" == version {
 		requestBytes = serializer.ProtoMsg2Bytes(&protoMsg, nil)
-		version = "2"
 	} else {
 		requestBytes = []byte(serializer.ProtoMsg2String(&protoMsg, nil))
-		version = "1"
 	}
 
 	req.Header.DisableNormalizing()
@@ -136,11 +171,17 @@ func post(protoMsg protocol.ProtoMessage, path string, isNeedDeserializerToMessa
 		}
 	}
 
+	// the server replies the frame version of the request, a legacy server never replies "2"
+	replyVersion := string(resp.Header.Peek("v"))
+	if "2" == version && "2" != replyVersion && status.ServerProtocolLevel < 2 {
+		return retProtoMsg, errFrameVersionNotSupported
+	}
+
 	if resp.StatusCode() != fasthttp.StatusOK {
 		return retProtoMsg, errors.Errorf(constant.SystemInternalError, "postRequest StatusCode != 200,fullURL=%s,statusCode=%v", fullURL, resp.StatusCode())
 	}
 
-	version = string(resp.Header.Peek("v"))
+	version = replyVersion
 	resBody := resp.Body()
 	if isNeedDeserializerToMessage {
 		if "2" == version {
@@ -1,8 +1,10 @@
 package callback
 
 import (
+	"context"
 	"encoding/json"
 	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/health"
 	"git.multiverse.io/eventkit/kit/common/msg"
 	"git.multiverse.io/eventkit/kit/constant"
 	"git.multiverse.io/eventkit/kit/log"
@@ -27,9 +29,19 @@ func SetKitVersion(v string) {
 	kitVersion = v
 }
 
-// HeartBeatBody is a model for heartbeat
+// HeartBeatBody is a model for heartbeat,
+// the health report contains the health status, runtime statistics, results of the health checks and details of the components.
 type HeartBeatBody struct {
 	ConfigVersion int64 `json:"configVersion"`
+	health.Report
+}
+
+// NewHeartBeatBody evaluates the health report and creates the heartbeat body
+func NewHeartBeatBody(ctx context.Context) HeartBeatBody {
+	return HeartBeatBody{
+		ConfigVersion: GetConfigVersion(),
+		Report:        health.Evaluate(ctx),
+	}
 }
 
 var heartBeatConfig *HeartBeat
@@ -67,9 +79,7 @@ func startRunHeartBeat(beat *HeartBeat) {
 			SessionName:    session,
 			TopicAttribute: heartBeatTopicAttribute,
 		}
-		body := HeartBeatBody{
-			ConfigVersion: GetConfigVersion(),
-		}
+		body := NewHeartBeatBody(context.Background())
 		message.SetAppProps(map[string]string{
 			"service.lang.type":        "golang",
 			"version.eventkit.kit":     kitVersion,
@@ -0,0 +1,35 @@
+package callback
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/health"
+)
+
+func TestNewHeartBeatBody(t *testing.T) {
+	defer SetConfigVersion(0)
+	defer health.Unregister("heartbeat-test")
+	defer health.UnregisterComponent("heartbeat-test")
+
+	SetConfigVersion(3)
+	health.Register("heartbeat-test", func(ctx context.Context) error {
+		return nil
+	})
+	health.RegisterComponent("heartbeat-test", func() interface{} {
+		return "ok"
+	})
+
+	bs, err := json.Marshal(NewHeartBeatBody(context.Background()))
+	assert.True(t, nil == err)
+
+	body := make(map[string]interface{})
+	assert.True(t, nil == json.Unmarshal(bs, &body))
+	assert.Equal(t, float64(3), body["configVersion"])
+	assert.Equal(t, health.StatusUp, body["status"])
+	assert.NotNil(t, body["runtime"])
+	assert.Equal(t, 1, len(body["checks"].([]interface{})))
+	assert.Equal(t, "ok", body["components"].(map[string]interface{})["heartbeat-test"])
+}
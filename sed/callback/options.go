@@ -3,10 +3,14 @@ package callback
 // Options is defined all the options that callback executor can set
 type Options struct {
 	Port                      int
-	CommType                  string // mandatory(mesh/direct): default mesh
-	ServerAddress             string // mandatory: default http://127.0.0.1:18080
+	CommType                  string // mandatory(mesh/direct/uds): default mesh
+	ServerAddress             string // mandatory: default http://127.0.0.1:18080, unix:///path/to/sed.sock for uds
 	CallbackPort              int    // mandatory: default 18082
+	CallbackSocketPath        string // socket file of the callback endpoint for uds: default /tmp/eventkit-callback.sock
 	EnableClientSideStatusFSM bool
+	EnableBinaryFrame         bool                        // sends the raw binary frames(v2) without waiting for the server protocol level
+	MaxConcurrency            int                         // max callbacks executed concurrently: default 0(unlimited)
+	ConcurrencyLimits         map[string]ConcurrencyLimit // concurrency limits and priorities of the topic types
 	ExtConfigs                map[string]interface{}
 }
 
@@ -23,6 +27,9 @@ var (
 	// DefaultCallbackPort  is default port of callback endpoint listener
 	DefaultCallbackPort = 18082
 
+	// DefaultCallbackSocketPath is default socket file of callback endpoint listener when the communicate type is uds
+	DefaultCallbackSocketPath = "/tmp/eventkit-callback.sock"
+
 	// DefaultEnableClientSideStatusFSM is default of whether enable client side status FSM.
 	DefaultEnableClientSideStatusFSM = false
 )
@@ -37,6 +44,7 @@ func NewHandlerOptions(options ...Option) Options {
 		CommType:                  DefaultCommType,
 		ServerAddress:             DefaultServerAddress,
 		CallbackPort:              DefaultCallbackPort,
+		CallbackSocketPath:        DefaultCallbackSocketPath,
 		EnableClientSideStatusFSM: DefaultEnableClientSideStatusFSM,
 		ExtConfigs:                make(map[string]interface{}),
 	}
@@ -76,6 +84,38 @@ func WithCallbackPort(callbackPort int) Option {
 	}
 }
 
+// WithCallbackSocketPath is used to modify the socket file of the callback endpoint
+func WithCallbackSocketPath(callbackSocketPath string) Option {
+	return func(options *Options) {
+		options.CallbackSocketPath = callbackSocketPath
+	}
+}
+
+// WithEnableBinaryFrame is used to open or close the raw binary frames
+func WithEnableBinaryFrame(enableBinaryFrame bool) Option {
+	return func(options *Options) {
+		options.EnableBinaryFrame = enableBinaryFrame
+	}
+}
+
+// WithMaxConcurrency is used to modify the max callbacks executed concurrently
+func WithMaxConcurrency(maxConcurrency int) Option {
+	return func(options *Options) {
+		options.MaxConcurrency = maxConcurrency
+	}
+}
+
+// WithConcurrencyLimit is used to set the concurrency limit and priority of the topic type,
+// use AnyTopicType to set the limit of the topic types without their own limit.
+func WithConcurrencyLimit(topicType string, limit ConcurrencyLimit) Option {
+	return func(options *Options) {
+		if nil == options.ConcurrencyLimits {
+			options.ConcurrencyLimits = make(map[string]ConcurrencyLimit)
+		}
+		options.ConcurrencyLimits[topicType] = limit
+	}
+}
+
 // WithEnableClientSideStatusFSM is used to open or close the status FSM of client side
 func WithEnableClientSideStatusFSM(enableClientSideStatusFSM bool) Option {
 	return func(options *Options) {
@@ -2,6 +2,7 @@ package callback
 
 import (
 	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/constant"
 	"testing"
 )
 
@@ -47,6 +48,41 @@ func TestWithCallbackPort(t *testing.T) {
 	assert.Equal(t, opts.CallbackPort, 2000)
 }
 
+func TestWithCallbackSocketPath(t *testing.T) {
+	opts := NewHandlerOptions()
+	assert.Equal(t, opts.CallbackSocketPath, DefaultCallbackSocketPath)
+	opt := WithCallbackSocketPath("/tmp/callback.sock")
+	opt(&opts)
+
+	assert.Equal(t, opts.CallbackSocketPath, "/tmp/callback.sock")
+}
+
+func TestWithEnableBinaryFrame(t *testing.T) {
+	opts := NewHandlerOptions()
+	opt := WithEnableBinaryFrame(true)
+	opt(&opts)
+
+	assert.Equal(t, opts.EnableBinaryFrame, true)
+}
+
+func TestWithMaxConcurrency(t *testing.T) {
+	opts := NewHandlerOptions()
+	opt := WithMaxConcurrency(100)
+	opt(&opts)
+
+	assert.Equal(t, opts.MaxConcurrency, 100)
+}
+
+func TestWithConcurrencyLimit(t *testing.T) {
+	opts := NewHandlerOptions()
+	opt := WithConcurrencyLimit(constant.TopicTypeOPS, ConcurrencyLimit{MaxConcurrency: 2, MaxQueueSize: 10, Priority: 5})
+	opt(&opts)
+
+	assert.Equal(t, len(opts.ConcurrencyLimits), 1)
+	assert.Equal(t, opts.ConcurrencyLimits[constant.TopicTypeOPS].MaxConcurrency, 2)
+	assert.Equal(t, opts.ConcurrencyLimits[constant.TopicTypeOPS].Priority, 5)
+}
+
 func TestWithEnableClientSideStatusFSM(t *testing.T) {
 	opts := NewHandlerOptions()
 	opt := WithEnableClientSideStatusFSM(true)
@@ -0,0 +1,233 @@
+package callback
+
+import (
+	"sync"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/constant"
+)
+
+// AnyTopicType is the topic type of the concurrency limit that applies to the topic types without their own limit
+const AnyTopicType = "*"
+
+// ConcurrencyLimit defines the concurrency control of the callbacks of a topic type
+type ConcurrencyLimit struct {
+	// MaxConcurrency is the max number of the callbacks of the topic type executed concurrently, 0 means unlimited
+	MaxConcurrency int
+	// MaxQueueSize is the max number of the callbacks waiting for execution, the callbacks exceeding it are shed
+	MaxQueueSize int
+	// Priority decides which topic type is served first when the max concurrency of all the topic types is reached,
+	// the higher value has the higher priority.
+	Priority int
+	// QueueTimeout is the max waiting time of the queued callbacks, 0 means waiting until being executed
+	QueueTimeout time.Duration
+}
+
+// DefaultConcurrencyLimits is the priorities of the topic types used when the concurrency control is enabled,
+// the business and DXC callbacks are served before the ops, metrics and log callbacks.
+var DefaultConcurrencyLimits = map[string]ConcurrencyLimit{
+	constant.TopicTypeHeartbeat: {Priority: 100, MaxQueueSize: 100},
+	constant.TopicTypeDXC:       {Priority: 90, MaxQueueSize: 1000},
+	constant.TopicTypeBusiness:  {Priority: 80, MaxQueueSize: 1000},
+	AnyTopicType:                {Priority: 50, MaxQueueSize: 1000},
+	constant.TopicTypeAlert:     {Priority: 20, MaxQueueSize: 100},
+	constant.TopicTypeOPS:       {Priority: 10, MaxQueueSize: 100},
+	constant.TopicTypeMetrics:   {Priority: 10, MaxQueueSize: 100},
+	constant.TopicTypeLog:       {Priority: 10, MaxQueueSize: 100},
+}
+
+// SchedulerStats is the statistics of a topic type in the scheduler
+type SchedulerStats struct {
+	Running int
+	Queued  int
+	Shed    int64
+}
+
+type waiter struct {
+	granted chan struct{}
+	// done is true if the waiter is granted or given up
+	done bool
+}
+
+type topicTypeQueue struct {
+	limit   ConcurrencyLimit
+	running int
+	waiters []*waiter
+	shed    int64
+}
+
+// Scheduler controls the concurrency of the callbacks in front of Executor.Handle,
+// the callbacks exceeding the concurrency are queued by the priority of their topic type,
+// and shed with the CallbackOverloadedError when the queue is full or the queue timeout is exceeded.
+type Scheduler struct {
+	lock           sync.Mutex
+	maxConcurrency int
+	running        int
+	queues         map[string]*topicTypeQueue
+}
+
+// NewScheduler creates a scheduler with the max concurrency of all the topic types(0 means unlimited)
+// and the concurrency limits of the topic types, the DefaultConcurrencyLimits are used for the topic types without limit,
+// the unset fields of the limits inherit the DefaultConcurrencyLimits of the topic type or AnyTopicType.
+func NewScheduler(maxConcurrency int, limits map[string]ConcurrencyLimit) *Scheduler {
+	s := &Scheduler{
+		maxConcurrency: maxConcurrency,
+		queues:         make(map[string]*topicTypeQueue),
+	}
+	for topicType, limit := range DefaultConcurrencyLimits {
+		s.queues[topicType] = &topicTypeQueue{limit: limit}
+	}
+	for topicType, limit := range limits {
+		defaultLimit, ok := DefaultConcurrencyLimits[topicType]
+		if !ok {
+			defaultLimit = DefaultConcurrencyLimits[AnyTopicType]
+		}
+		s.queues[topicType] = &topicTypeQueue{limit: mergeLimit(limit, defaultLimit)}
+	}
+
+	return s
+}
+
+// mergeLimit fills the unset fields of the limit with the default limit
+func mergeLimit(limit, defaultLimit ConcurrencyLimit) ConcurrencyLimit {
+	if 0 == limit.MaxConcurrency {
+		limit.MaxConcurrency = defaultLimit.MaxConcurrency
+	}
+	if 0 == limit.MaxQueueSize {
+		limit.MaxQueueSize = defaultLimit.MaxQueueSize
+	}
+	if 0 == limit.Priority {
+		limit.Priority = defaultLimit.Priority
+	}
+	if 0 == limit.QueueTimeout {
+		limit.QueueTimeout = defaultLimit.QueueTimeout
+	}
+
+	return limit
+}
+
+func (s *Scheduler) queueOf(topicType string) *topicTypeQueue {
+	if queue, ok := s.queues[topicType]; ok {
+		return queue
+	}
+
+	return s.queues[AnyTopicType]
+}
+
+func (s *Scheduler) isAvailable(queue *topicTypeQueue) bool {
+	if s.maxConcurrency > 0 && s.running >= s.maxConcurrency {
+		return false
+	}
+
+	return queue.limit.MaxConcurrency <= 0 || queue.running < queue.limit.MaxConcurrency
+}
+
+// Acquire waits for the permit of executing a callback of the topic type,
+// the returned release function must be called after the callback finishes.
+func (s *Scheduler) Acquire(topicType string) (release func(), err *errors.Error) {
+	s.lock.Lock()
+	queue := s.queueOf(topicType)
+	if len(queue.waiters) == 0 && s.isAvailable(queue) {
+		queue.running++
+		s.running++
+		s.lock.Unlock()
+		return s.releaseFunc(queue), nil
+	}
+	if len(queue.waiters) >= queue.limit.MaxQueueSize {
+		queue.shed++
+		s.lock.Unlock()
+		return nil, errors.Errorf(constant.CallbackOverloadedError, "The callback queue of topic type[%s] is full, max queue size=%d", topicType, queue.limit.MaxQueueSize)
+	}
+	w := &waiter{granted: make(chan struct{})}
+	queue.waiters = append(queue.waiters, w)
+	s.lock.Unlock()
+
+	var timeout <-chan time.Time
+	if queue.limit.QueueTimeout > 0 {
+		timer := time.NewTimer(queue.limit.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case <-w.granted:
+		return s.releaseFunc(queue), nil
+	case <-timeout:
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		if w.done {
+			// granted at the same time of timeout
+			return s.releaseFunc(queue), nil
+		}
+		w.done = true
+		s.removeWaiter(queue, w)
+		queue.shed++
+		return nil, errors.Errorf(constant.CallbackOverloadedError, "The callback of topic type[%s] waits for execution more than %s", topicType, queue.limit.QueueTimeout)
+	}
+}
+
+func (s *Scheduler) removeWaiter(queue *topicTypeQueue, w *waiter) {
+	for i, item := range queue.waiters {
+		if item == w {
+			queue.waiters = append(queue.waiters[:i], queue.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *Scheduler) releaseFunc(queue *topicTypeQueue) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			queue.running--
+			s.running--
+			s.dispatch()
+		})
+	}
+}
+
+// dispatch grants the permits to the waiters of the highest priority topic types
+func (s *Scheduler) dispatch() {
+	for {
+		var next *topicTypeQueue
+		for _, queue := range s.queues {
+			if len(queue.waiters) == 0 || !s.isAvailable(queue) {
+				continue
+			}
+			if nil == next || queue.limit.Priority > next.limit.Priority {
+				next = queue
+			}
+		}
+		if nil == next {
+			return
+		}
+		w := next.waiters[0]
+		next.waiters = next.waiters[1:]
+		w.done = true
+		next.running++
+		s.running++
+		close(w.granted)
+	}
+}
+
+// Stats returns the statistics of the topic type
+func (s *Scheduler) Stats(topicType string) SchedulerStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	queue := s.queueOf(topicType)
+	return SchedulerStats{
+		Running: queue.running,
+		Queued:  len(queue.waiters),
+		Shed:    queue.shed,
+	}
+}
+
+var scheduler *Scheduler
+
+// SetScheduler sets the scheduler of the callback server, nil means the callbacks are executed without limit
+func SetScheduler(s *Scheduler) {
+	scheduler = s
+}
@@ -0,0 +1,108 @@
+package callback
+
+import (
+	"testing"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/constant"
+)
+
+func TestScheduler_ConcurrencyLimit(t *testing.T) {
+	s := NewScheduler(0, map[string]ConcurrencyLimit{
+		constant.TopicTypeOPS: {MaxConcurrency: 1, MaxQueueSize: 1},
+	})
+
+	release, err := s.Acquire(constant.TopicTypeOPS)
+	assert.True(t, nil == err)
+
+	// the other topic types are not limited by the limit of OPS
+	releaseBusiness, err := s.Acquire(constant.TopicTypeBusiness)
+	assert.True(t, nil == err)
+	releaseBusiness()
+
+	acquired := make(chan struct{})
+	go func() {
+		r, aerr := s.Acquire(constant.TopicTypeOPS)
+		assert.True(t, nil == aerr)
+		close(acquired)
+		r()
+	}()
+	waitForQueued(t, s, constant.TopicTypeOPS, 1)
+
+	// the queue is full
+	_, err = s.Acquire(constant.TopicTypeOPS)
+	assert.NotNil(t, err)
+	assert.Equal(t, constant.CallbackOverloadedError, err.ErrorCode)
+	assert.Equal(t, int64(1), s.Stats(constant.TopicTypeOPS).Shed)
+
+	release()
+	// release twice makes no difference
+	release()
+	<-acquired
+}
+
+func TestScheduler_Priority(t *testing.T) {
+	s := NewScheduler(1, nil)
+	release, err := s.Acquire(constant.TopicTypeBusiness)
+	assert.True(t, nil == err)
+
+	order := make(chan string, 2)
+	for _, topicType := range []string{constant.TopicTypeLog, constant.TopicTypeDXC} {
+		topicType := topicType
+		go func() {
+			r, aerr := s.Acquire(topicType)
+			assert.True(t, nil == aerr)
+			order <- topicType
+			time.Sleep(10 * time.Millisecond)
+			r()
+		}()
+		waitForQueued(t, s, topicType, 1)
+	}
+
+	release()
+	// DXC is queued after LOG but executed first
+	assert.Equal(t, constant.TopicTypeDXC, <-order)
+	assert.Equal(t, constant.TopicTypeLog, <-order)
+}
+
+func TestScheduler_QueueTimeout(t *testing.T) {
+	s := NewScheduler(0, map[string]ConcurrencyLimit{
+		AnyTopicType: {MaxConcurrency: 1, MaxQueueSize: 10, QueueTimeout: 20 * time.Millisecond},
+	})
+	release, err := s.Acquire("unknown")
+	assert.True(t, nil == err)
+	defer release()
+
+	_, err = s.Acquire("unknown")
+	assert.NotNil(t, err)
+	assert.Equal(t, constant.CallbackOverloadedError, err.ErrorCode)
+
+	stats := s.Stats("unknown")
+	assert.Equal(t, 1, stats.Running)
+	assert.Equal(t, 0, stats.Queued)
+	assert.Equal(t, int64(1), stats.Shed)
+}
+
+func TestScheduler_MergeDefaultLimits(t *testing.T) {
+	s := NewScheduler(0, map[string]ConcurrencyLimit{
+		constant.TopicTypeDXC: {MaxConcurrency: 2},
+		"CUSTOM":              {MaxConcurrency: 1, Priority: 60},
+	})
+
+	// the unset fields inherit the default limit of the topic type
+	assert.Equal(t, ConcurrencyLimit{MaxConcurrency: 2, MaxQueueSize: 1000, Priority: 90}, s.queues[constant.TopicTypeDXC].limit)
+	// the topic types without default limit inherit the default limit of AnyTopicType
+	assert.Equal(t, ConcurrencyLimit{MaxConcurrency: 1, MaxQueueSize: 1000, Priority: 60}, s.queues["CUSTOM"].limit)
+	assert.Equal(t, DefaultConcurrencyLimits[constant.TopicTypeLog], s.queues[constant.TopicTypeLog].limit)
+}
+
+func waitForQueued(t *testing.T, s *Scheduler, topicType string, queued int) {
+	deadline := time.Now().Add(5 * time.Second)
+	for s.Stats(topicType).Queued != queued {
+		if time.Now().After(deadline) {
+			t.Fatalf("the callbacks of topic type[%s] are not queued", topicType)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/valyala/fasthttp"
 	"net/http"
 	"reflect"
+	"strings"
 	"time"
 )
 
@@ -35,10 +36,6 @@ const (
 // default address is "http://127.0.0.1:18080"
 var serverAddr = "http://127.0.0.1:18080"
 
-// define global variable for storing sed server address(unix socket address)
-// default address is "http://unix"
-//var unixSocketHttpAddr = "http://unix"
-
 // use for tcp/ip socket
 var httpClient *http.Client
 
@@ -61,10 +58,17 @@ func InitSedClient() {
 
 // SetSedServerAddr is for application to specify sed server address
 // if addr is empty, default value "http://127.0.0.1:18080" will be set.
+// the address starts with "unix://" is the socket file of the sed server(e.g. "unix:///var/run/sed/sed.sock").
 func SetSedServerAddr(addr string) {
 	if addr != "" {
 		log.Infosf("Sed server address:%s", addr)
-		serverAddr = addr
+		if isUnixSocketAddress(addr) {
+			serverSocketPath = strings.TrimPrefix(addr, UnixSocketAddressPrefix)
+			serverAddr = unixSocketHTTPAddr
+		} else {
+			serverSocketPath = ""
+			serverAddr = addr
+		}
 	}
 }
 
@@ -2,6 +2,7 @@ package callback
 
 import (
 	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/shutdown"
 	"git.multiverse.io/eventkit/kit/common/status"
 	"git.multiverse.io/eventkit/kit/constant"
 	"git.multiverse.io/eventkit/kit/log"
@@ -21,6 +22,9 @@ var (
 		// Disable idempotent calls attempts when remote call abnormal.
 		// default max attempts is 5 times.
 		MaxIdemponentCallAttempts: 1,
+
+		// dial the sed server with the transport of the current communicate type
+		Dial: dialSedServer,
 	}
 )
 
@@ -100,7 +104,12 @@ func WaitingForServerStatusToCanSend() {
 	status.WaitingForServerStatus(make(chan struct{}, 0), time.Second*1, -1, status.ServerStartingCanSend, status.ServerStarted)
 }
 
+// DefaultDrainTimeout is the default timeout of draining the in-flight handlers when the client stop timeout is unlimited
+var DefaultDrainTimeout = 30 * time.Second
+
 // StopClient is used to stop the client side executor.
+// After the server status becomes `STOP`, the in-flight handlers and background tasks are drained
+// by the shutdown coordinator within the rest of the timeout, then the registered closers are executed.
 func StopClient(maxClientStopTimeout time.Duration, clientSideStatusFSM bool) {
 	status.SetClientStatus(status.ClientPreStop)
 	defer status.SetClientStatus(status.ClientStop)
@@ -110,5 +119,17 @@ func StopClient(maxClientStopTimeout time.Duration, clientSideStatusFSM bool) {
 		status.SetServerStatus(status.ServerStop)
 	}
 
+	startTime := time.Now()
 	status.WaitingForServerStatus(make(chan struct{}, 0), time.Second*1, maxClientStopTimeout, status.ServerStop)
+
+	drainTimeout := DefaultDrainTimeout
+	if maxClientStopTimeout >= 0 {
+		drainTimeout = maxClientStopTimeout - time.Since(startTime)
+		if drainTimeout < 0 {
+			drainTimeout = 0
+		}
+	}
+	if err := shutdown.Shutdown(drainTimeout); nil != err {
+		log.Errorsf("Graceful shutdown failed, error=%s", errors.ErrorToString(err))
+	}
 }
@@ -0,0 +1,182 @@
+package callback
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/log"
+	"github.com/valyala/fasthttp"
+)
+
+// UnixSocketAddressPrefix is the prefix of the sed server address when the server listens on a unix domain socket,
+// e.g. "unix:///var/run/sed/sed.sock"
+const UnixSocketAddressPrefix = "unix://"
+
+// unixSocketHTTPAddr is the placeholder HTTP address of the requests sent over a unix domain socket
+const unixSocketHTTPAddr = "http://unix"
+
+// Transport is the connection layer between the service and the SED server(sidecar),
+// the HTTP requests of both directions are served on the listeners and connections created by the transport.
+type Transport interface {
+	// Listen creates the listener of the callback server
+	Listen(address string) (net.Listener, error)
+	// Dial creates the connection to the SED server
+	Dial(address string) (net.Conn, error)
+}
+
+type tcpTransport struct{}
+
+func (t *tcpTransport) Listen(address string) (net.Listener, error) {
+	return net.Listen("tcp", address)
+}
+
+func (t *tcpTransport) Dial(address string) (net.Conn, error) {
+	return fasthttp.Dial(address)
+}
+
+type udsTransport struct{}
+
+func (t *udsTransport) Listen(address string) (net.Listener, error) {
+	// remove the socket file left by the previous process, any other file at the path is never removed
+	if info, err := os.Lstat(address); nil == err {
+		if 0 == info.Mode()&os.ModeSocket {
+			return nil, errors.Errorf(constant.SystemInternalError, "The callback socket path[%s] exists and isn't a socket file", address)
+		}
+		if err := os.Remove(address); nil != err && !os.IsNotExist(err) {
+			return nil, err
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	return net.Listen("unix", address)
+}
+
+func (t *udsTransport) Dial(address string) (net.Conn, error) {
+	return net.Dial("unix", address)
+}
+
+var (
+	transportLock sync.RWMutex
+	transports    = map[string]Transport{
+		constant.CommMesh:   &tcpTransport{},
+		constant.CommDirect: &tcpTransport{},
+		constant.CommUDS:    &udsTransport{},
+	}
+	currentCommType = constant.CommMesh
+	// serverSocketPath is the socket file of the sed server when the server address starts with "unix://"
+	serverSocketPath string
+)
+
+// RegisterTransport registers a transport for the communicate type, the registered transport will replace the existing one
+func RegisterTransport(commType string, transport Transport) {
+	transportLock.Lock()
+	defer transportLock.Unlock()
+
+	transports[commType] = transport
+}
+
+// GetTransport returns the transport of the communicate type, empty means the default TCP transport
+func GetTransport(commType string) (Transport, bool) {
+	transportLock.RLock()
+	defer transportLock.RUnlock()
+
+	if "" == commType {
+		commType = DefaultCommType
+	}
+	transport, ok := transports[commType]
+
+	return transport, ok
+}
+
+// SetTransport selects the transport used between the service and the SED server by the communicate type,
+// the TCP transport is used if the communicate type has no registered transport.
+func SetTransport(commType string) {
+	if _, ok := GetTransport(commType); !ok {
+		log.Warnsf("Unknown communicate type[%s], use the default communicate type[%s]", commType, DefaultCommType)
+		commType = DefaultCommType
+	}
+	log.Infosf("Communicate type:%s", commType)
+
+	transportLock.Lock()
+	defer transportLock.Unlock()
+	currentCommType = commType
+}
+
+func currentTransport() Transport {
+	transportLock.RLock()
+	commType := currentCommType
+	transportLock.RUnlock()
+
+	transport, ok := GetTransport(commType)
+	if !ok {
+		transport, _ = GetTransport(DefaultCommType)
+	}
+
+	return transport
+}
+
+// ValidateTransport checks the communicate type and the sed server address of the options together,
+// the uds communicate type requires the "unix://" server address and the TCP transports can't dial a socket file.
+func ValidateTransport(options *Options) error {
+	commType := options.CommType
+	if "" == commType {
+		commType = DefaultCommType
+	}
+	transport, ok := GetTransport(commType)
+	if !ok {
+		return errors.Errorf(constant.SystemInternalError, "Unknown communicate type[%s]", commType)
+	}
+
+	serverAddress := options.ServerAddress
+	if "" == serverAddress {
+		serverAddress = DefaultServerAddress
+	}
+	if constant.CommUDS == commType && !isUnixSocketAddress(serverAddress) {
+		return errors.Errorf(constant.SystemInternalError, "The communicate type[%s] requires the server address starts with %s, server address:%s",
+			commType, UnixSocketAddressPrefix, serverAddress)
+	}
+	if _, isTCP := transport.(*tcpTransport); isTCP && isUnixSocketAddress(serverAddress) {
+		return errors.Errorf(constant.SystemInternalError, "The communicate type[%s] can't dial the unix socket server address:%s",
+			commType, serverAddress)
+	}
+
+	return nil
+}
+
+// isUnixSocketAddress returns true if the address is a unix domain socket address
+func isUnixSocketAddress(addr string) bool {
+	return strings.HasPrefix(addr, UnixSocketAddressPrefix)
+}
+
+// dialSedServer creates the connection to the SED server with the current transport,
+// it's used as the dial function of the HTTP clients.
+func dialSedServer(addr string) (net.Conn, error) {
+	if "" != serverSocketPath {
+		addr = serverSocketPath
+	}
+
+	return currentTransport().Dial(addr)
+}
+
+// callbackListenAddress returns the listen address of the callback server for the communicate type
+func callbackListenAddress(options *Options) string {
+	if constant.CommUDS == options.CommType {
+		if "" == options.CallbackSocketPath {
+			return DefaultCallbackSocketPath
+		}
+		return options.CallbackSocketPath
+	}
+
+	port := options.CallbackPort
+	if 0 == port {
+		port = DefaultCallbackPort
+	}
+
+	return "0.0.0.0:" + strconv.Itoa(port)
+}
@@ -0,0 +1,128 @@
+package callback
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/status"
+	"git.multiverse.io/eventkit/kit/constant"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/valyala/fasthttp"
+)
+
+func TestSetSedServerAddr(t *testing.T) {
+	defer SetSedServerAddr(DefaultServerAddress)
+
+	SetSedServerAddr("unix:///var/run/sed/sed.sock")
+	assert.Equal(t, "/var/run/sed/sed.sock", serverSocketPath)
+	assert.Equal(t, unixSocketHTTPAddr, serverAddr)
+
+	SetSedServerAddr("http://127.0.0.1:18081")
+	assert.Equal(t, "", serverSocketPath)
+	assert.Equal(t, "http://127.0.0.1:18081", serverAddr)
+}
+
+func TestSetTransport(t *testing.T) {
+	defer SetTransport(constant.CommMesh)
+
+	_, ok := GetTransport("")
+	assert.True(t, ok)
+	_, ok = GetTransport("unknown")
+	assert.False(t, ok)
+
+	SetTransport(constant.CommUDS)
+	_, ok = currentTransport().(*udsTransport)
+	assert.True(t, ok)
+
+	SetTransport("unknown")
+	_, ok = currentTransport().(*tcpTransport)
+	assert.True(t, ok)
+}
+
+func TestCallbackListenAddress(t *testing.T) {
+	options := NewHandlerOptions(WithCallbackPort(0))
+	assert.Equal(t, "0.0.0.0:18082", callbackListenAddress(&options))
+
+	options = NewHandlerOptions(WithCommType(constant.CommUDS), WithCallbackSocketPath("/tmp/callback.sock"))
+	assert.Equal(t, "/tmp/callback.sock", callbackListenAddress(&options))
+}
+
+func TestServeCallbackOverUnixSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "callback.sock")
+	transport, _ := GetTransport(constant.CommUDS)
+	listener, err := transport.Listen(socketPath)
+	assert.True(t, nil == err)
+	go ServeCallback(listener)
+	defer ShutdownClientListen()
+
+	client := &fasthttp.Client{Dial: func(addr string) (conn net.Conn, err error) {
+		return transport.Dial(socketPath)
+	}}
+	req := fasthttp.AcquireRequest()
+	defer fasthttp.ReleaseRequest(req)
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseResponse(resp)
+	req.SetRequestURI(unixSocketHTTPAddr + "/v1/client/status")
+
+	// the server may be not ready to serve
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		err = client.DoTimeout(req, resp, time.Second)
+		if nil == err || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, nil == err)
+
+	res := status.Response{}
+	assert.True(t, nil == jsoniter.Unmarshal(resp.Body(), &res))
+	assert.Equal(t, status.ClientProtocolLevel, res.ProtocolLevel)
+}
+
+func TestValidateTransport(t *testing.T) {
+	options := NewHandlerOptions()
+	assert.True(t, nil == ValidateTransport(&options))
+
+	options = NewHandlerOptions(WithCommType(constant.CommUDS), WithServerAddress("unix:///var/run/sed/sed.sock"))
+	assert.True(t, nil == ValidateTransport(&options))
+
+	// the uds communicate type with the TCP server address
+	options = NewHandlerOptions(WithCommType(constant.CommUDS))
+	assert.True(t, nil != ValidateTransport(&options))
+
+	// the TCP communicate type with the unix socket server address
+	options = NewHandlerOptions(WithCommType(constant.CommDirect), WithServerAddress("unix:///var/run/sed/sed.sock"))
+	assert.True(t, nil != ValidateTransport(&options))
+
+	options = NewHandlerOptions(WithCommType("unknown"))
+	assert.True(t, nil != ValidateTransport(&options))
+}
+
+func TestUnixSocketListenWithExistingFile(t *testing.T) {
+	transport, _ := GetTransport(constant.CommUDS)
+
+	// the regular file at the socket path is never removed
+	filePath := filepath.Join(t.TempDir(), "callback.sock")
+	assert.True(t, nil == os.WriteFile(filePath, []byte("data"), 0600))
+	_, err := transport.Listen(filePath)
+	assert.True(t, nil != err)
+	_, err = os.Stat(filePath)
+	assert.True(t, nil == err)
+
+	// the socket file left by the previous process is replaced
+	socketPath := filepath.Join(t.TempDir(), "callback.sock")
+	listener, err := transport.Listen(socketPath)
+	assert.True(t, nil == err)
+	if unixListener, ok := listener.(*net.UnixListener); ok {
+		unixListener.SetUnlinkOnClose(false)
+	}
+	listener.Close()
+	listener, err = transport.Listen(socketPath)
+	assert.True(t, nil == err)
+	listener.Close()
+}
@@ -0,0 +1,572 @@
+// Package emulator provides an in-process SED server emulator for integration tests and local development.
+// It implements the endpoints that sed/callback posts to(request/reply, publish, semi-sync reply, server status and GLS),
+// routes the messages by topic ID to the registered callback.Executor or stub handlers,
+// records the traffic for assertions and injects latency and faults.
+package emulator
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/model"
+	"git.multiverse.io/eventkit/kit/common/msg"
+	"git.multiverse.io/eventkit/kit/common/protocol"
+	"git.multiverse.io/eventkit/kit/common/serializer"
+	"git.multiverse.io/eventkit/kit/common/status"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/log"
+	"git.multiverse.io/eventkit/kit/sed/callback"
+	"github.com/buaazp/fasthttprouter"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/valyala/fasthttp"
+)
+
+// AnyTopic matches all the topic IDs when registering handlers, latencies and faults
+const AnyTopic = "*"
+
+// Handler handles the message routed to the topic ID, the reply is ignored for the published messages
+type Handler func(ctx context.Context, in *msg.Message) (*msg.Message, error)
+
+// Record is the traffic recorded by the emulator
+type Record struct {
+	Path      string
+	TopicType string
+	TopicID   string
+	Request   *msg.Message
+	Reply     *msg.Message
+	Error     error
+	At        time.Time
+	Cost      time.Duration
+}
+
+// Fault defines the fault injected into the messages of topic
+type Fault struct {
+	// ErrorCode and ErrorMessage are returned to the caller as the error response
+	ErrorCode    string
+	ErrorMessage string
+	// HTTPStatus returns the HTTP status code instead of the error response if it's not 0
+	HTTPStatus int
+	// Probability is the probability of the fault in (0, 1], 0 means always
+	Probability float64
+	// Times is the max number of the fault injected, 0 means unlimited
+	Times int
+}
+
+type faultState struct {
+	fault    Fault
+	injected int
+}
+
+// Options defines the options of the emulator
+type Options struct {
+	// StartupStepInterval is the interval that the server status steps through the startup FSM,
+	// 0 means the server status is `STARTED` immediately.
+	StartupStepInterval time.Duration
+	Version             string
+	ProtocolLevel       int
+}
+
+// Option sets the options of the emulator
+type Option func(*Options)
+
+// WithStartupStepInterval sets the interval that the server status steps through the startup FSM
+func WithStartupStepInterval(interval time.Duration) Option {
+	return func(o *Options) {
+		o.StartupStepInterval = interval
+	}
+}
+
+// WithProtocolLevel sets the protocol level reported by the server status,
+// the emulator acts as a legacy server that only accepts the base64 frames if the protocol level is less than 2.
+func WithProtocolLevel(protocolLevel int) Option {
+	return func(o *Options) {
+		o.ProtocolLevel = protocolLevel
+	}
+}
+
+// Emulator is an in-process SED server
+type Emulator struct {
+	options  Options
+	listener net.Listener
+	server   *fasthttp.Server
+	closed   chan struct{}
+
+	lock      sync.RWMutex
+	status    int
+	handlers  map[string]Handler
+	latencies map[string]time.Duration
+	faults    map[string]*faultState
+	records   []*Record
+	// recorded is closed and recreated on every new record, used to wait for the records
+	recorded chan struct{}
+
+	gls *glsStore
+}
+
+// New creates an emulator, call Start to serve the endpoints
+func New(opts ...Option) *Emulator {
+	options := Options{
+		Version:       "emulator",
+		ProtocolLevel: constant.ProtocolLevel,
+	}
+	for _, o := range opts {
+		o(&options)
+	}
+
+	return &Emulator{
+		options:   options,
+		closed:    make(chan struct{}),
+		status:    status.ServerStop,
+		handlers:  make(map[string]Handler),
+		latencies: make(map[string]time.Duration),
+		faults:    make(map[string]*faultState),
+		recorded:  make(chan struct{}),
+		gls:       newGlsStore(),
+	}
+}
+
+// Start listens the address(e.g. "127.0.0.1:0") and serves the endpoints in background
+func (e *Emulator) Start(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if nil != err {
+		return err
+	}
+
+	return e.Serve(listener)
+}
+
+// StartUnix listens the unix domain socket file and serves the endpoints in background
+func (e *Emulator) StartUnix(socketPath string) error {
+	if err := os.Remove(socketPath); nil != err && !os.IsNotExist(err) {
+		return err
+	}
+	listener, err := net.Listen("unix", socketPath)
+	if nil != err {
+		return err
+	}
+
+	return e.Serve(listener)
+}
+
+// Serve serves the endpoints on the listener in background
+func (e *Emulator) Serve(listener net.Listener) error {
+	e.listener = listener
+
+	router := fasthttprouter.New()
+	router.POST(callback.SendRequestReplyMsgPath, e.handleRequestReply)
+	router.POST(callback.SendTopicMsgPath, e.handlePublish)
+	router.POST(callback.ReplySemiSyncCallPath, e.handleAck)
+	router.POST(callback.SendReplyMsgPath, e.handleAck)
+	router.POST(callback.SendQueueAckPath, e.handleAck)
+	router.GET(callback.ServerStatusGetPath, e.handleStatus)
+	router.POST(callback.LookupPath, e.handleGlsLookup)
+	router.POST(callback.LookupsPath, e.handleGlsLookups)
+	router.POST(callback.LookSuTypePath, e.handleGlsLookSuType)
+	router.POST(callback.LookListPath, e.handleGlsLooklist)
+
+	e.server = &fasthttp.Server{
+		Handler:                       router.Handler,
+		MaxRequestBodySize:            1024 * 1024 * 1024,
+		DisableHeaderNamesNormalizing: true,
+	}
+	go func() {
+		if err := e.server.Serve(listener); nil != err {
+			log.Errorsf("SED emulator stopped serving, error:%v", err)
+		}
+	}()
+	go e.runStartupFSM()
+
+	log.Infosf("SED emulator started, listen addr=%s", listener.Addr())
+	return nil
+}
+
+// Addr returns the HTTP address of the emulator that can be set by callback.SetSedServerAddr
+func (e *Emulator) Addr() string {
+	if nil == e.listener {
+		return ""
+	}
+
+	if "unix" == e.listener.Addr().Network() {
+		return callback.UnixSocketAddressPrefix + e.listener.Addr().String()
+	}
+
+	return "http://" + e.listener.Addr().String()
+}
+
+// Close steps the server status to `STOP` and stops serving
+func (e *Emulator) Close() error {
+	select {
+	case <-e.closed:
+		return nil
+	default:
+		close(e.closed)
+	}
+	e.SetStatus(status.ServerPreStop)
+	e.SetStatus(status.ServerStop)
+	if nil == e.server {
+		return nil
+	}
+
+	return e.server.Shutdown()
+}
+
+func (e *Emulator) runStartupFSM() {
+	steps := []int{status.ServerStartingInit, status.ServerStartingPreCanSend, status.ServerStartingCanSend, status.ServerStarted}
+	for _, step := range steps {
+		if e.options.StartupStepInterval > 0 {
+			select {
+			case <-time.After(e.options.StartupStepInterval):
+			case <-e.closed:
+				return
+			}
+		}
+		e.SetStatus(step)
+	}
+}
+
+// SetStatus sets the server status reported to the client side FSM
+func (e *Emulator) SetStatus(serverStatus int) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.status = serverStatus
+}
+
+// Status returns the server status
+func (e *Emulator) Status() int {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	return e.status
+}
+
+// Handle registers the handler of the topic ID, AnyTopic registers the default handler
+func (e *Emulator) Handle(topicID string, handler Handler) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.handlers[topicID] = handler
+}
+
+// HandleExecutor routes the messages of the topic ID to the callback executor(e.g. handler.Executor),
+// the errors are wrapped into the error responses as same as the callback server.
+func (e *Emulator) HandleExecutor(topicID string, executor callback.Executor) {
+	e.Handle(topicID, func(ctx context.Context, in *msg.Message) (*msg.Message, error) {
+		reply, err := executor.Handle(ctx, in)
+		if nil != err {
+			reply = msg.WrapperErrorResponse(err, in.JudgeUserLang(), executor.ResponseTemplate(), nil)
+		}
+		return reply, nil
+	})
+}
+
+// InjectLatency delays the messages of the topic ID, AnyTopic delays all the messages
+func (e *Emulator) InjectLatency(topicID string, latency time.Duration) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.latencies[topicID] = latency
+}
+
+// InjectFault injects the fault into the messages of the topic ID, AnyTopic injects into all the messages
+func (e *Emulator) InjectFault(topicID string, fault Fault) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.faults[topicID] = &faultState{fault: fault}
+}
+
+// ClearFaults removes all the injected latencies and faults
+func (e *Emulator) ClearFaults() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.latencies = make(map[string]time.Duration)
+	e.faults = make(map[string]*faultState)
+}
+
+// Records returns the recorded traffic of the topic ID, AnyTopic returns all the records
+func (e *Emulator) Records(topicID string) []*Record {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	records := make([]*Record, 0)
+	for _, record := range e.records {
+		if AnyTopic == topicID || record.TopicID == topicID {
+			records = append(records, record)
+		}
+	}
+
+	return records
+}
+
+// ResetRecords removes all the recorded traffic
+func (e *Emulator) ResetRecords() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.records = nil
+}
+
+// WaitForRecords waits until the number of records of the topic ID reaches n, returns false if timeout,
+// it's useful for the published messages that are handled asynchronously.
+func (e *Emulator) WaitForRecords(topicID string, n int, timeout time.Duration) bool {
+	deadline := time.After(timeout)
+	for {
+		e.lock.RLock()
+		recorded := e.recorded
+		e.lock.RUnlock()
+		if len(e.Records(topicID)) >= n {
+			return true
+		}
+		select {
+		case <-recorded:
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+func (e *Emulator) record(record *Record) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.records = append(e.records, record)
+	close(e.recorded)
+	e.recorded = make(chan struct{})
+}
+
+func (e *Emulator) handlerOf(topicID string) (Handler, bool) {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	if handler, ok := e.handlers[topicID]; ok {
+		return handler, true
+	}
+	handler, ok := e.handlers[AnyTopic]
+
+	return handler, ok
+}
+
+func (e *Emulator) latencyOf(topicID string) time.Duration {
+	e.lock.RLock()
+	defer e.lock.RUnlock()
+
+	if latency, ok := e.latencies[topicID]; ok {
+		return latency
+	}
+
+	return e.latencies[AnyTopic]
+}
+
+// faultOf returns the fault that should be injected into the message of the topic ID
+func (e *Emulator) faultOf(topicID string) (Fault, bool) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	state, ok := e.faults[topicID]
+	if !ok {
+		if state, ok = e.faults[AnyTopic]; !ok {
+			return Fault{}, false
+		}
+	}
+	if state.fault.Times > 0 && state.injected >= state.fault.Times {
+		return Fault{}, false
+	}
+	if state.fault.Probability > 0 && rand.Float64() >= state.fault.Probability {
+		return Fault{}, false
+	}
+	state.injected++
+
+	return state.fault, true
+}
+
+func (f Fault) error(topicID string) error {
+	errorCode := f.ErrorCode
+	if "" == errorCode {
+		errorCode = constant.SystemInternalError
+	}
+	errorMessage := f.ErrorMessage
+	if "" == errorMessage {
+		errorMessage = fmt.Sprintf("fault injected by SED emulator, topic ID:[%s]", topicID)
+	}
+
+	return errors.Errorf(errorCode, errorMessage)
+}
+
+// readMessage decodes the request body as same as the sed server
+func (e *Emulator) readMessage(ctx *fasthttp.RequestCtx) (string, *msg.Message, error) {
+	version := string(ctx.Request.Header.Peek("v"))
+	if e.options.ProtocolLevel < 2 {
+		version = "1"
+	}
+	var protoMsg protocol.ProtoMessage
+	var err error
+	if "2" == version {
+		protoMsg, err = serializer.Bytes2protoMsg(ctx.PostBody())
+	} else {
+		protoMsg, err = serializer.String2protoMsg(string(ctx.PostBody()))
+	}
+	if nil != err {
+		return version, nil, errors.Errorf(constant.SystemInternalError, "SED emulator: failed to decode the request, error:%v", err)
+	}
+
+	return version, model.ProtocolMsgToMsg(&protoMsg), nil
+}
+
+func writeMessage(ctx *fasthttp.RequestCtx, version string, reply *msg.Message, err error) {
+	ctx.Response.Header.Set("v", version)
+	var protoMsg protocol.ProtoMessage
+	if nil != reply {
+		protoMsg = model.MsgToProtocolMsg(reply)
+	}
+	if "2" == version {
+		ctx.Write(serializer.ProtoMsg2BytesCompatible(&protoMsg, err, true))
+	} else {
+		ctx.Write([]byte(serializer.ProtoMsg2StringCompatible(&protoMsg, err, true)))
+	}
+}
+
+func writeError(ctx *fasthttp.RequestCtx, version string, err error) {
+	ctx.Response.Header.Set("v", version)
+	if "2" == version {
+		ctx.Write(serializer.Error2BytesCompatible(err, true))
+	} else {
+		ctx.Write([]byte(serializer.Error2StringCompatible(err, true)))
+	}
+}
+
+// dispatch injects the latency and the fault evaluated by the caller, then calls the handler of the message
+func (e *Emulator) dispatch(path string, in *msg.Message, fault *Fault) (*msg.Message, error) {
+	topicID := in.GetMsgTopicId()
+	record := &Record{
+		Path:      path,
+		TopicType: in.GetMsgTopicType(),
+		TopicID:   topicID,
+		Request:   in,
+		At:        time.Now(),
+	}
+	defer func() {
+		record.Cost = time.Since(record.At)
+		e.record(record)
+	}()
+
+	if latency := e.latencyOf(topicID); latency > 0 {
+		time.Sleep(latency)
+	}
+	if nil != fault {
+		record.Error = fault.error(topicID)
+		return nil, record.Error
+	}
+
+	handler, ok := e.handlerOf(topicID)
+	if !ok {
+		record.Error = errors.Errorf(constant.CannotFoundHandlerWithEventIDError, "SED emulator: cannot found handler of topic ID:[%s]", topicID)
+		return nil, record.Error
+	}
+	record.Reply, record.Error = e.callHandler(handler, in)
+
+	return record.Reply, record.Error
+}
+
+func (e *Emulator) callHandler(handler Handler, in *msg.Message) (reply *msg.Message, err error) {
+	defer func() {
+		if r := recover(); nil != r {
+			err = errors.Errorf(constant.SystemInternalError, "SED emulator: handler panic:%v", r)
+		}
+	}()
+
+	return handler(context.Background(), in)
+}
+
+func (e *Emulator) handleRequestReply(ctx *fasthttp.RequestCtx) {
+	version, in, err := e.readMessage(ctx)
+	if nil != err {
+		writeError(ctx, version, err)
+		return
+	}
+
+	var fault *Fault
+	if f, ok := e.faultOf(in.GetMsgTopicId()); ok {
+		fault = &f
+	}
+	reply, err := e.dispatch(callback.SendRequestReplyMsgPath, in, fault)
+	if nil != fault && 0 != fault.HTTPStatus {
+		ctx.Error(err.Error(), fault.HTTPStatus)
+		return
+	}
+	if nil == err && nil == reply {
+		reply = &msg.Message{}
+	}
+	writeMessage(ctx, version, reply, err)
+}
+
+func (e *Emulator) handlePublish(ctx *fasthttp.RequestCtx) {
+	version, in, err := e.readMessage(ctx)
+	if nil != err {
+		writeError(ctx, version, err)
+		return
+	}
+
+	// the fault is injected into the publish response, the message is not delivered
+	topicID := in.GetMsgTopicId()
+	if fault, ok := e.faultOf(topicID); ok {
+		err = fault.error(topicID)
+		e.record(&Record{
+			Path:      callback.SendTopicMsgPath,
+			TopicType: in.GetMsgTopicType(),
+			TopicID:   topicID,
+			Request:   in,
+			Error:     err,
+			At:        time.Now(),
+		})
+		if 0 != fault.HTTPStatus {
+			ctx.Error(err.Error(), fault.HTTPStatus)
+			return
+		}
+		writeError(ctx, version, err)
+		return
+	}
+
+	go func() {
+		if _, err := e.dispatch(callback.SendTopicMsgPath, in, nil); nil != err {
+			log.Debugsf("SED emulator: failed to deliver the published message of topic ID:[%s], error:%v", topicID, err)
+		}
+	}()
+	writeError(ctx, version, nil)
+}
+
+func (e *Emulator) handleAck(ctx *fasthttp.RequestCtx) {
+	version, in, err := e.readMessage(ctx)
+	if nil == err {
+		e.record(&Record{
+			Path:      string(ctx.Path()),
+			TopicType: in.GetMsgTopicType(),
+			TopicID:   in.GetMsgTopicId(),
+			Request:   in,
+			At:        time.Now(),
+		})
+	}
+	writeError(ctx, version, err)
+}
+
+func (e *Emulator) handleStatus(ctx *fasthttp.RequestCtx) {
+	res := status.Response{
+		Status:        e.Status(),
+		Version:       e.options.Version,
+		ProtocolLevel: e.options.ProtocolLevel,
+	}
+	resBytes, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(res)
+	if nil != err {
+		ctx.Error(err.Error(), fasthttp.StatusInternalServerError)
+		return
+	}
+
+	ctx.Write(resBytes)
+}
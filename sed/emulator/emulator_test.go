@@ -0,0 +1,252 @@
+package emulator
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"git.multiverse.io/eventkit/kit/common/assert"
+	"git.multiverse.io/eventkit/kit/common/errors"
+	"git.multiverse.io/eventkit/kit/common/model/glsdef"
+	"git.multiverse.io/eventkit/kit/common/msg"
+	"git.multiverse.io/eventkit/kit/common/status"
+	"git.multiverse.io/eventkit/kit/constant"
+	"git.multiverse.io/eventkit/kit/sed/callback"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/valyala/fasthttp"
+)
+
+type echoExecutor struct{}
+
+func (e *echoExecutor) Init() error { return nil }
+
+func (e *echoExecutor) Handle(ctx context.Context, in *msg.Message) (*msg.Message, error) {
+	if "fail" == string(in.Body) {
+		return nil, errors.Errorf("BIZ0001", "business failed")
+	}
+
+	return &msg.Message{Body: append([]byte("echo:"), in.Body...)}, nil
+}
+
+func (e *echoExecutor) CallbackOptions() *callback.Options { return nil }
+
+func (e *echoExecutor) ResponseTemplate() string { return "" }
+
+func (e *echoExecutor) Destroy() error { return nil }
+
+func newMessage(topicType, topicID, body string) *msg.Message {
+	return &msg.Message{
+		TopicAttribute: map[string]string{
+			constant.TopicType: topicType,
+			constant.TopicID:   topicID,
+		},
+		Body: []byte(body),
+	}
+}
+
+func startEmulator(t *testing.T, opts ...Option) *Emulator {
+	emulator := New(opts...)
+	assert.True(t, nil == emulator.Start("127.0.0.1:0"))
+	callback.SetSedServerAddr(emulator.Addr())
+	return emulator
+}
+
+func TestEmulator_RequestReply(t *testing.T) {
+	emulator := startEmulator(t)
+	defer emulator.Close()
+	emulator.HandleExecutor("Echo", &echoExecutor{})
+
+	for _, protocolLevel := range []int{1, 2} {
+		status.ServerProtocolLevel = protocolLevel
+		reply, err := callback.SyncCall(newMessage(constant.TopicTypeBusiness, "Echo", "hello"), 5*time.Second)
+		assert.True(t, nil == err)
+		assert.Equal(t, "echo:hello", string(reply.Body))
+
+		// the business errors are wrapped into the error response
+		reply, err = callback.SyncCall(newMessage(constant.TopicTypeBusiness, "Echo", "fail"), 5*time.Second)
+		assert.True(t, nil == err)
+		assert.Equal(t, "BIZ0001", reply.GetAppPropertySilence(constant.ReturnErrorCode))
+	}
+
+	_, err := callback.SyncCall(newMessage(constant.TopicTypeBusiness, "Unknown", "hello"), 5*time.Second)
+	assert.NotNil(t, err)
+
+	records := emulator.Records("Echo")
+	assert.Equal(t, 4, len(records))
+	assert.Equal(t, callback.SendRequestReplyMsgPath, records[0].Path)
+	assert.Equal(t, "hello", string(records[0].Request.Body))
+	assert.Equal(t, 5, len(emulator.Records(AnyTopic)))
+
+	emulator.ResetRecords()
+	assert.Equal(t, 0, len(emulator.Records(AnyTopic)))
+}
+
+func TestEmulator_UnixSocket(t *testing.T) {
+	emulator := New()
+	assert.True(t, nil == emulator.StartUnix(filepath.Join(t.TempDir(), "sed.sock")))
+	defer emulator.Close()
+	emulator.HandleExecutor("Echo", &echoExecutor{})
+
+	callback.SetTransport(constant.CommUDS)
+	defer callback.SetTransport(constant.CommMesh)
+	callback.SetSedServerAddr(emulator.Addr())
+	defer callback.SetSedServerAddr(callback.DefaultServerAddress)
+	assert.True(t, strings.HasPrefix(emulator.Addr(), callback.UnixSocketAddressPrefix))
+
+	reply, err := callback.SyncCall(newMessage(constant.TopicTypeBusiness, "Echo", "hello"), 5*time.Second)
+	assert.True(t, nil == err)
+	assert.Equal(t, "echo:hello", string(reply.Body))
+
+	assert.True(t, nil == callback.Publish(newMessage(constant.TopicTypeBusiness, "Echo", "event")))
+	assert.True(t, emulator.WaitForRecords("Echo", 2, 5*time.Second))
+}
+
+func TestEmulator_BinaryFrameNegotiation(t *testing.T) {
+	status.ServerProtocolLevel = 0
+	callback.SetBinaryFrame(true)
+	defer callback.SetBinaryFrame(false)
+
+	// the binary frame is accepted by the server
+	emulator := startEmulator(t)
+	emulator.HandleExecutor("Echo", &echoExecutor{})
+	reply, err := callback.SyncCall(newMessage(constant.TopicTypeBusiness, "Echo", "hello"), 5*time.Second)
+	assert.True(t, nil == err)
+	assert.Equal(t, "echo:hello", string(reply.Body))
+	emulator.Close()
+
+	// the legacy server only accepts the base64 frame, the client falls back
+	legacy := startEmulator(t, WithProtocolLevel(1))
+	defer legacy.Close()
+	legacy.HandleExecutor("Echo", &echoExecutor{})
+	for i := 0; i < 2; i++ {
+		reply, err = callback.SyncCall(newMessage(constant.TopicTypeBusiness, "Echo", "hello"), 5*time.Second)
+		assert.True(t, nil == err)
+		assert.Equal(t, "echo:hello", string(reply.Body))
+	}
+	assert.Equal(t, 2, len(legacy.Records("Echo")))
+}
+
+func TestEmulator_PublishAndFaults(t *testing.T) {
+	emulator := startEmulator(t)
+	defer emulator.Close()
+	received := make(chan string, 10)
+	emulator.Handle(AnyTopic, func(ctx context.Context, in *msg.Message) (*msg.Message, error) {
+		received <- in.GetMsgTopicId()
+		return nil, nil
+	})
+
+	assert.True(t, nil == callback.Publish(newMessage(constant.TopicTypeBusiness, "Notify", "event")))
+	assert.True(t, emulator.WaitForRecords("Notify", 1, 5*time.Second))
+	assert.Equal(t, "Notify", <-received)
+
+	// the fault is injected twice
+	emulator.InjectFault("Notify", Fault{ErrorCode: "FAULT01", Times: 2})
+	for i := 0; i < 2; i++ {
+		err := callback.Publish(newMessage(constant.TopicTypeBusiness, "Notify", "event"))
+		assert.NotNil(t, err)
+	}
+	assert.True(t, nil == callback.Publish(newMessage(constant.TopicTypeBusiness, "Notify", "event")))
+	assert.True(t, emulator.WaitForRecords("Notify", 4, 5*time.Second))
+
+	// the HTTP status fault
+	emulator.InjectFault(AnyTopic, Fault{HTTPStatus: fasthttp.StatusServiceUnavailable})
+	_, err := callback.SyncCall(newMessage(constant.TopicTypeBusiness, "Query", "q"), 5*time.Second)
+	assert.NotNil(t, err)
+
+	// the latency causes the timeout
+	emulator.ClearFaults()
+	emulator.InjectLatency("Query", 500*time.Millisecond)
+	_, err = callback.SyncCall(newMessage(constant.TopicTypeBusiness, "Query", "q"), 100*time.Millisecond)
+	assert.NotNil(t, err)
+}
+
+func TestEmulator_PublishWithProbabilityFault(t *testing.T) {
+	emulator := startEmulator(t)
+	defer emulator.Close()
+	emulator.Handle("Notify", func(ctx context.Context, in *msg.Message) (*msg.Message, error) {
+		return nil, nil
+	})
+
+	// the fault is evaluated once, the accepted messages are always delivered
+	emulator.InjectFault("Notify", Fault{ErrorCode: "FAULT01", Probability: 0.5})
+	accepted := 0
+	for i := 0; i < 20; i++ {
+		if nil == callback.Publish(newMessage(constant.TopicTypeBusiness, "Notify", "event")) {
+			accepted++
+		}
+	}
+	assert.True(t, emulator.WaitForRecords("Notify", 20, 5*time.Second))
+	delivered := 0
+	for _, record := range emulator.Records("Notify") {
+		if nil == record.Error {
+			delivered++
+		}
+	}
+	assert.Equal(t, accepted, delivered)
+	assert.Equal(t, 20-accepted, emulator.faults["Notify"].injected)
+}
+
+func TestEmulator_Status(t *testing.T) {
+	emulator := startEmulator(t, WithStartupStepInterval(10*time.Millisecond))
+	getStatus := func() int {
+		req := fasthttp.AcquireRequest()
+		defer fasthttp.ReleaseRequest(req)
+		resp := fasthttp.AcquireResponse()
+		defer fasthttp.ReleaseResponse(resp)
+		req.SetRequestURI(emulator.Addr() + callback.ServerStatusGetPath)
+		assert.True(t, nil == fasthttp.DoTimeout(req, resp, 5*time.Second))
+		res := status.Response{}
+		assert.True(t, nil == jsoniter.Unmarshal(resp.Body(), &res))
+		assert.Equal(t, constant.ProtocolLevel, res.ProtocolLevel)
+		return res.Status
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for getStatus() != status.ServerStarted && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, status.ServerStarted, getStatus())
+
+	emulator.SetStatus(status.ServerPreStop)
+	assert.Equal(t, status.ServerPreStop, getStatus())
+
+	emulator.Close()
+	assert.Equal(t, status.ServerStop, emulator.Status())
+}
+
+func TestEmulator_GLS(t *testing.T) {
+	emulator := startEmulator(t)
+	defer emulator.Close()
+	emulator.SetTopicSuType(constant.TopicTypeBusiness, "Transfer", "ACC")
+	emulator.Bind("ACC", glsdef.Element{ElementType: "CUS", ElementID: "001"}, "su1")
+	emulator.Bind("CRD", glsdef.Element{ElementType: "CUS", ElementClass: "CUS", ElementID: "001"}, "su9")
+
+	dim := glsdef.Dimension{Topic: glsdef.Topic{TopicType: constant.TopicTypeBusiness, TopicID: "Transfer"}}
+	suType, code, err := callback.LookSuType(dim)
+	assert.True(t, nil == err)
+	assert.Equal(t, callback.Successful, code)
+	assert.Equal(t, "ACC", suType)
+
+	primarySu, _, err := callback.Lookup(dim, &glsdef.Element{ElementType: "CUS", ElementClass: "CUS", ElementID: "001"})
+	assert.True(t, nil == err)
+	assert.Equal(t, "su1", primarySu.SuID)
+
+	_, code, err = callback.Lookup(dim, &glsdef.Element{ElementType: "CUS", ElementID: "002"})
+	assert.NotNil(t, err)
+	assert.Equal(t, callback.NotFound, code)
+
+	lookups, _, err := callback.Lookups(dim, []glsdef.Element{{ElementType: "CUS", ElementID: "001"}, {ElementType: "CUS", ElementID: "002"}})
+	assert.True(t, nil == err)
+	assert.Equal(t, uint(2), lookups.Total)
+	assert.Equal(t, uint(1), lookups.Succ)
+
+	primarySus, _, err := callback.Looklist(&glsdef.Element{ElementType: "CUS", ElementID: "001"})
+	assert.True(t, nil == err)
+	assert.Equal(t, []glsdef.PrimarySu{{SuType: "ACC", SuID: "su1"}, {SuType: "CRD", SuID: "su9"}}, primarySus)
+
+	emulator.Unbind("ACC", glsdef.Element{ElementType: "CUS", ElementID: "001"})
+	_, code, _ = callback.Lookup(dim, &glsdef.Element{ElementType: "CUS", ElementID: "001"})
+	assert.Equal(t, callback.NotFound, code)
+}
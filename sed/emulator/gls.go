@@ -0,0 +1,212 @@
+package emulator
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"git.multiverse.io/eventkit/kit/common/model"
+	"git.multiverse.io/eventkit/kit/common/model/glsdef"
+	"git.multiverse.io/eventkit/kit/sed/callback"
+	jsoniter "github.com/json-iterator/go"
+	"github.com/valyala/fasthttp"
+)
+
+// glsStore is the in-memory GLS table of the emulator
+type glsStore struct {
+	lock sync.RWMutex
+	// suTypes maps the topic to the SU type
+	suTypes map[glsdef.Topic]string
+	// bindings maps the SU type and element to the SU ID
+	bindings map[string]map[glsdef.Element]string
+}
+
+func newGlsStore() *glsStore {
+	return &glsStore{
+		suTypes:  make(map[glsdef.Topic]string),
+		bindings: make(map[string]map[glsdef.Element]string),
+	}
+}
+
+// elementKeyOf returns the key of element, the element class defaults to the element type
+func elementKeyOf(element glsdef.Element) glsdef.Element {
+	if "" == element.ElementClass {
+		element.ElementClass = element.ElementType
+	}
+
+	return element
+}
+
+// SetTopicSuType sets the SU type of the topic that is returned by the GLS looksutype API
+func (e *Emulator) SetTopicSuType(topicType, topicID, suType string) {
+	e.gls.lock.Lock()
+	defer e.gls.lock.Unlock()
+
+	e.gls.suTypes[glsdef.Topic{TopicType: topicType, TopicID: topicID}] = suType
+}
+
+// Bind binds the element to the SU of the SU type
+func (e *Emulator) Bind(suType string, element glsdef.Element, suID string) {
+	e.gls.lock.Lock()
+	defer e.gls.lock.Unlock()
+
+	elements, ok := e.gls.bindings[suType]
+	if !ok {
+		elements = make(map[glsdef.Element]string)
+		e.gls.bindings[suType] = elements
+	}
+	elements[elementKeyOf(element)] = suID
+}
+
+// Unbind removes the binding of the element from the SU type
+func (e *Emulator) Unbind(suType string, element glsdef.Element) {
+	e.gls.lock.Lock()
+	defer e.gls.lock.Unlock()
+
+	delete(e.gls.bindings[suType], elementKeyOf(element))
+}
+
+func (g *glsStore) suTypeOf(dim *glsdef.Dimension) (string, bool) {
+	if nil == dim {
+		return "", false
+	}
+	if "" != dim.SuType {
+		return dim.SuType, true
+	}
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	suType, ok := g.suTypes[dim.Topic]
+	return suType, ok
+}
+
+func (g *glsStore) lookup(dim *glsdef.Dimension, element glsdef.Element) (*glsdef.PrimarySu, bool) {
+	suType, ok := g.suTypeOf(dim)
+	if !ok {
+		return nil, false
+	}
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	suID, ok := g.bindings[suType][elementKeyOf(element)]
+	if !ok {
+		return nil, false
+	}
+
+	return &glsdef.PrimarySu{SuType: suType, SuID: suID}, true
+}
+
+func (g *glsStore) looklist(element glsdef.Element) []glsdef.PrimarySu {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	primarySus := make([]glsdef.PrimarySu, 0)
+	key := elementKeyOf(element)
+	for suType, elements := range g.bindings {
+		if suID, ok := elements[key]; ok {
+			primarySus = append(primarySus, glsdef.PrimarySu{SuType: suType, SuID: suID})
+		}
+	}
+	sort.Slice(primarySus, func(i, j int) bool {
+		return primarySus[i].SuType < primarySus[j].SuType
+	})
+
+	return primarySus
+}
+
+func writeGlsResponse(ctx *fasthttp.RequestCtx, data interface{}) {
+	resBytes, err := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(model.CommonResponse{Data: data})
+	if nil != err {
+		ctx.Error(err.Error(), fasthttp.StatusInternalServerError)
+		return
+	}
+
+	ctx.Write(resBytes)
+}
+
+func readGlsRequest(ctx *fasthttp.RequestCtx, request interface{}) bool {
+	if err := jsoniter.ConfigCompatibleWithStandardLibrary.Unmarshal(ctx.PostBody(), request); nil != err {
+		resBytes, _ := jsoniter.ConfigCompatibleWithStandardLibrary.Marshal(model.BuildErrorResponse(err.Error()))
+		ctx.Write(resBytes)
+		return false
+	}
+
+	return true
+}
+
+func (e *Emulator) handleGlsLookup(ctx *fasthttp.RequestCtx) {
+	args := glsdef.LookupArgs{}
+	if !readGlsRequest(ctx, &args) {
+		return
+	}
+
+	primarySu, ok := e.gls.lookup(args.Dimesion, args.Element)
+	if !ok {
+		writeGlsResponse(ctx, callback.ReplyLookupPrimary{
+			Code:    callback.NotFound,
+			Message: fmt.Sprintf("SED emulator: element[%s/%s] not found", args.ElementType, args.ElementID),
+		})
+		return
+	}
+	writeGlsResponse(ctx, callback.ReplyLookupPrimary{
+		Code: callback.Successful,
+		Data: &callback.PrimarySuResult{Code: callback.Successful, PrimarySu: primarySu},
+	})
+}
+
+func (e *Emulator) handleGlsLookups(ctx *fasthttp.RequestCtx) {
+	args := glsdef.LookupsArgs{}
+	if !readGlsRequest(ctx, &args) {
+		return
+	}
+
+	result := &glsdef.LookupsStruct{
+		Total:  uint(len(args.Elements)),
+		PrmSus: make([]glsdef.PrimarySu, 0, len(args.Elements)),
+	}
+	for _, element := range args.Elements {
+		if primarySu, ok := e.gls.lookup(args.Dimesion, element); ok {
+			result.Succ++
+			result.PrmSus = append(result.PrmSus, *primarySu)
+		} else {
+			result.PrmSus = append(result.PrmSus, glsdef.PrimarySu{})
+		}
+	}
+	writeGlsResponse(ctx, callback.ReplyLookupsPrimary{
+		Code: callback.Successful,
+		Data: result,
+	})
+}
+
+func (e *Emulator) handleGlsLookSuType(ctx *fasthttp.RequestCtx) {
+	dim := glsdef.Dimension{}
+	if !readGlsRequest(ctx, &dim) {
+		return
+	}
+
+	dim.SuType = ""
+	suType, ok := e.gls.suTypeOf(&dim)
+	if !ok {
+		writeGlsResponse(ctx, callback.ReplyLookSutypePrimary{
+			Code:    callback.NotFound,
+			Message: fmt.Sprintf("SED emulator: SU type of topic[%s/%s] not found", dim.Topic.TopicType, dim.Topic.TopicID),
+		})
+		return
+	}
+	writeGlsResponse(ctx, callback.ReplyLookSutypePrimary{
+		Code: callback.Successful,
+		Data: suType,
+	})
+}
+
+func (e *Emulator) handleGlsLooklist(ctx *fasthttp.RequestCtx) {
+	element := glsdef.Element{}
+	if !readGlsRequest(ctx, &element) {
+		return
+	}
+
+	writeGlsResponse(ctx, callback.ReplyLookupPrimaryList{
+		Code: callback.Successful,
+		Data: e.gls.looklist(element),
+	})
+}